@@ -13,7 +13,6 @@ import (
 	"github.com/flare-foundation/go-flare-common/pkg/database"
 	"github.com/flare-foundation/go-flare-common/pkg/events"
 	"github.com/flare-foundation/go-flare-common/pkg/logger"
-	"github.com/flare-foundation/go-flare-common/pkg/priority"
 	"github.com/pkg/errors"
 
 	bitvotes "github.com/flare-foundation/fdc-client/client/attestation/bitVotes"
@@ -34,6 +33,23 @@ const (
 	Failed
 )
 
+var roundStatusNames = [...]string{
+	Unassigned:   "unassigned",
+	PreConsensus: "pre_consensus",
+	Consensus:    "consensus",
+	Done:         "done",
+	Failed:       "failed",
+}
+
+// String returns the name of the round status.
+func (s RoundStatus) String() string {
+	if s < 0 || int(s) >= len(roundStatusNames) {
+		return "unknown"
+	}
+
+	return roundStatusNames[s]
+}
+
 type RoundStatusMutex struct {
 	Value RoundStatus
 	sync.RWMutex
@@ -52,8 +68,32 @@ const (
 	Retrying
 	ProcessError
 	Unconfirmed
+	Disagreement
 )
 
+var statusNames = [...]string{
+	Unprocessed:     "unprocessed",
+	UnsupportedPair: "unsupported_pair",
+	Waiting:         "waiting",
+	Processing:      "processing",
+	Success:         "success",
+	WrongMIC:        "wrong_mic",
+	InvalidLUT:      "invalid_lut",
+	Retrying:        "retrying",
+	ProcessError:    "process_error",
+	Unconfirmed:     "unconfirmed",
+	Disagreement:    "disagreement",
+}
+
+// String returns the name of the status.
+func (s Status) String() string {
+	if s < 0 || int(s) >= len(statusNames) {
+		return "unknown"
+	}
+
+	return statusNames[s]
+}
+
 // fdcFilterer is only used for Attestation Requests logs parsing. Set in init().
 var fdcFilterer *fdchub.FdcHubFilterer
 
@@ -95,13 +135,16 @@ type Attestation struct {
 	Status            Status
 	Consensus         bool
 	Hash              common.Hash
+	Reverted          bool // all request logs of the attestation were reverted from the chain
 	ResponseABI       *abi.Arguments
 	ResponseABIString *string
 	LUTLimit          uint64
 	QueueName         string
-	Credentials       *VerifierCredentials
-
-	QueuePointer *priority.Item[priority.Wrapped[*Attestation], Weight]
+	Verifiers         []VerifierCredentials
+	VerificationMode  string // one of config.ModeFailover, config.ModeRace, config.ModeQuorum
+	Quorum            int    // number of identical responses required in config.ModeQuorum
+	Attempts          int    // number of verification attempts
+	ConfigVersion     uint64 // version of the configuration the request was prepared with, set by the manager
 
 	sync.RWMutex
 }
@@ -161,6 +204,11 @@ func (a *Attestation) Discard(ctx context.Context) bool {
 		return true
 	}
 
+	if a.Reverted {
+		logger.Debugf("discarding reverted request from round %d", a.RoundID)
+		return true
+	}
+
 	if a.RoundStatus.Value == Done {
 		logger.Debugf("discarding request from finished round %d", a.RoundID)
 		return true
@@ -180,7 +228,14 @@ func (a *Attestation) Handle(ctx context.Context) error {
 	a.Lock()
 	defer a.Unlock()
 
+	a.Attempts++
+
 	responseBytes, confirmed, err := ResolveAttestationRequest(ctx, a)
+	if errors.Is(err, ErrNoQuorum) {
+		a.Status = Disagreement
+		logger.Warnf("verifiers of attestation request %s for round %d disagree: %s", a.Request.TypeAndSourceString(), a.RoundID, err)
+		return errors.Wrap(err, "unable to resolve attestation request")
+	}
 	if err != nil {
 		a.Status = ProcessError
 		return errors.Wrap(err, "unable to resolve attestation request")
@@ -200,8 +255,28 @@ func (a *Attestation) Handle(ctx context.Context) error {
 	return nil
 }
 
-// prepareRequest adds response ABI, LUT limit and verifierCredentials to the Attestation.
-func (a *Attestation) PrepareRequest(attestationTypesConfigs config.AttestationTypes) error {
+// SetResponse validates a response that was obtained without the verifiers, e.g., read from a file, and saves it in the struct.
+// The request has to be prepared first.
+func (a *Attestation) SetResponse(response Response) error {
+	a.Lock()
+	defer a.Unlock()
+
+	if a.ResponseABI == nil {
+		return errors.New("request not prepared, no response abi")
+	}
+
+	a.Response = response
+	err := a.validateResponse()
+	if err != nil {
+		return errors.Wrap(err, "unable to validate attestation response")
+	}
+
+	return nil
+}
+
+// prepareRequest adds response ABI, LUT limit and verifiers to the Attestation.
+// If breakers is not nil, circuit breakers of the verifiers are attached.
+func (a *Attestation) PrepareRequest(attestationTypesConfigs config.AttestationTypes, breakers *Breakers) error {
 	a.Lock()
 	defer a.Unlock()
 
@@ -233,13 +308,32 @@ func (a *Attestation) PrepareRequest(attestationTypesConfigs config.AttestationT
 	}
 
 	a.LUTLimit = sourceConfig.LUTLimit
-	a.Credentials = &VerifierCredentials{sourceConfig.URL, sourceConfig.APIKey}
+	a.Verifiers = make([]VerifierCredentials, len(sourceConfig.Verifiers))
+	for i, verifier := range sourceConfig.Verifiers {
+		a.Verifiers[i] = newVerifierCredentials(verifier, &sourceConfig, breakers)
+	}
+	a.VerificationMode = sourceConfig.Mode
+	a.Quorum = sourceConfig.Quorum
 	a.QueueName = sourceConfig.QueueName
 	a.Status = Processing
 
 	return nil
 }
 
+// VerifiersAvailable returns true if at least one of the verifiers of the attestation has a closed circuit breaker.
+func (a *Attestation) VerifiersAvailable() bool {
+	a.RLock()
+	defer a.RUnlock()
+
+	for i := range a.Verifiers {
+		if a.Verifiers[i].available() {
+			return true
+		}
+	}
+
+	return false
+}
+
 // validateResponse checks the MIC and LUT of the attestation. If both conditions pass, hash is computed and added to the attestation.
 func (a *Attestation) validateResponse() error {
 	// MIC
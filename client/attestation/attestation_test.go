@@ -74,9 +74,9 @@ func TestHandleAttestation(t *testing.T) {
 	att, err := attestation.AttestationFromDatabaseLog(testLog)
 	require.NoError(t, err)
 
-	err = att.PrepareRequest(attestationTypesConfigs)
+	err = att.PrepareRequest(attestationTypesConfigs, nil)
 	require.NoError(t, err)
-	att.Credentials.URL = "http://localhost:5555"
+	att.Verifiers[0].URL = "http://localhost:5555"
 
 	go mocks.MockVerifierForTests(t, 5555, testResponse, testLog)
 	time.Sleep(1 * time.Second)
@@ -1,14 +1,33 @@
 package bitvotes
 
 import (
+	"context"
 	"math"
 	"math/big"
 	"slices"
 )
 
+// contextCheckInterval is the number of operations between two checks whether the context of a branch and bound strategy is done.
+const contextCheckInterval = 10_000
+
 type SharedStatus struct {
 	CurrentBound  Value
 	NumOperations int
+	Interrupted   bool // true if the context of the strategy was done before the search space was explored
+
+	nextContextCheck int
+}
+
+// interrupted reports whether ctx is done. The context is checked once in contextCheckInterval operations.
+func (s *SharedStatus) interrupted(ctx context.Context) bool {
+	if s.Interrupted || s.NumOperations < s.nextContextCheck {
+		return s.Interrupted
+	}
+
+	s.nextContextCheck = s.NumOperations + contextCheckInterval
+	s.Interrupted = ctx.Err() != nil
+
+	return s.Interrupted
 }
 
 type ProcessInfo struct {
@@ -21,6 +40,8 @@ type ProcessInfo struct {
 
 	MaxOperations int
 	ExcludeFirst  bool
+
+	ctx context.Context // the search stops when ctx is done
 }
 
 type branchAndBoundPartialSolution struct {
@@ -30,8 +51,8 @@ type branchAndBoundPartialSolution struct {
 }
 
 type Value struct {
-	CappedValue   *big.Int
-	UncappedValue *big.Int
+	CappedValue   *big.Int `json:"cappedValue"`
+	UncappedValue *big.Int `json:"uncappedValue"`
 }
 
 func (v Value) Copy() Value {
@@ -104,8 +125,12 @@ func sortFees(bits []*AggregatedBit, sortFunc func(*AggregatedBit, *AggregatedBi
 // The second strategy sorts the aggregated bits by the ascending value (cappedSupport * fee) and at depth k the branch in which does not include k-th bit is explored first.
 //
 // If both strategies find an optimal but different solutions, the solution of the first strategy is returned.
-func BranchAndBoundBitsDouble(bitVotes []*AggregatedVote, bits []*AggregatedBit, assumedWeight, weightVoted, absoluteTotalWeight uint16,
+// If the first strategy finds an optimal solution, the second one is stopped.
+func BranchAndBoundBitsDouble(ctx context.Context, bitVotes []*AggregatedVote, bits []*AggregatedBit, assumedWeight, weightVoted, absoluteTotalWeight uint16,
 	assumedFees *big.Int, maxOperations int, initialBound Value) *ConsensusSolution {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	solutions := make([]*ConsensusSolution, 2)
 
 	firstDone := make(chan bool, 1)
@@ -116,7 +141,7 @@ func BranchAndBoundBitsDouble(bitVotes []*AggregatedVote, bits []*AggregatedBit,
 	bitsDscVal := sortFees(bits, cmpValDsc(absoluteTotalWeight))
 
 	go func() {
-		solution := BranchAndBoundBits(bitVotes, bitsDscVal, assumedWeight, weightVoted, absoluteTotalWeight, assumedFees, maxOperations, initialBound, false)
+		solution := BranchAndBoundBits(ctx, bitVotes, bitsDscVal, assumedWeight, weightVoted, absoluteTotalWeight, assumedFees, maxOperations, initialBound, false)
 		solutions[0] = solution
 
 		if solution.Optimal {
@@ -129,7 +154,7 @@ func BranchAndBoundBitsDouble(bitVotes []*AggregatedVote, bits []*AggregatedBit,
 	}()
 
 	go func() {
-		solution := BranchAndBoundBits(bitVotes, bitsAscVal, assumedWeight, weightVoted, absoluteTotalWeight, assumedFees, maxOperations, initialBound, true)
+		solution := BranchAndBoundBits(ctx, bitVotes, bitsAscVal, assumedWeight, weightVoted, absoluteTotalWeight, assumedFees, maxOperations, initialBound, true)
 
 		solutions[1] = solution // no problem in two processes writing to the same place, since in that case the solution not used
 		secondDone <- true
@@ -158,7 +183,9 @@ func BranchAndBoundBitsDouble(bitVotes []*AggregatedVote, bits []*AggregatedBit,
 // through the entire solution space before reaching the given max operations counter, the algorithm
 // gives an optimal solution. If solution space is too big, the algorithm gives a
 // the best solution it finds. If not solution exceeding initialBound is found, no solution (nil) is returned.
+// If ctx is done before the search space is explored, the best solution found so far is returned as not optimal.
 func BranchAndBoundBits(
+	ctx context.Context,
 	bitVotes []*AggregatedVote,
 	bits []*AggregatedBit,
 	assumedWeight, weightVoted, absoluteTotalWeight uint16,
@@ -191,18 +218,26 @@ func BranchAndBoundBits(
 		NumProviders:     len(bitVotes),
 		MaxOperations:    maxOperations,
 		ExcludeFirst:     excludeBitFirst,
+		ctx:              ctx,
 	}
 
 	provisionalResult := BranchBits(processInfo, currentStatus, 0, includedVotes, weightVoted, totalFee)
-	isOptimal := currentStatus.NumOperations < maxOperations
+	isOptimal := currentStatus.NumOperations < maxOperations && !currentStatus.Interrupted
+
+	method := MethodBitsDescending
+	if excludeBitFirst {
+		method = MethodBitsAscending
+	}
 
 	// empty solution
 	if provisionalResult == nil {
 		return &ConsensusSolution{
-			Votes:   bitVotes,
-			Bits:    []*AggregatedBit{},
-			Value:   Value{big.NewInt(0), big.NewInt(0)},
-			Optimal: false,
+			Votes:      bitVotes,
+			Bits:       []*AggregatedBit{},
+			Value:      Value{big.NewInt(0), big.NewInt(0)},
+			Optimal:    false,
+			Method:     method,
+			Operations: currentStatus.NumOperations,
 		}
 	}
 
@@ -211,16 +246,18 @@ func BranchAndBoundBits(
 	}
 
 	result := ConsensusSolution{
-		Votes:   make([]*AggregatedVote, 0),
-		Bits:    make([]*AggregatedBit, 0),
-		Optimal: isOptimal,
-		Value:   provisionalResult.Value,
+		Votes:      make([]*AggregatedVote, 0),
+		Bits:       make([]*AggregatedBit, 0),
+		Optimal:    isOptimal,
+		Value:      provisionalResult.Value,
+		Method:     method,
+		Operations: currentStatus.NumOperations,
 	}
 
-	for key := range provisionalResult.Votes {
+	for _, key := range sortedKeys(provisionalResult.Votes) {
 		result.Votes = append(result.Votes, bitVotes[key])
 	}
-	for key := range provisionalResult.Bits {
+	for _, key := range sortedKeys(provisionalResult.Bits) {
 		result.Bits = append(result.Bits, bits[key])
 	}
 
@@ -248,8 +285,8 @@ func BranchBits(processInfo *ProcessInfo, currentStatus *SharedStatus, branch in
 		return nil
 	}
 
-	// check if we already reached the maximal search space
-	if currentStatus.NumOperations >= processInfo.MaxOperations {
+	// check if we already reached the maximal search space or ran out of time
+	if currentStatus.NumOperations >= processInfo.MaxOperations || currentStatus.interrupted(processInfo.ctx) {
 		return nil
 	}
 
@@ -1,6 +1,7 @@
 package bitvotes_test
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 	"testing"
@@ -36,6 +37,7 @@ func TestBranchAndBoundRandom(t *testing.T) {
 
 	start := time.Now()
 	solution := bitvotes.BranchAndBoundBits(
+		context.Background(),
 		aggBitVotes,
 		fees,
 		0,
@@ -84,7 +86,7 @@ func TestBranchAndBound65(t *testing.T) {
 
 	start := time.Now()
 
-	solution := bitvotes.BranchAndBoundBits(aggregatedVotes, aggregatedFees, filterResults.GuaranteedWeight, totalWeight, totalWeight, filterResults.GuaranteedFees, 20000000, initialBound, true)
+	solution := bitvotes.BranchAndBoundBits(context.Background(), aggregatedVotes, aggregatedFees, filterResults.GuaranteedWeight, totalWeight, totalWeight, filterResults.GuaranteedFees, 20000000, initialBound, true)
 
 	finalSolution := AssembleSolutionFull(filterResults, solution)
 
@@ -130,7 +132,7 @@ func TestBranchAndBoundFix(t *testing.T) {
 	initialBound := bitvotes.Value{big.NewInt(0), big.NewInt(0)}
 
 	start := time.Now()
-	solution := bitvotes.BranchAndBoundBits(weightedBitvotes, fees, 0, totalWeight, totalWeight, big.NewInt(0), 50000000, initialBound, true)
+	solution := bitvotes.BranchAndBoundBits(context.Background(), weightedBitvotes, fees, 0, totalWeight, totalWeight, big.NewInt(0), 50000000, initialBound, true)
 
 	fmt.Println("time passed:", time.Since(start).Seconds())
 
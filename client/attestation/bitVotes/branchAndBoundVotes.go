@@ -1,6 +1,7 @@
 package bitvotes
 
 import (
+	"context"
 	"math/big"
 	"slices"
 )
@@ -50,7 +51,9 @@ func sortVotes(votes []*AggregatedVote, sortFunc func(*AggregatedVote, *Aggregat
 // The second strategy sorts the aggregated votes by the ascending value (weight * fee) and at depth k the branch in which does not include k-th vote is explored first.
 //
 // If both strategies find an optimal but different solutions, the solution of the first strategy is returned.
+// If the first strategy finds an optimal solution, the second one is stopped.
 func BranchAndBoundVotesDouble(
+	ctx context.Context,
 	bitVotes []*AggregatedVote,
 	bits []*AggregatedBit,
 	assumedWeight, weightVoted, absoluteTotalWeight uint16,
@@ -58,6 +61,9 @@ func BranchAndBoundVotesDouble(
 	maxOperations int,
 	initialBound Value,
 ) *ConsensusSolution {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	solutions := make([]*ConsensusSolution, 2)
 
 	firstDone := make(chan bool, 1)
@@ -69,6 +75,7 @@ func BranchAndBoundVotesDouble(
 
 	go func() {
 		solution := BranchAndBoundVotes(
+			ctx,
 			votesDscVal,
 			bits,
 			assumedWeight,
@@ -93,6 +100,7 @@ func BranchAndBoundVotesDouble(
 
 	go func() {
 		solution := BranchAndBoundVotes(
+			ctx,
 			votesAscVal,
 			bits,
 			assumedWeight,
@@ -127,7 +135,9 @@ func BranchAndBoundVotesDouble(
 // BranchAndBoundVotes is similar to BranchAndBound, the difference is that it
 // executes a branch and bound strategy on the space of subsets of bitVotes, hence
 // it is particularly useful when there are not too many distinct bitVotes.
+// If ctx is done before the search space is explored, the best solution found so far is returned as not optimal.
 func BranchAndBoundVotes(
+	ctx context.Context,
 	bitVotes []*AggregatedVote,
 	bits []*AggregatedBit,
 	assumedWeight, weightVoted, absoluteTotalWeight uint16,
@@ -157,19 +167,27 @@ func BranchAndBoundVotes(
 		NumProviders:     len(bitVotes),
 		MaxOperations:    maxOperations,
 		ExcludeFirst:     strategy,
+		ctx:              ctx,
 	}
 
 	provisionalResult := BranchVotes(processInfo, currentStatus, 0, includedBits, totalFee, weightVoted)
 
-	isOptimal := currentStatus.NumOperations < maxOperations
+	isOptimal := currentStatus.NumOperations < maxOperations && !currentStatus.Interrupted
+
+	method := MethodVotesDescending
+	if strategy {
+		method = MethodVotesAscending
+	}
 
 	// empty solution
 	if provisionalResult == nil {
 		return &ConsensusSolution{
-			Votes:   bitVotes,
-			Bits:    []*AggregatedBit{},
-			Value:   Value{big.NewInt(0), big.NewInt(0)},
-			Optimal: false,
+			Votes:      bitVotes,
+			Bits:       []*AggregatedBit{},
+			Value:      Value{big.NewInt(0), big.NewInt(0)},
+			Optimal:    false,
+			Method:     method,
+			Operations: currentStatus.NumOperations,
 		}
 	}
 
@@ -178,16 +196,18 @@ func BranchAndBoundVotes(
 	}
 
 	result := ConsensusSolution{
-		Votes:   make([]*AggregatedVote, 0),
-		Bits:    make([]*AggregatedBit, 0),
-		Optimal: isOptimal,
-		Value:   provisionalResult.Value,
+		Votes:      make([]*AggregatedVote, 0),
+		Bits:       make([]*AggregatedBit, 0),
+		Optimal:    isOptimal,
+		Value:      provisionalResult.Value,
+		Method:     method,
+		Operations: currentStatus.NumOperations,
 	}
 
-	for key := range provisionalResult.Votes {
+	for _, key := range sortedKeys(provisionalResult.Votes) {
 		result.Votes = append(result.Votes, bitVotes[key])
 	}
-	for key := range provisionalResult.Bits {
+	for _, key := range sortedKeys(provisionalResult.Bits) {
 		result.Bits = append(result.Bits, bits[key])
 	}
 
@@ -221,8 +241,8 @@ func BranchVotes(
 		return nil
 	}
 
-	// check if we already reached the maximal search space
-	if currentStatus.NumOperations >= processInfo.MaxOperations {
+	// check if we already reached the maximal search space or ran out of time
+	if currentStatus.NumOperations >= processInfo.MaxOperations || currentStatus.interrupted(processInfo.ctx) {
 		return nil
 	}
 
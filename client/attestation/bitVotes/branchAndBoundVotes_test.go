@@ -1,6 +1,7 @@
 package bitvotes_test
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 	"testing"
@@ -47,6 +48,7 @@ func TestBranchAndBoundProvidersFix(t *testing.T) {
 
 	start := time.Now()
 	solution := bitvotes.BranchAndBoundVotes(
+		context.Background(),
 		aggregatedVotes,
 		aggregatedFees,
 		filterResults.GuaranteedWeight,
@@ -68,6 +70,7 @@ func TestBranchAndBoundProvidersFix(t *testing.T) {
 	fmt.Printf("finalSolution.Bits: %v\n", finalSolution.Bits)
 
 	solutionTest := bitvotes.BranchAndBoundBits(
+		context.Background(),
 		aggregatedVotes,
 		aggregatedFees,
 		filterResults.GuaranteedWeight,
@@ -85,6 +88,7 @@ func TestBranchAndBoundProvidersFix(t *testing.T) {
 	require.Equal(t, finalSolutionTest.Value, finalSolution.Value)
 
 	solution2 := bitvotes.BranchAndBoundVotes(
+		context.Background(),
 		aggregatedVotes,
 		aggregatedFees,
 		filterResults.GuaranteedWeight,
@@ -127,6 +131,7 @@ func TestBranchAndBoundProvidersRandom(t *testing.T) {
 	start := time.Now()
 
 	solution := bitvotes.BranchAndBoundVotes(
+		context.Background(),
 		aggregatedVotes,
 		aggregatedFees,
 		filterResults.GuaranteedWeight,
@@ -144,6 +149,7 @@ func TestBranchAndBoundProvidersRandom(t *testing.T) {
 	start2 := time.Now()
 
 	solutionTest := bitvotes.BranchAndBoundBits(
+		context.Background(),
 		aggregatedVotes,
 		aggregatedFees,
 		filterResults.GuaranteedWeight,
@@ -170,6 +176,7 @@ func TestBranchAndBoundProvidersRandom(t *testing.T) {
 	require.Equal(t, solution.Value, solutionTest.Value)
 
 	solution2 := bitvotes.BranchAndBoundVotes(
+		context.Background(),
 		aggregatedVotes,
 		aggregatedFees,
 		filterResults.GuaranteedWeight,
@@ -1,8 +1,10 @@
 package bitvotes_test
 
 import (
+	"context"
 	"fmt"
 	"math/big"
+	"math/rand"
 	"testing"
 	"time"
 
@@ -50,7 +52,7 @@ func TestEnsembleRandom(t *testing.T) {
 
 	start = time.Now()
 
-	solutionCheck := bitvotes.BranchAndBoundBits(aggregatedBitVotes, aggFees, 0, totalWeight, totalWeight, big.NewInt(0), 100000000, bitvotes.Value{big.NewInt(0), big.NewInt(0)}, false)
+	solutionCheck := bitvotes.BranchAndBoundBits(context.Background(), aggregatedBitVotes, aggFees, 0, totalWeight, totalWeight, big.NewInt(0), 100000000, bitvotes.Value{big.NewInt(0), big.NewInt(0)}, false)
 
 	fmt.Println("time passed:", time.Since(start).Seconds())
 	fmt.Printf("solution: %v\n", solution)
@@ -113,7 +115,74 @@ func TestEnsembleFixed(t *testing.T) {
 }
 
 func EnsembleFull(allBitVotes []*bitvotes.WeightedBitVote, fees []*big.Int, totalWeight uint16, maxOperations int) Solution {
-	filterResults, filterSolution, _ := bitvotes.Ensemble(allBitVotes, fees, totalWeight, maxOperations)
+	filterResults, filterSolution, _ := bitvotes.Ensemble(context.Background(), allBitVotes, fees, totalWeight, maxOperations, true)
 
 	return AssembleSolutionFull(filterResults, filterSolution)
 }
+
+func TestEnsembleExplained(t *testing.T) {
+	positions := [][]int{{0, 1, 2, 3}, {0, 1, 2, 3}, {0, 1, 2, 3}, {0, 1, 4}, {0, 5}}
+
+	weightedBitVotes := make([]*bitvotes.WeightedBitVote, len(positions))
+	for j := range positions {
+		weightedBitVotes[j] = setBitVoteFromPositions(6, positions[j])
+		weightedBitVotes[j].Index = 10 + j
+	}
+
+	fees := make([]*big.Int, 6)
+	for j := range fees {
+		fees[j] = big.NewInt(1)
+	}
+
+	consensus, explanation, err := bitvotes.EnsembleConsensusBitVoteExplained(context.Background(), weightedBitVotes, fees, 5, 1000, true)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(0b1111), consensus.BitVector)
+
+	require.Equal(t, []int{0}, explanation.AlwaysInBits)
+	require.Equal(t, []int{4, 5}, explanation.AlwaysOutBits)
+	require.Equal(t, []int{0, 1, 2, 3}, explanation.Chosen)
+	require.Equal(t, []int{10, 11, 12}, explanation.Supporters)
+	require.Equal(t, uint16(3), explanation.SupportWeight)
+	require.Equal(t, bitvotes.Value{big.NewInt(12), big.NewInt(12)}, explanation.Value)
+	require.True(t, explanation.Optimal)
+	require.NotEmpty(t, explanation.Method)
+	require.LessOrEqual(t, explanation.Operations, explanation.MaxOperations)
+	require.Equal(t, 1000, explanation.MaxOperations)
+
+	// without a majority, only the filtering is explained
+	_, explanation, err = bitvotes.EnsembleConsensusBitVoteExplained(context.Background(), weightedBitVotes[3:], fees, 5, 1000, true)
+	require.Error(t, err)
+	require.NotNil(t, explanation)
+	require.Empty(t, explanation.Method)
+	require.Empty(t, explanation.Chosen)
+}
+
+func TestEnsembleDeadline(t *testing.T) {
+	// clustered voters with noise, on which the strategies do not finish within the deadline
+	round := generateRound(rand.New(rand.NewSource(4)), 300, 100, 5, 0.95, 0.005)
+	weightedBitVotes, fees, totalWeight := round.bitVotes, round.fees, round.totalWeight
+
+	t.Run("deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		consensus, explanation, err := bitvotes.EnsembleConsensusBitVoteExplained(ctx, weightedBitVotes, fees, totalWeight, 1_000_000_000, true)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Less(t, time.Since(start), 5*time.Second)
+
+		// the partial result is not returned
+		require.Nil(t, consensus.BitVector)
+		require.NotNil(t, explanation)
+		require.Empty(t, explanation.Method)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, explanation, err := bitvotes.EnsembleConsensusBitVoteExplained(ctx, weightedBitVotes, fees, totalWeight, 1_000_000_000, true)
+		require.ErrorIs(t, err, context.Canceled)
+		require.NotNil(t, explanation)
+	})
+}
@@ -1,20 +1,44 @@
 package bitvotes
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 )
 
+// Strategies that can find the consensus solution.
+const (
+	MethodBitsDescending  = "bits-descending"  // branch on bits sorted by descending value, including the bit first
+	MethodBitsAscending   = "bits-ascending"   // branch on bits sorted by ascending value, excluding the bit first
+	MethodVotesDescending = "votes-descending" // branch on votes sorted by descending value, including the vote first
+	MethodVotesAscending  = "votes-ascending"  // branch on votes sorted by ascending value, excluding the vote first
+	MethodExactVotes      = "exact-votes"      // dynamic programming over intersections of votes
+)
+
 type ConsensusSolution struct {
-	Votes   []*AggregatedVote // set of votes that support the solution
-	Bits    []*AggregatedBit  // set of bits that are confirmed
-	Value   Value
-	Optimal bool // if true the solution is optimal. If false, it still might be optimal
+	Votes      []*AggregatedVote // set of votes that support the solution
+	Bits       []*AggregatedBit  // set of bits that are confirmed
+	Value      Value
+	Optimal    bool   // if true the solution is optimal. If false, it still might be optimal
+	Method     string // strategy that found the solution
+	Operations int    // number of operations used by the strategy
 }
 
-func ensemble(allBitVotes []*WeightedBitVote, fees []*big.Int, totalWeight uint16, maxOperations int) (*FilterResults, *ConsensusSolution, error) {
+func ensemble(ctx context.Context, allBitVotes []*WeightedBitVote, fees []*big.Int, totalWeight uint16, maxOperations int, exactVotes bool) (*FilterResults, *ConsensusSolution, error) {
 	aggregatedVotes, aggregatedFees, filterResults := FilterAndAggregate(allBitVotes, fees, totalWeight)
 
+	solution, err := solve(ctx, aggregatedVotes, aggregatedFees, filterResults, totalWeight, maxOperations, exactVotes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return filterResults, solution, nil
+}
+
+// solve runs the branch and bound strategies on the aggregated votes and bits, starting with the ones that branch on the smaller set.
+// If exactVotes is true and none of them is known to be optimal, the exact search over votes is run.
+// A later strategy is used only if it finds a strictly better solution. If ctx is done before the search finishes, an error is returned.
+func solve(ctx context.Context, aggregatedVotes []*AggregatedVote, aggregatedFees []*AggregatedBit, filterResults *FilterResults, totalWeight uint16, maxOperations int, exactVotes bool) (*ConsensusSolution, error) {
 	method0, method1 := BranchAndBoundBitsDouble, BranchAndBoundVotesDouble
 	if len(aggregatedVotes) < len(aggregatedFees) {
 		method0, method1 = BranchAndBoundVotesDouble, BranchAndBoundBitsDouble
@@ -27,11 +51,12 @@ func ensemble(allBitVotes []*WeightedBitVote, fees []*big.Int, totalWeight uint1
 	}
 
 	if weightVoted <= totalWeight/2 {
-		return nil, nil, fmt.Errorf("only %.1f voted", 100*float64(weightVoted)/float64(totalWeight))
+		return nil, fmt.Errorf("only %.1f voted", 100*float64(weightVoted)/float64(totalWeight))
 	}
 
 	var solution *ConsensusSolution
 	solution = method0(
+		ctx,
 		aggregatedVotes,
 		aggregatedFees,
 		filterResults.GuaranteedWeight,
@@ -43,6 +68,7 @@ func ensemble(allBitVotes []*WeightedBitVote, fees []*big.Int, totalWeight uint1
 	)
 	if !solution.Optimal {
 		solution2 := method1(
+			ctx,
 			aggregatedVotes,
 			aggregatedFees,
 			filterResults.GuaranteedWeight,
@@ -53,22 +79,79 @@ func ensemble(allBitVotes []*WeightedBitVote, fees []*big.Int, totalWeight uint1
 			solution.Value.Copy(),
 		)
 
-		if solution2.Value.Cmp(solution.Value) == 1 {
-			solution = solution2
-		}
+		solution = better(solution, solution2)
 	}
 
-	return filterResults, solution, nil
+	if exactVotes && !solution.Optimal {
+		solution3 := ExactVotes(
+			ctx,
+			aggregatedVotes,
+			aggregatedFees,
+			filterResults.GuaranteedWeight,
+			weightVoted,
+			totalWeight,
+			filterResults.GuaranteedFees,
+			maxOperations,
+			solution.Value.Copy(),
+		)
+
+		solution = better(solution, solution3)
+	}
+
+	// a search stopped by the deadline depends on the speed of the node, so other nodes could find a different bitVote
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("search interrupted: %w", err)
+	}
+
+	return solution, nil
+}
+
+// better returns next if it has a higher value than solution, which was used as the initial bound of next. Otherwise, solution is returned
+// and it is marked optimal if next is optimal, since then no better solution exists.
+func better(solution, next *ConsensusSolution) *ConsensusSolution {
+	if next.Value.Cmp(solution.Value) == 1 {
+		return next
+	}
+
+	if next.Optimal {
+		solution.Optimal = true
+	}
+
+	return solution
 }
 
-func EnsembleConsensusBitVote(allBitVotes []*WeightedBitVote, fees []*big.Int, totalWeight uint16, maxOperations int) (BitVote, error) {
-	filterResults, filterSolution, err := ensemble(allBitVotes, fees, totalWeight, maxOperations)
+// EnsembleConsensusBitVote computes the consensus bitVote.
+// The returned bool is true if the consensus bitVote is known to be optimal.
+func EnsembleConsensusBitVote(ctx context.Context, allBitVotes []*WeightedBitVote, fees []*big.Int, totalWeight uint16, maxOperations int, exactVotes bool) (BitVote, bool, error) {
+	consensus, explanation, err := EnsembleConsensusBitVoteExplained(ctx, allBitVotes, fees, totalWeight, maxOperations, exactVotes)
+	if err != nil {
+		return BitVote{}, false, err
+	}
+
+	return consensus, explanation.Optimal, nil
+}
 
+// EnsembleConsensusBitVoteExplained computes the consensus bitVote and explains how it was chosen.
+// If the consensus bitVote cannot be computed, the explanation of the filtering is returned together with the error.
+//
+// Each strategy is bounded by maxOperations. The deadline of ctx only aborts the computation:
+// if ctx is done before the search finishes, no bitVote is returned, since it would depend on the speed of the node.
+// The exact search over votes is run only if exactVotes is true. All data providers must agree on it, since it can change the consensus bitVote.
+func EnsembleConsensusBitVoteExplained(ctx context.Context, allBitVotes []*WeightedBitVote, fees []*big.Int, totalWeight uint16, maxOperations int, exactVotes bool) (BitVote, *Explanation, error) {
+	aggregatedVotes, aggregatedFees, filterResults := FilterAndAggregate(allBitVotes, fees, totalWeight)
+
+	explanation := explainFilter(allBitVotes, filterResults, aggregatedVotes, aggregatedFees)
+	explanation.MaxOperations = maxOperations
+
+	solution, err := solve(ctx, aggregatedVotes, aggregatedFees, filterResults, totalWeight, maxOperations, exactVotes)
 	if err != nil {
-		return BitVote{}, fmt.Errorf("consensus bitVote: %s", err)
+		return BitVote{}, explanation, fmt.Errorf("consensus bitVote: %w", err)
 	}
 
-	return AssembleSolution(filterResults, filterSolution, uint16(len(fees))), nil
+	consensus := AssembleSolution(filterResults, solution, uint16(len(fees)))
+	explanation.explainSolution(allBitVotes, solution, consensus)
+
+	return consensus, explanation, nil
 }
 
 func (solution *branchAndBoundPartialSolution) CalcValueFromFees(allBitVotes []*AggregatedVote, bits []*AggregatedBit, assumedFees *big.Int, assumedWeight, totalWeight uint16) Value {
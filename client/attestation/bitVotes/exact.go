@@ -0,0 +1,161 @@
+package bitvotes
+
+import (
+	"context"
+	"math/big"
+	"slices"
+)
+
+// exactState is a set of bits that is the intersection of a subset of votes. Bits are positions in the aggregated bits.
+type exactState struct {
+	bits *big.Int
+	fees *big.Int // assumed fees and fees of the bits
+}
+
+// ExactVotes finds an optimal solution with dynamic programming over aggregated votes.
+//
+// For any set of votes, the confirmed bits are the intersection of the votes and the best set of votes with those bits consists of all the votes that
+// support them. Hence, it is enough to consider the distinct intersections of subsets of votes. The votes are processed one by one and each
+// intersection found so far is intersected with the vote. Intersections whose fees cannot beat the current best value even with the weight of all
+// the votes are dropped, since their further intersections have even lower fees.
+//
+// The number of intersections can grow exponentially, hence the search stops after maxOperations operations or when ctx is done.
+// In that case, the best solution found so far is returned as not optimal. If no solution exceeding initialBound is found, the returned solution has no bits.
+func ExactVotes(
+	ctx context.Context,
+	bitVotes []*AggregatedVote,
+	bits []*AggregatedBit,
+	assumedWeight, weightVoted, absoluteTotalWeight uint16,
+	assumedFees *big.Int,
+	maxOperations int,
+	initialBound Value,
+) *ConsensusSolution {
+	// heavier votes first, so that intersections with enough weight are found early
+	votes := slices.Clone(bitVotes)
+	slices.SortFunc(votes, func(a, b *AggregatedVote) int {
+		if a.Weight != b.Weight {
+			return int(b.Weight) - int(a.Weight)
+		}
+		return a.Indexes[0] - b.Indexes[0]
+	})
+
+	// votes over the positions of the aggregated bits
+	voteBits := make([]*big.Int, len(votes))
+	for i, vote := range votes {
+		voteBits[i] = new(big.Int)
+		for j, bit := range bits {
+			if vote.BitVector.Bit(bit.Indexes[0]) == 1 {
+				voteBits[i].SetBit(voteBits[i], j, 1)
+			}
+		}
+	}
+
+	status := &SharedStatus{CurrentBound: initialBound}
+	lowerBoundWeight := absoluteTotalWeight / 2
+
+	var best *exactState
+
+	// evaluate updates the best state and reports whether the intersections of state can improve the best value.
+	evaluate := func(state *exactState) bool {
+		weight := assumedWeight
+		for i := range votes {
+			status.NumOperations++
+			if new(big.Int).AndNot(state.bits, voteBits[i]).Sign() == 0 {
+				weight += votes[i].Weight
+			}
+		}
+
+		if weight > lowerBoundWeight {
+			if value := CalcValue(state.fees, weight, absoluteTotalWeight); value.Cmp(status.CurrentBound) == 1 {
+				status.CurrentBound = value
+				best = state
+			}
+		}
+
+		return CalcValue(state.fees, weightVoted, absoluteTotalWeight).Cmp(status.CurrentBound) == 1
+	}
+
+	allBits := new(big.Int)
+	allFees := new(big.Int).Set(assumedFees)
+	for j, bit := range bits {
+		allBits.SetBit(allBits, j, 1)
+		allFees.Add(allFees, bit.Fee)
+	}
+
+	initial := &exactState{bits: allBits, fees: allFees}
+	seen := map[string]bool{string(allBits.Bytes()): true}
+
+	var states []*exactState
+	if evaluate(initial) {
+		states = append(states, initial)
+	}
+
+	// the intersections of the heaviest votes give the initial bound
+	greedy := initial
+	for i := 0; i < len(votes) && status.NumOperations < maxOperations; i++ {
+		greedy = intersect(greedy, voteBits[i], bits)
+		evaluate(greedy)
+	}
+
+	stopped := false // true if the search space was not explored
+	for i := 0; i < len(votes) && !stopped; i++ {
+		for _, state := range states[:len(states):len(states)] {
+			if status.NumOperations >= maxOperations || status.interrupted(ctx) {
+				stopped = true
+				break
+			}
+
+			next := intersect(state, voteBits[i], bits)
+			key := string(next.bits.Bytes())
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			if evaluate(next) {
+				states = append(states, next)
+			}
+		}
+	}
+
+	solution := &ConsensusSolution{
+		Votes:      []*AggregatedVote{},
+		Bits:       []*AggregatedBit{},
+		Value:      Value{big.NewInt(0), big.NewInt(0)},
+		Optimal:    !stopped,
+		Method:     MethodExactVotes,
+		Operations: status.NumOperations,
+	}
+
+	if best == nil {
+		return solution
+	}
+
+	solution.Value = status.CurrentBound
+	for j := range bits {
+		if best.bits.Bit(j) == 1 {
+			solution.Bits = append(solution.Bits, bits[j])
+		}
+	}
+	for i := range votes {
+		if new(big.Int).AndNot(best.bits, voteBits[i]).Sign() == 0 {
+			solution.Votes = append(solution.Votes, votes[i])
+		}
+	}
+
+	return solution
+}
+
+// intersect returns the state with the bits of state that are also in vote.
+func intersect(state *exactState, vote *big.Int, bits []*AggregatedBit) *exactState {
+	intersection := new(big.Int).And(state.bits, vote)
+
+	fees := new(big.Int).Set(state.fees)
+	for j := range bits {
+		if state.bits.Bit(j) == 1 && intersection.Bit(j) == 0 {
+			fees.Sub(fees, bits[j].Fee)
+		}
+	}
+
+	return &exactState{bits: intersection, fees: fees}
+}
@@ -0,0 +1,247 @@
+package bitvotes_test
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	bitvotes "github.com/flare-foundation/fdc-client/client/attestation/bitVotes"
+
+	"github.com/stretchr/testify/require"
+)
+
+type solver func(context.Context, []*bitvotes.AggregatedVote, []*bitvotes.AggregatedBit, uint16, uint16, uint16, *big.Int, int, bitvotes.Value) *bitvotes.ConsensusSolution
+
+var solvers = []struct {
+	name  string
+	solve solver
+}{
+	{"bits-double", bitvotes.BranchAndBoundBitsDouble},
+	{"votes-double", bitvotes.BranchAndBoundVotesDouble},
+	{bitvotes.MethodExactVotes, bitvotes.ExactVotes},
+}
+
+// generatedRound is a round with random bitVotes.
+type generatedRound struct {
+	bitVotes    []*bitvotes.WeightedBitVote
+	fees        []*big.Int
+	totalWeight uint16
+}
+
+// generateRound generates a round in which voters are split into clusters, e.g., by the verifiers they use.
+// Each cluster supports each attestation with probability prob and each voter misses each attestation supported by its cluster with probability noise.
+func generateRound(rng *rand.Rand, numAttestations, numVoters, numClusters int, prob, noise float64) generatedRound {
+	round := generatedRound{
+		bitVotes: make([]*bitvotes.WeightedBitVote, numVoters),
+		fees:     make([]*big.Int, numAttestations),
+	}
+
+	for i := range round.fees {
+		round.fees[i] = big.NewInt(1 + rng.Int63n(10))
+	}
+
+	clusters := make([]*big.Int, numClusters)
+	for k := range clusters {
+		clusters[k] = big.NewInt(0)
+		for i := range numAttestations {
+			if rng.Float64() < prob {
+				clusters[k].SetBit(clusters[k], i, 1)
+			}
+		}
+	}
+
+	for j := range round.bitVotes {
+		bitVector := new(big.Int).Set(clusters[j%numClusters])
+		for i := range numAttestations {
+			if rng.Float64() < noise {
+				bitVector.SetBit(bitVector, i, 0)
+			}
+		}
+
+		weight := uint16(1 + rng.Intn(100))
+		round.bitVotes[j] = &bitvotes.WeightedBitVote{Index: j, Weight: weight, BitVote: bitvotes.BitVote{Length: uint16(numAttestations), BitVector: bitVector}}
+		round.totalWeight += weight
+	}
+
+	return round
+}
+
+// aggregated returns the aggregated votes and bits of the round together with the assumed weight, the voted weight and the assumed fees.
+func (r generatedRound) aggregated() ([]*bitvotes.AggregatedVote, []*bitvotes.AggregatedBit, uint16, uint16, *big.Int) {
+	votes, bits, filterResults := bitvotes.FilterAndAggregate(r.bitVotes, r.fees, r.totalWeight)
+
+	weightVoted := filterResults.GuaranteedWeight
+	for _, vote := range votes {
+		weightVoted += vote.Weight
+	}
+
+	return votes, bits, filterResults.GuaranteedWeight, weightVoted, filterResults.GuaranteedFees
+}
+
+// bruteForce returns the best value over all subsets of votes.
+func bruteForce(votes []*bitvotes.AggregatedVote, bits []*bitvotes.AggregatedBit, assumedWeight, totalWeight uint16, assumedFees *big.Int) bitvotes.Value {
+	best := bitvotes.Value{CappedValue: big.NewInt(0), UncappedValue: big.NewInt(0)}
+
+	for subset := range 1 << len(votes) {
+		weight := assumedWeight
+		for i := range votes {
+			if subset&(1<<i) != 0 {
+				weight += votes[i].Weight
+			}
+		}
+		if weight <= totalWeight/2 {
+			continue
+		}
+
+		fees := new(big.Int).Set(assumedFees)
+		for _, bit := range bits {
+			supported := true
+			for i := range votes {
+				if subset&(1<<i) != 0 && votes[i].BitVector.Bit(bit.Indexes[0]) == 0 {
+					supported = false
+					break
+				}
+			}
+			if supported {
+				fees.Add(fees, bit.Fee)
+			}
+		}
+
+		if value := bitvotes.CalcValue(fees, weight, totalWeight); value.Cmp(best) == 1 {
+			best = value
+		}
+	}
+
+	return best
+}
+
+func TestExactVotes(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for i := range 50 {
+		round := generateRound(rng, 15, 10, 10, 0.7, 0)
+		votes, bits, assumedWeight, weightVoted, assumedFees := round.aggregated()
+
+		solution := bitvotes.ExactVotes(context.Background(), votes, bits, assumedWeight, weightVoted, round.totalWeight, assumedFees, 1_000_000, bitvotes.Value{CappedValue: big.NewInt(0), UncappedValue: big.NewInt(0)})
+		require.True(t, solution.Optimal, i)
+		require.Equal(t, bitvotes.MethodExactVotes, solution.Method)
+		require.Equal(t, 0, bruteForce(votes, bits, assumedWeight, round.totalWeight, assumedFees).Cmp(solution.Value), i)
+
+		// the returned bits are supported by the returned votes with the returned value
+		weight := assumedWeight
+		for _, vote := range solution.Votes {
+			weight += vote.Weight
+			for _, bit := range solution.Bits {
+				require.Equal(t, uint(1), vote.BitVector.Bit(bit.Indexes[0]))
+			}
+		}
+		fees := new(big.Int).Set(assumedFees)
+		for _, bit := range solution.Bits {
+			fees.Add(fees, bit.Fee)
+		}
+		if len(solution.Votes) > 0 {
+			require.Equal(t, 0, bitvotes.CalcValue(fees, weight, round.totalWeight).Cmp(solution.Value), i)
+		}
+	}
+}
+
+func TestExactVotesBound(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	round := generateRound(rng, 15, 10, 10, 0.7, 0)
+	votes, bits, assumedWeight, weightVoted, assumedFees := round.aggregated()
+
+	optimal := bitvotes.ExactVotes(context.Background(), votes, bits, assumedWeight, weightVoted, round.totalWeight, assumedFees, 1_000_000, bitvotes.Value{CappedValue: big.NewInt(0), UncappedValue: big.NewInt(0)})
+	require.True(t, optimal.Optimal)
+
+	// nothing exceeds the optimal value
+	bounded := bitvotes.ExactVotes(context.Background(), votes, bits, assumedWeight, weightVoted, round.totalWeight, assumedFees, 1_000_000, optimal.Value.Copy())
+	require.True(t, bounded.Optimal)
+	require.Empty(t, bounded.Bits)
+
+	// the search is stopped by the operations budget
+	limited := bitvotes.ExactVotes(context.Background(), votes, bits, assumedWeight, weightVoted, round.totalWeight, assumedFees, 1, bitvotes.Value{CappedValue: big.NewInt(0), UncappedValue: big.NewInt(0)})
+	require.False(t, limited.Optimal)
+}
+
+func TestEnsembleExact(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+
+	// with a small operations budget, branch and bound strategies do not finish, while the exact search over few votes does
+	round := generateRound(rng, 300, 12, 12, 0.95, 0)
+	votes, bits, assumedWeight, weightVoted, assumedFees := round.aggregated()
+
+	exact := bitvotes.ExactVotes(context.Background(), votes, bits, assumedWeight, weightVoted, round.totalWeight, assumedFees, 10_000_000, bitvotes.Value{CappedValue: big.NewInt(0), UncappedValue: big.NewInt(0)})
+	require.True(t, exact.Optimal)
+
+	_, explanation, err := bitvotes.EnsembleConsensusBitVoteExplained(context.Background(), round.bitVotes, round.fees, round.totalWeight, 10_000, true)
+	require.NoError(t, err)
+	require.Equal(t, 0, exact.Value.Cmp(explanation.Value))
+
+	_, activated, err := bitvotes.EnsembleConsensusBitVoteExplained(context.Background(), round.bitVotes, round.fees, round.totalWeight, 100, true)
+	require.NoError(t, err)
+	require.Equal(t, bitvotes.MethodExactVotes, activated.Method)
+
+	// before the exact search is activated, only branch and bound strategies are run
+	_, explanation, err = bitvotes.EnsembleConsensusBitVoteExplained(context.Background(), round.bitVotes, round.fees, round.totalWeight, 100, false)
+	require.NoError(t, err)
+	require.NotEqual(t, bitvotes.MethodExactVotes, explanation.Method)
+	require.Equal(t, -1, explanation.Value.Cmp(activated.Value))
+}
+
+// BenchmarkConsensus compares runtime and optimality of the strategies on generated rounds with independent and with clustered voters.
+// Names of the benchmarks include the numbers of aggregated bits and votes.
+// Metric optimal is 1 if the strategy proved its solution optimal and value/best is the ratio of its capped value to the best capped value found.
+func BenchmarkConsensus(b *testing.B) {
+	const maxOperations = 20_000_000
+
+	rounds := []struct {
+		attestations, voters, clusters int
+		prob, noise                    float64
+	}{
+		{50, 10, 10, 0.9, 0},
+		{100, 30, 30, 0.95, 0},
+		{300, 60, 60, 0.98, 0},
+		{300, 100, 5, 0.95, 0.005},
+		{500, 100, 10, 0.97, 0.002},
+	}
+
+	for k, r := range rounds {
+		round := generateRound(rand.New(rand.NewSource(int64(k))), r.attestations, r.voters, r.clusters, r.prob, r.noise)
+		votes, bits, assumedWeight, weightVoted, assumedFees := round.aggregated()
+
+		solutions := make(map[string]*bitvotes.ConsensusSolution, len(solvers))
+		best := big.NewInt(0)
+		for _, s := range solvers {
+			solutions[s.name] = s.solve(context.Background(), votes, bits, assumedWeight, weightVoted, round.totalWeight, assumedFees, maxOperations, bitvotes.Value{CappedValue: big.NewInt(0), UncappedValue: big.NewInt(0)})
+			if solutions[s.name].Value.CappedValue.Cmp(best) == 1 {
+				best = solutions[s.name].Value.CappedValue
+			}
+		}
+
+		for _, s := range solvers {
+			name := fmt.Sprintf("round=%d/bits=%d/votes=%d/%s", k, len(bits), len(votes), s.name)
+
+			b.Run(name, func(b *testing.B) {
+				var solution *bitvotes.ConsensusSolution
+				for range b.N {
+					solution = s.solve(context.Background(), votes, bits, assumedWeight, weightVoted, round.totalWeight, assumedFees, maxOperations, bitvotes.Value{CappedValue: big.NewInt(0), UncappedValue: big.NewInt(0)})
+				}
+
+				optimal := 0.0
+				if solution.Optimal {
+					optimal = 1
+				}
+				b.ReportMetric(optimal, "optimal")
+
+				ratio := 1.0
+				if best.Sign() > 0 {
+					ratio, _ = new(big.Rat).SetFrac(solution.Value.CappedValue, best).Float64()
+				}
+				b.ReportMetric(ratio, "value/best")
+				b.ReportMetric(float64(solution.Operations), "operations")
+			})
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package bitvotes
+
+import (
+	"math/big"
+	"slices"
+)
+
+// Explanation describes how the consensus bitVote was chosen.
+// Bits are indexes of attestations in the round and voters are signing policy indexes of the voters that submitted a bitVote.
+type Explanation struct {
+	AlwaysInBits     []int    `json:"alwaysInBits"`  // bits supported by all voters
+	AlwaysOutBits    []int    `json:"alwaysOutBits"` // bits supported by at most half of the total weight
+	GuaranteedFees   *big.Int `json:"guaranteedFees"`
+	AlwaysInVotes    []int    `json:"alwaysInVotes"`  // voters that support all remaining bits
+	AlwaysOutVotes   []int    `json:"alwaysOutVotes"` // voters that support none of the remaining bits
+	GuaranteedWeight uint16   `json:"guaranteedWeight"`
+
+	AggregatedBits  []ExplainedBit  `json:"aggregatedBits"`  // remaining bits grouped by the voters supporting them
+	AggregatedVotes []ExplainedVote `json:"aggregatedVotes"` // remaining voters grouped by the bits they support
+
+	Method        string `json:"method"` // branch and bound strategy that found the solution, empty if no solution was found
+	Operations    int    `json:"operations"`
+	MaxOperations int    `json:"maxOperations"`
+	Optimal       bool   `json:"optimal"`
+	Value         Value  `json:"value"`
+
+	Chosen        []int  `json:"chosen"`     // bits of the consensus bitVote
+	Supporters    []int  `json:"supporters"` // voters whose bitVotes include all chosen bits
+	SupportWeight uint16 `json:"supportWeight"`
+}
+
+// ExplainedBit is an aggregated bit of the explanation.
+type ExplainedBit struct {
+	Bits    []int    `json:"bits"`
+	Fee     *big.Int `json:"fee"`
+	Support uint16   `json:"support"`
+}
+
+// ExplainedVote is an aggregated vote of the explanation.
+type ExplainedVote struct {
+	Voters []int  `json:"voters"`
+	Weight uint16 `json:"weight"`
+}
+
+// explainFilter describes filterResults and the aggregated votes and bits. Votes are identified by the signing policy indexes of allBitVotes.
+func explainFilter(allBitVotes []*WeightedBitVote, filterResults *FilterResults, votes []*AggregatedVote, bits []*AggregatedBit) *Explanation {
+	explanation := &Explanation{
+		AlwaysInBits:     sorted(filterResults.AlwaysInBits),
+		AlwaysOutBits:    sorted(filterResults.AlwaysOutBits),
+		GuaranteedFees:   new(big.Int).Set(filterResults.GuaranteedFees),
+		AlwaysInVotes:    voterIndexes(allBitVotes, filterResults.AlwaysInVotes),
+		AlwaysOutVotes:   voterIndexes(allBitVotes, filterResults.AlwaysOutVotes),
+		GuaranteedWeight: filterResults.GuaranteedWeight,
+		AggregatedBits:   make([]ExplainedBit, len(bits)),
+		AggregatedVotes:  make([]ExplainedVote, len(votes)),
+		Value:            Value{big.NewInt(0), big.NewInt(0)},
+		Chosen:           []int{},
+		Supporters:       []int{},
+	}
+
+	for i, bit := range bits {
+		explanation.AggregatedBits[i] = ExplainedBit{Bits: sorted(bit.Indexes), Fee: new(big.Int).Set(bit.Fee), Support: bit.Support}
+	}
+	slices.SortFunc(explanation.AggregatedBits, func(a, b ExplainedBit) int { return a.Bits[0] - b.Bits[0] })
+
+	for i, vote := range votes {
+		explanation.AggregatedVotes[i] = ExplainedVote{Voters: voterIndexes(allBitVotes, vote.Indexes), Weight: vote.Weight}
+	}
+	slices.SortFunc(explanation.AggregatedVotes, func(a, b ExplainedVote) int { return a.Voters[0] - b.Voters[0] })
+
+	return explanation
+}
+
+// explainSolution adds the strategy and the value of solution and the voters that support consensus to the explanation.
+func (e *Explanation) explainSolution(allBitVotes []*WeightedBitVote, solution *ConsensusSolution, consensus BitVote) {
+	e.Method = solution.Method
+	e.Operations = solution.Operations
+	e.Optimal = solution.Optimal
+	e.Value = solution.Value.Copy()
+
+	for i := range int(consensus.Length) {
+		if consensus.BitVector.Bit(i) == 1 {
+			e.Chosen = append(e.Chosen, i)
+		}
+	}
+
+	supported := new(big.Int)
+	for _, bitVote := range allBitVotes {
+		if supported.And(bitVote.BitVote.BitVector, consensus.BitVector).Cmp(consensus.BitVector) == 0 {
+			e.Supporters = append(e.Supporters, bitVote.Index)
+			e.SupportWeight += bitVote.Weight
+		}
+	}
+	slices.Sort(e.Supporters)
+}
+
+// voterIndexes returns the sorted signing policy indexes of bitVotes at positions.
+func voterIndexes(bitVotes []*WeightedBitVote, positions []int) []int {
+	indexes := make([]int, len(positions))
+	for i, j := range positions {
+		indexes[i] = bitVotes[j].Index
+	}
+	slices.Sort(indexes)
+
+	return indexes
+}
+
+func sorted(s []int) []int {
+	c := slices.Clone(s)
+	if c == nil {
+		c = []int{}
+	}
+	slices.Sort(c)
+
+	return c
+}
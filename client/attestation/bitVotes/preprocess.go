@@ -24,7 +24,7 @@ type FilterResults struct {
 // and moves them from RemainingBits to AlwaysInBits or AlwaysOutBits, respectively.
 // Fees of bits moved to AlwaysInBits are added to guaranteedFees.
 func (fr *FilterResults) FilterBits(bitVotes []*WeightedBitVote, fees []*big.Int, totalWeight uint16) *FilterResults {
-	for i := range fr.RemainingBits {
+	for _, i := range sortedKeys(fr.RemainingBits) {
 		support := fr.GuaranteedWeight
 
 		for j := range fr.RemainingVotes {
@@ -51,7 +51,7 @@ func (fr *FilterResults) FilterBits(bitVotes []*WeightedBitVote, fees []*big.Int
 // FilterBitsOnes moves bits that are supported by all RemainingVotes to AlwaysInBits and updates GuaranteedFees.
 func (fr *FilterResults) FilterBitsOnes(bitVotes []*WeightedBitVote, fees []*big.Int) *FilterResults {
 bits:
-	for i := range fr.RemainingBits {
+	for _, i := range sortedKeys(fr.RemainingBits) {
 		for j := range fr.RemainingVotes {
 			if bitVotes[j].BitVote.BitVector.Bit(i) == 0 {
 				continue bits
@@ -75,7 +75,7 @@ func (fr *FilterResults) FilterVotes(bitVotes []*WeightedBitVote, totalWeight ui
 	somethingChanged := false
 
 votes:
-	for i := range fr.RemainingVotes {
+	for _, i := range sortedKeys(fr.RemainingVotes) {
 		allOnes := true
 		allZeros := len(fr.AlwaysInBits) == 0 // len(fr.AlwaysInBits) == 0 ensures we only remove votes who have no chance to contribute to the optimal solution
 
@@ -111,6 +111,14 @@ votes:
 	return somethingChanged
 }
 
+// sortedKeys returns the keys of set in ascending order, so that the results do not depend on the iteration order of the map.
+func sortedKeys(set map[int]bool) []int {
+	keys := utils.Keys(set)
+	slices.Sort(keys)
+
+	return keys
+}
+
 // Filter identifies the bits and votes that are guaranteed to be included in the selection of the consensus bitVote.
 func Filter(bitVotes []*WeightedBitVote, fees []*big.Int, totalWeight uint16) *FilterResults {
 	remainingBits := make(map[int]bool)
@@ -170,14 +178,13 @@ func (f *AggregatedBit) Value(totalWeight uint16, cache bool) Value {
 }
 
 // AggregateBits aggregates fees of the bits that agree on all the RemainingVotes.
+// The aggregated bits are ordered by their first index.
 func AggregateBits(bitVotes []*WeightedBitVote, fees []*big.Int, filterResults *FilterResults) []*AggregatedBit {
 	aggregator := map[string]*AggregatedBit{}
+	aggregated := []*AggregatedBit{}
 
-	remainingBitsSorted := utils.Keys(filterResults.RemainingBits)
-	slices.Sort(remainingBitsSorted)
-
-	remainingVotesSorted := utils.Keys(filterResults.RemainingVotes)
-	slices.Sort(remainingVotesSorted)
+	remainingBitsSorted := sortedKeys(filterResults.RemainingBits)
+	remainingVotesSorted := sortedKeys(filterResults.RemainingVotes)
 
 	for _, i := range remainingBitsSorted {
 		identifier := ""
@@ -203,13 +210,14 @@ func AggregateBits(bitVotes []*WeightedBitVote, fees []*big.Int, filterResults *
 			}
 
 			aggregator[identifier] = &newAggFee
+			aggregated = append(aggregated, &newAggFee)
 		} else {
 			aggFee.Fee.Add(aggFee.Fee, fees[i])
 			aggFee.Indexes = append(aggFee.Indexes, i) // i is always larger than the existing indexes
 		}
 	}
 
-	return utils.Values(aggregator)
+	return aggregated
 }
 
 type AggregatedVote struct {
@@ -219,14 +227,14 @@ type AggregatedVote struct {
 	Fees      *big.Int // for sorting purposes
 }
 
+// AggregateVotes aggregates weights of the votes that agree on all the RemainingBits.
+// The aggregated votes are ordered by their first index.
 func AggregateVotes(bitVotes []*WeightedBitVote, fees []*big.Int, filterResults *FilterResults) []*AggregatedVote {
 	aggregator := map[string]*AggregatedVote{}
+	aggregated := []*AggregatedVote{}
 
-	remainingBitsSorted := utils.Keys(filterResults.RemainingBits)
-	slices.Sort(remainingBitsSorted)
-
-	remainingVotesSorted := utils.Keys(filterResults.RemainingVotes)
-	slices.Sort(remainingVotesSorted)
+	remainingBitsSorted := sortedKeys(filterResults.RemainingBits)
+	remainingVotesSorted := sortedKeys(filterResults.RemainingVotes)
 
 	for _, i := range remainingVotesSorted {
 		feesVote := big.NewInt(0).Set(filterResults.GuaranteedFees)
@@ -254,13 +262,14 @@ func AggregateVotes(bitVotes []*WeightedBitVote, fees []*big.Int, filterResults
 			}
 
 			aggregator[identifier] = &newAggVote
+			aggregated = append(aggregated, &newAggVote)
 		} else {
 			aggVote.Weight += bitVotes[i].Weight
 			aggVote.Indexes = append(aggVote.Indexes, i) // i is always larger than the existing indexes
 		}
 	}
 
-	return utils.Values(aggregator)
+	return aggregated
 }
 
 func FilterAndAggregate(bitVotes []*WeightedBitVote, fees []*big.Int, totalWeight uint16) ([]*AggregatedVote, []*AggregatedBit, *FilterResults) {
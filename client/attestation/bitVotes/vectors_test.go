@@ -0,0 +1,271 @@
+package bitvotes_test
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	bitvotes "github.com/flare-foundation/fdc-client/client/attestation/bitVotes"
+
+	"github.com/stretchr/testify/require"
+)
+
+var updateVectors = flag.Bool("update", false, "regenerate the consensus test vectors")
+
+const (
+	vectorsFile          = "testdata/consensus_vectors.json"
+	vectorsMaxOperations = 20_000_000
+	vectorsRuns          = 5 // number of times each vector is computed to detect nondeterminism
+)
+
+// vector is a test vector of the consensus bitVote.
+type vector struct {
+	Name          string          `json:"name"`
+	TotalWeight   uint16          `json:"totalWeight"`
+	MaxOperations int             `json:"maxOperations"`
+	ExactVotes    bool            `json:"exactVotes"` // true if the exact search over votes is activated
+	Fees          []string        `json:"fees"`
+	BitVotes      []vectorBitVote `json:"bitVotes"`
+	Consensus     string          `json:"consensus"` // encoded consensus bitVote, empty if there is no consensus
+	Optimal       bool            `json:"optimal"`
+	Unique        bool            `json:"unique"` // true if no other bitVote has the value of the consensus bitVote
+}
+
+type vectorBitVote struct {
+	Index   int    `json:"index"`
+	Weight  uint16 `json:"weight"`
+	BitVote string `json:"bitVote"` // encoded as in the payload of submit2
+}
+
+func (v vector) input(t *testing.T) ([]*bitvotes.WeightedBitVote, []*big.Int) {
+	fees := make([]*big.Int, len(v.Fees))
+	for i := range v.Fees {
+		fee, ok := new(big.Int).SetString(v.Fees[i], 10)
+		require.True(t, ok, v.Fees[i])
+		fees[i] = fee
+	}
+
+	bitVotes := make([]*bitvotes.WeightedBitVote, len(v.BitVotes))
+	for i, b := range v.BitVotes {
+		encoded, err := hex.DecodeString(b.BitVote)
+		require.NoError(t, err)
+
+		bitVote, err := bitvotes.DecodeBitVoteBytes(encoded)
+		require.NoError(t, err)
+
+		bitVotes[i] = &bitvotes.WeightedBitVote{Index: b.Index, Weight: b.Weight, BitVote: bitVote}
+	}
+
+	return bitVotes, fees
+}
+
+// consensus returns the encoded consensus bitVote or an empty string if there is none.
+func (v vector) consensus(t *testing.T, bitVotes []*bitvotes.WeightedBitVote, fees []*big.Int) (string, bool) {
+	consensus, optimal, err := bitvotes.EnsembleConsensusBitVote(context.Background(), bitVotes, fees, v.TotalWeight, v.MaxOperations, v.ExactVotes)
+	if err != nil {
+		return "", false
+	}
+
+	return consensus.EncodeBitVoteHex(), optimal
+}
+
+// TestVectors checks that the consensus bitVotes of the test vectors are reproduced and do not depend on the run.
+// With -update, the vectors are generated again.
+func TestVectors(t *testing.T) {
+	if *updateVectors {
+		writeVectors(t)
+	}
+
+	data, err := os.ReadFile(vectorsFile)
+	require.NoError(t, err)
+
+	var vectors []vector
+	require.NoError(t, json.Unmarshal(data, &vectors))
+	require.NotEmpty(t, vectors)
+
+	for _, v := range vectors {
+		t.Run(v.Name, func(t *testing.T) {
+			bitVotes, fees := v.input(t)
+
+			for range vectorsRuns {
+				consensus, optimal := v.consensus(t, bitVotes, fees)
+				require.Equal(t, v.Consensus, consensus)
+				require.Equal(t, v.Optimal, optimal)
+			}
+
+			// a unique optimal consensus does not depend on the order of bitVotes
+			if v.Unique && v.Optimal {
+				reversed := slices.Clone(bitVotes)
+				slices.Reverse(reversed)
+
+				consensus, _ := v.consensus(t, reversed, fees)
+				require.Equal(t, v.Consensus, consensus)
+			}
+		})
+	}
+}
+
+// writeVectors generates the test vectors, computes their consensus bitVotes, and writes them to vectorsFile.
+func writeVectors(t *testing.T) {
+	vectors := generateVectors()
+
+	for i := range vectors {
+		v := &vectors[i]
+		bitVotes, fees := v.input(t)
+
+		v.Consensus, v.Optimal = v.consensus(t, bitVotes, fees)
+
+		var optimal []string
+		optimal, v.Unique = optimalBitVotes(bitVotes, fees, v.TotalWeight)
+		if v.Consensus != "" {
+			require.Contains(t, optimal, v.Consensus, v.Name)
+		}
+	}
+
+	data, err := json.MarshalIndent(vectors, "", "  ")
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(vectorsFile), 0o755))
+	require.NoError(t, os.WriteFile(vectorsFile, append(data, '\n'), 0o644))
+}
+
+// generateVectors returns the handcrafted vectors followed by vectors with generated rounds.
+// Rounds have at most 14 voters, so that the optimal bitVotes can be found by brute force.
+// The exact search over votes does not change the consensus bitVotes of such rounds, it can only prove them optimal.
+func generateVectors() []vector {
+	vectors := []vector{
+		newVector("single voter", 100, []string{"1", "2", "3"}, []uint16{100}, []string{"101"}),
+		newVector("unanimous", 100, []string{"1", "1", "1", "1"}, []uint16{50, 30, 20}, []string{"1011", "1011", "1011"}),
+		newVector("no majority voted", 100, []string{"1", "1"}, []uint16{30, 20}, []string{"11", "11"}),
+		newVector("no bit with majority support", 100, []string{"1", "1", "1"}, []uint16{34, 33, 33}, []string{"001", "010", "100"}),
+		newExactVector("no bit with majority support exact votes", 100, []string{"1", "1", "1"}, []uint16{34, 33, 33}, []string{"001", "010", "100"}),
+		newVector("tie between two majorities", 100, []string{"1", "1"}, []uint16{34, 33, 33}, []string{"11", "10", "01"}),
+		newVector("tie between majorities with equal weights", 90, []string{"1", "1", "1"}, []uint16{30, 30, 30}, []string{"110", "011", "101"}),
+		newVector("tie broken by the order of bitVotes", 15, []string{"2", "1", "1", "1", "1"}, []uint16{3, 4, 4, 4}, []string{"01100", "11000", "00110", "11111"}),
+		newVector("fees outweigh support", 100, []string{"1", "10", "1"}, []uint16{40, 15, 45}, []string{"111", "110", "011"}),
+		newVector("capped support", 100, []string{"5", "4"}, []uint16{82, 18}, []string{"11", "01"}),
+		newVector("large fees", 100, []string{"1000000000000000000", "2500000000000000000", "1"}, []uint16{40, 30, 30}, []string{"111", "011", "101"}),
+	}
+
+	rounds := []struct {
+		attestations, voters, clusters int
+		prob, noise                    float64
+	}{
+		{5, 3, 3, 0.7, 0},
+		{10, 5, 5, 0.7, 0},
+		{10, 8, 8, 0.8, 0},
+		{20, 10, 10, 0.85, 0},
+		{30, 12, 12, 0.9, 0},
+		{40, 14, 14, 0.9, 0},
+		{60, 14, 3, 0.9, 0.02},
+		{100, 14, 4, 0.95, 0.01},
+		{200, 14, 2, 0.97, 0.005},
+		{12, 14, 14, 0.5, 0},
+	}
+
+	for k, r := range rounds {
+		round := generateRound(rand.New(rand.NewSource(int64(k))), r.attestations, r.voters, r.clusters, r.prob, r.noise)
+
+		v := vector{
+			Name:          fmt.Sprintf("generated %d attestations %d voters %d clusters", r.attestations, r.voters, r.clusters),
+			TotalWeight:   round.totalWeight,
+			MaxOperations: vectorsMaxOperations,
+		}
+		for _, fee := range round.fees {
+			v.Fees = append(v.Fees, fee.String())
+		}
+		for _, b := range round.bitVotes {
+			v.BitVotes = append(v.BitVotes, vectorBitVote{Index: b.Index, Weight: b.Weight, BitVote: b.BitVote.EncodeBitVoteHex()})
+		}
+
+		vectors = append(vectors, v)
+	}
+
+	return vectors
+}
+
+// newVector returns a vector with bitVotes given as strings of bits, with the bit of the first attestation on the right.
+func newVector(name string, totalWeight uint16, fees []string, weights []uint16, bits []string) vector {
+	v := vector{Name: name, TotalWeight: totalWeight, MaxOperations: vectorsMaxOperations, Fees: fees}
+
+	for i := range bits {
+		bitVector, _ := new(big.Int).SetString(bits[i], 2)
+		bitVote := bitvotes.BitVote{Length: uint16(len(fees)), BitVector: bitVector}
+
+		v.BitVotes = append(v.BitVotes, vectorBitVote{Index: i, Weight: weights[i], BitVote: bitVote.EncodeBitVoteHex()})
+	}
+
+	return v
+}
+
+// newExactVector returns a vector as newVector that is computed with the exact search over votes.
+func newExactVector(name string, totalWeight uint16, fees []string, weights []uint16, bits []string) vector {
+	v := newVector(name, totalWeight, fees, weights, bits)
+	v.ExactVotes = true
+
+	return v
+}
+
+// optimalBitVotes returns the encoded bitVotes with the highest value by checking all subsets of voters,
+// and whether there is only one such bitVote.
+func optimalBitVotes(bitVotes []*bitvotes.WeightedBitVote, fees []*big.Int, totalWeight uint16) ([]string, bool) {
+	best := bitvotes.Value{CappedValue: big.NewInt(0), UncappedValue: big.NewInt(0)}
+	optimal := map[string]bool{}
+
+	for subset := 1; subset < 1<<len(bitVotes); subset++ {
+		weight := uint16(0)
+		bits := new(big.Int).Lsh(big.NewInt(1), uint(len(fees)))
+		bits.Sub(bits, big.NewInt(1))
+
+		for i := range bitVotes {
+			if subset&(1<<i) != 0 {
+				weight += bitVotes[i].Weight
+				bits.And(bits, bitVotes[i].BitVote.BitVector)
+			}
+		}
+		if weight <= totalWeight/2 {
+			continue
+		}
+
+		// the bits are supported by all the voters that voted for them
+		support := uint16(0)
+		for i := range bitVotes {
+			if new(big.Int).AndNot(bits, bitVotes[i].BitVote.BitVector).Sign() == 0 {
+				support += bitVotes[i].Weight
+			}
+		}
+
+		feeSum := big.NewInt(0)
+		for j := range fees {
+			if bits.Bit(j) == 1 {
+				feeSum.Add(feeSum, fees[j])
+			}
+		}
+
+		value := bitvotes.CalcValue(feeSum, support, totalWeight)
+		switch value.Cmp(best) {
+		case 1:
+			best = value
+			optimal = map[string]bool{}
+			fallthrough
+		case 0:
+			optimal[bitvotes.BitVote{Length: uint16(len(fees)), BitVector: bits}.EncodeBitVoteHex()] = true
+		}
+	}
+
+	encoded := make([]string, 0, len(optimal))
+	for bitVote := range optimal {
+		encoded = append(encoded, bitVote)
+	}
+	slices.Sort(encoded)
+
+	return encoded, len(encoded) == 1
+}
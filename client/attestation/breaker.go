@@ -0,0 +1,371 @@
+package attestation
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flare-foundation/go-flare-common/pkg/logger"
+	"github.com/pkg/errors"
+
+	"github.com/flare-foundation/fdc-client/client/config"
+)
+
+const breakerTick = 100 * time.Millisecond // period of checking for open breakers that need to be probed
+
+// errBreakerOpen is returned for queries to verifiers with an open breaker.
+var errBreakerOpen = errors.New("verifier circuit breaker open")
+
+type BreakerState string
+
+const (
+	BreakerClosed BreakerState = "closed" // verifier is queried
+	BreakerOpen   BreakerState = "open"   // verifier is not queried, only probed
+)
+
+// BreakerStatus is a snapshot of the state of a verifier's breaker.
+type BreakerStatus struct {
+	URL                 string       `json:"url"`
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutiveFailures"`
+	OpenedAt            int64        `json:"openedAt,omitempty"` // unix timestamp
+	LastError           string       `json:"lastError,omitempty"`
+}
+
+// Breaker is a circuit breaker of a verifier server.
+// It opens after settings.Threshold consecutive failed queries. While it is open, the verifier is probed every settings.ProbeInterval
+// and the breaker closes after a successful probe.
+type Breaker struct {
+	creds    VerifierCredentials // used for probing
+	settings config.Breaker
+
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int
+	openedAt  time.Time
+	lastProbe time.Time
+	lastError string
+	probing   bool
+}
+
+// Allow returns true if the verifier can be queried.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state == BreakerClosed
+}
+
+// success records a successful query and closes the breaker.
+func (b *Breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen {
+		logger.Infof("verifier %s circuit breaker closed", b.creds.URL)
+	}
+
+	b.state = BreakerClosed
+	b.failures = 0
+	b.lastError = ""
+}
+
+// failure records a failed query and opens the breaker if the threshold is reached.
+func (b *Breaker) failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.lastError = err.Error()
+
+	if b.state == BreakerClosed && b.failures >= b.settings.Threshold {
+		logger.Warnf("verifier %s circuit breaker opened after %d consecutive failures: %s", b.creds.URL, b.failures, err)
+
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+		b.lastProbe = b.openedAt
+	}
+}
+
+// Status returns a snapshot of the breaker.
+func (b *Breaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{
+		URL:                 b.creds.URL,
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastError,
+	}
+
+	if b.state == BreakerOpen {
+		status.OpenedAt = b.openedAt.Unix()
+	}
+
+	return status
+}
+
+// probeDue returns true if the breaker is open, not being probed and was last probed at least settings.ProbeInterval ago.
+// If true is returned, the breaker is marked as being probed.
+func (b *Breaker) probeDue(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != BreakerOpen || b.probing || now.Sub(b.lastProbe) < b.settings.ProbeInterval {
+		return false
+	}
+
+	b.probing = true
+	b.lastProbe = now
+
+	return true
+}
+
+// probe checks whether the verifier is up and closes the breaker if it is.
+func (b *Breaker) probe(ctx context.Context) {
+	b.mu.Lock()
+	current := Breaker{creds: b.creds, settings: b.settings}
+	b.mu.Unlock()
+
+	err := current.check(ctx)
+
+	if err == nil {
+		b.success()
+	} else {
+		logger.Debugf("probing verifier %s: %s", current.creds.URL, err)
+		b.failure(err)
+	}
+
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+// check probes the verifier.
+// If HealthPath is set, it has to respond with 2xx status to a GET request.
+// Otherwise, if ProbeRequest is set, it has to respond to the request.
+// Otherwise, it has to respond to a GET request to its url with any status below 500 except 401 and 403.
+// Verifiers accept only POST requests on their url, so other 4xx responses (e.g. 404 or 405) show that the server is up,
+// while 401 and 403 show that it rejects the API key.
+func (b *Breaker) check(ctx context.Context) error {
+	if b.settings.HealthPath == "" && len(b.settings.ProbeRequest) > 0 {
+		_, _, err := postRequest(ctx, &b.creds, b.settings.ProbeRequest)
+		return err
+	}
+
+	target := b.creds.URL
+	if b.settings.HealthPath != "" {
+		base, err := url.Parse(b.creds.URL)
+		if err != nil {
+			return fmt.Errorf("parsing url: %s", err)
+		}
+
+		target = base.ResolveReference(&url.URL{Path: b.settings.HealthPath}).String()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create http request")
+	}
+	request.Header.Set(b.creds.header(), b.creds.apiKeyPrefix+b.creds.apiKey)
+
+	resp, err := b.creds.httpClient().Do(request)
+	if err != nil {
+		return errors.Wrap(err, "failed to send http request")
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	healthy := resp.StatusCode < http.StatusInternalServerError && resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden
+	if b.settings.HealthPath != "" {
+		healthy = resp.StatusCode >= 200 && resp.StatusCode < 300
+	}
+
+	if !healthy {
+		return fmt.Errorf("probe responded with code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// PingVerifier checks whether the verifier is reachable with the access settings of source.
+// The verifier is checked the same way as its circuit breaker probes it.
+func PingVerifier(ctx context.Context, verifier config.Verifier, source *config.Source) error {
+	breaker := Breaker{creds: newVerifierCredentials(verifier, source, nil), settings: source.Breaker}
+
+	return breaker.check(ctx)
+}
+
+// Breakers holds circuit breakers of all verifiers and attestations that wait for one of their verifiers to become available.
+type Breakers struct {
+	mu       sync.Mutex
+	breakers map[string]*Breaker // by breakerKey
+	parked   []*Attestation
+}
+
+// NewBreakers returns an empty set of breakers.
+func NewBreakers() *Breakers {
+	return &Breakers{breakers: make(map[string]*Breaker)}
+}
+
+// breakerKey identifies a breaker by the verifier url, the API key header and the hash of the API key, and the breaker settings,
+// so that sources that access the same verifier differently do not share a breaker.
+func breakerKey(creds VerifierCredentials, settings config.Breaker) string {
+	apiKeyHash := sha256.Sum256([]byte(creds.apiKeyPrefix + creds.apiKey))
+
+	return fmt.Sprintf("%s|%s|%x|%d|%s|%s|%x", creds.URL, creds.header(), apiKeyHash,
+		settings.Threshold, settings.ProbeInterval, settings.HealthPath, settings.ProbeRequest)
+}
+
+// breakerSettings returns settings with defaults in place of unset values.
+func breakerSettings(settings config.Breaker) config.Breaker {
+	if settings.Threshold <= 0 {
+		settings.Threshold = config.DefaultBreakerThreshold
+	}
+	if settings.ProbeInterval <= 0 {
+		settings.ProbeInterval = config.DefaultProbeInterval
+	}
+
+	return settings
+}
+
+// get returns the breaker of the verifier with creds and settings. If there is no such breaker yet, one is created.
+// A reloaded configuration with different credentials or settings (e.g. a rotated API key) gets a new breaker.
+func (bs *Breakers) get(creds VerifierCredentials, settings config.Breaker) *Breaker {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	settings = breakerSettings(settings)
+	creds.breaker = nil
+
+	key := breakerKey(creds, settings)
+
+	breaker, ok := bs.breakers[key]
+	if !ok {
+		breaker = &Breaker{creds: creds, settings: settings, state: BreakerClosed}
+		bs.breakers[key] = breaker
+	}
+
+	return breaker
+}
+
+// Retain removes the breakers of verifiers that are not used by any source of types, e.g., after a reload.
+// Removed breakers are closed, so attestations parked on them are enqueued again.
+func (bs *Breakers) Retain(types config.AttestationTypes) {
+	used := make(map[string]bool)
+	for attType := range types {
+		for _, sourceConfig := range types[attType].SourcesConfig {
+			for _, verifier := range sourceConfig.Verifiers {
+				creds := newVerifierCredentials(verifier, &sourceConfig, nil)
+				used[breakerKey(creds, breakerSettings(sourceConfig.Breaker))] = true
+			}
+		}
+	}
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	for key, breaker := range bs.breakers {
+		if !used[key] {
+			breaker.success()
+			delete(bs.breakers, key)
+		}
+	}
+}
+
+// Park stores the attestation until one of its verifiers becomes available.
+func (bs *Breakers) Park(att *Attestation) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	bs.parked = append(bs.parked, att)
+}
+
+// Parked returns the number of parked attestations.
+func (bs *Breakers) Parked() int {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	return len(bs.parked)
+}
+
+// Statuses returns snapshots of all breakers sorted by url.
+func (bs *Breakers) Statuses() []BreakerStatus {
+	bs.mu.Lock()
+	breakers := make([]*Breaker, 0, len(bs.breakers))
+	for _, breaker := range bs.breakers {
+		breakers = append(breakers, breaker)
+	}
+	bs.mu.Unlock()
+
+	statuses := make([]BreakerStatus, len(breakers))
+	for i := range breakers {
+		statuses[i] = breakers[i].Status()
+	}
+
+	slices.SortFunc(statuses, func(a, b BreakerStatus) int { return strings.Compare(a.URL, b.URL) })
+
+	return statuses
+}
+
+// Run probes verifiers with open breakers and passes parked attestations that can be handled again to requeue.
+// Parked attestations that no longer need to be handled are dropped.
+func (bs *Breakers) Run(ctx context.Context, requeue func(*Attestation)) {
+	ticker := time.NewTicker(breakerTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			bs.probe(ctx, now)
+
+			for _, att := range bs.unpark(ctx) {
+				requeue(att)
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// probe starts probes of all breakers that are due.
+func (bs *Breakers) probe(ctx context.Context, now time.Time) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	for _, breaker := range bs.breakers {
+		if breaker.probeDue(now) {
+			go breaker.probe(ctx)
+		}
+	}
+}
+
+// unpark removes and returns parked attestations that have an available verifier.
+func (bs *Breakers) unpark(ctx context.Context) []*Attestation {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	var available []*Attestation
+
+	bs.parked = slices.DeleteFunc(bs.parked, func(att *Attestation) bool {
+		if att.Discard(ctx) {
+			return true
+		}
+
+		if att.VerifiersAvailable() {
+			available = append(available, att)
+			return true
+		}
+
+		return false
+	})
+
+	return available
+}
@@ -0,0 +1,262 @@
+package attestation_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flare-foundation/fdc-client/client/attestation"
+	"github.com/flare-foundation/fdc-client/client/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreaker(t *testing.T) {
+	var up atomic.Bool
+	var queries atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if !up.Load() {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		if request.URL.Path == "/health" {
+			return
+		}
+
+		queries.Add(1)
+		err := json.NewEncoder(writer).Encode(attestation.ABIEncodedResponseBody{Status: "INVALID"})
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	attType, err := config.StringToByte32("EVMTransaction")
+	require.NoError(t, err)
+
+	source, err := config.StringToByte32("ETH")
+	require.NoError(t, err)
+
+	sourceConfig := config.Source{
+		Verifiers: []config.Verifier{{URL: server.URL + "/verify"}},
+		Mode:      config.ModeFailover,
+		Breaker:   config.Breaker{Threshold: 2, ProbeInterval: 50 * time.Millisecond, HealthPath: "/health"},
+	}
+	types := config.AttestationTypes{attType: {SourcesConfig: map[[32]byte]config.Source{source: sourceConfig}}}
+
+	breakers := attestation.NewBreakers()
+
+	att := &attestation.Attestation{
+		Request:     append(append(attType[:], source[:]...), make([]byte, 32)...),
+		RoundStatus: &attestation.RoundStatusMutex{Value: attestation.PreConsensus},
+	}
+	require.NoError(t, att.PrepareRequest(types, breakers))
+
+	for range 2 {
+		_, _, err = attestation.ResolveAttestationRequest(context.Background(), att)
+		require.Error(t, err)
+	}
+
+	statuses := breakers.Statuses()
+	require.Len(t, statuses, 1)
+	require.Equal(t, attestation.BreakerOpen, statuses[0].State)
+	require.Equal(t, 2, statuses[0].ConsecutiveFailures)
+	require.False(t, att.VerifiersAvailable())
+
+	// open breaker is not queried
+	up.Store(true)
+	_, _, err = attestation.ResolveAttestationRequest(context.Background(), att)
+	require.Error(t, err)
+	require.Zero(t, queries.Load())
+
+	breakers.Park(att)
+	require.Equal(t, 1, breakers.Parked())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	requeued := make(chan *attestation.Attestation, 1)
+	go breakers.Run(ctx, func(a *attestation.Attestation) { requeued <- a })
+
+	select {
+	case a := <-requeued:
+		require.Equal(t, att, a)
+	case <-ctx.Done():
+		t.Fatal("attestation not requeued")
+	}
+
+	require.Equal(t, attestation.BreakerClosed, breakers.Statuses()[0].State)
+	require.Zero(t, breakers.Parked())
+
+	_, confirmed, err := attestation.ResolveAttestationRequest(context.Background(), att)
+	require.NoError(t, err)
+	require.False(t, confirmed)
+	require.Equal(t, int32(1), queries.Load())
+}
+
+func TestBreakerPerSource(t *testing.T) {
+	// the verifier rejects the key of the first source and the probe with it
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Header.Get(config.DefaultAPIKeyHeader) == "revoked" {
+			writer.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		err := json.NewEncoder(writer).Encode(attestation.ABIEncodedResponseBody{Status: "INVALID"})
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	attType, err := config.StringToByte32("EVMTransaction")
+	require.NoError(t, err)
+
+	sourceA, err := config.StringToByte32("ETH")
+	require.NoError(t, err)
+
+	sourceB, err := config.StringToByte32("BTC")
+	require.NoError(t, err)
+
+	types := config.AttestationTypes{attType: {SourcesConfig: map[[32]byte]config.Source{
+		sourceA: {
+			Verifiers:    []config.Verifier{{URL: server.URL, APIKey: "revoked"}},
+			APIKeyHeader: config.DefaultAPIKeyHeader,
+			Breaker:      config.Breaker{Threshold: 1, ProbeInterval: 10 * time.Millisecond},
+		},
+		sourceB: {
+			Verifiers:    []config.Verifier{{URL: server.URL, APIKey: "valid"}},
+			APIKeyHeader: config.DefaultAPIKeyHeader,
+			Breaker:      config.Breaker{Threshold: 5, ProbeInterval: 10 * time.Millisecond},
+		},
+	}}}
+
+	breakers := attestation.NewBreakers()
+
+	newAttestation := func(source [32]byte) *attestation.Attestation {
+		att := &attestation.Attestation{
+			Request:     append(append(attType[:], source[:]...), make([]byte, 32)...),
+			RoundStatus: &attestation.RoundStatusMutex{Value: attestation.PreConsensus},
+		}
+		require.NoError(t, att.PrepareRequest(types, breakers))
+
+		return att
+	}
+
+	attA, attB := newAttestation(sourceA), newAttestation(sourceB)
+	require.Len(t, breakers.Statuses(), 2)
+
+	_, _, err = attestation.ResolveAttestationRequest(context.Background(), attA)
+	require.Error(t, err)
+	require.False(t, attA.VerifiersAvailable())
+	require.True(t, attB.VerifiersAvailable())
+
+	// probes responding with 401 do not close the breaker
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	breakers.Run(ctx, func(*attestation.Attestation) {})
+
+	require.False(t, attA.VerifiersAvailable())
+
+	_, confirmed, err := attestation.ResolveAttestationRequest(context.Background(), attB)
+	require.NoError(t, err)
+	require.False(t, confirmed)
+}
+
+func TestBreakerDefaultProbe(t *testing.T) {
+	// the verifier fails queries until it is up and, like real verifiers, rejects GET requests on its url
+	var up atomic.Bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch {
+		case !up.Load():
+			writer.WriteHeader(http.StatusBadGateway)
+		case request.Method != http.MethodPost:
+			writer.WriteHeader(http.StatusMethodNotAllowed)
+		default:
+			err := json.NewEncoder(writer).Encode(attestation.ABIEncodedResponseBody{Status: "INVALID"})
+			require.NoError(t, err)
+		}
+	}))
+	defer server.Close()
+
+	attType, err := config.StringToByte32("EVMTransaction")
+	require.NoError(t, err)
+
+	source, err := config.StringToByte32("ETH")
+	require.NoError(t, err)
+
+	types := config.AttestationTypes{attType: {SourcesConfig: map[[32]byte]config.Source{source: {
+		Verifiers: []config.Verifier{{URL: server.URL, APIKey: "key"}},
+		Breaker:   config.Breaker{Threshold: 1, ProbeInterval: 10 * time.Millisecond},
+	}}}}
+
+	breakers := attestation.NewBreakers()
+
+	att := &attestation.Attestation{
+		Request:     append(append(attType[:], source[:]...), make([]byte, 32)...),
+		RoundStatus: &attestation.RoundStatusMutex{Value: attestation.PreConsensus},
+	}
+	require.NoError(t, att.PrepareRequest(types, breakers))
+
+	_, _, err = attestation.ResolveAttestationRequest(context.Background(), att)
+	require.Error(t, err)
+	require.False(t, att.VerifiersAvailable())
+
+	up.Store(true)
+	breakers.Park(att)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	requeued := make(chan *attestation.Attestation, 1)
+	go breakers.Run(ctx, func(a *attestation.Attestation) { requeued <- a })
+
+	select {
+	case a := <-requeued:
+		require.Equal(t, att, a)
+	case <-ctx.Done():
+		t.Fatal("attestation not requeued")
+	}
+
+	require.Equal(t, attestation.BreakerClosed, breakers.Statuses()[0].State)
+}
+
+func TestBreakersRetain(t *testing.T) {
+	attType, err := config.StringToByte32("EVMTransaction")
+	require.NoError(t, err)
+
+	source, err := config.StringToByte32("ETH")
+	require.NoError(t, err)
+
+	newTypes := func(apiKey string) config.AttestationTypes {
+		return config.AttestationTypes{attType: {SourcesConfig: map[[32]byte]config.Source{source: {
+			Verifiers: []config.Verifier{{URL: "http://127.0.0.1:1", APIKey: apiKey}},
+			Breaker:   config.Breaker{Threshold: 1},
+		}}}}
+	}
+
+	breakers := attestation.NewBreakers()
+
+	att := &attestation.Attestation{
+		Request:     append(append(attType[:], source[:]...), make([]byte, 32)...),
+		RoundStatus: &attestation.RoundStatusMutex{Value: attestation.PreConsensus},
+	}
+	require.NoError(t, att.PrepareRequest(newTypes("old"), breakers))
+
+	_, _, err = attestation.ResolveAttestationRequest(context.Background(), att)
+	require.Error(t, err)
+	require.False(t, att.VerifiersAvailable())
+
+	// the breaker of the current key is kept
+	breakers.Retain(newTypes("old"))
+	require.Len(t, breakers.Statuses(), 1)
+	require.False(t, att.VerifiersAvailable())
+
+	// the breaker of a rotated key is removed and closed
+	breakers.Retain(newTypes("new"))
+	require.Empty(t, breakers.Statuses())
+	require.True(t, att.VerifiersAvailable())
+}
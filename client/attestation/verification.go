@@ -50,8 +50,8 @@ func (r Request) Source() ([32]byte, error) {
 	return res, nil
 }
 
-// TypeAndSourceString returns the attestation type and source name for debugging
-func (r Request) TypeAndSourceString() string {
+// TypeAndSource returns the attestation type and source names. Unparsable values are "unknown_type" and "unknown_source".
+func (r Request) TypeAndSource() (string, string) {
 	attType, err := r.AttestationType()
 	attTypeString := "unknown_type"
 	if err == nil {
@@ -64,7 +64,14 @@ func (r Request) TypeAndSourceString() string {
 		sourceString = utils.Bytes32ToString(source)
 	}
 
-	return fmt.Sprintf("%s/%s", attTypeString, sourceString)
+	return attTypeString, sourceString
+}
+
+// TypeAndSourceString returns the attestation type and source name for debugging
+func (r Request) TypeAndSourceString() string {
+	attType, source := r.TypeAndSource()
+
+	return fmt.Sprintf("%s/%s", attType, source)
 }
 
 // MIC returns Message Integrity code of the request (the third 32 bytes).
@@ -216,3 +223,40 @@ func IsStaticType(bytes []byte) (bool, error) {
 
 	return d != first32, nil
 }
+
+// responseHeader is the layout of the common fields at the start of every response struct.
+// Response.AddRound writes the round to the third slot and Response.LUT reads the fourth slot.
+var responseHeader = []struct {
+	name string
+	typ  string
+}{
+	{"attestationType", "bytes32"},
+	{"sourceId", "bytes32"},
+	{"votingRound", "uint64"},
+	{"lowestUsedTimestamp", "uint64"},
+}
+
+// ValidateResponseABI checks that args describe a single response struct that starts with the common fields
+// attestationType, sourceId, votingRound, and lowestUsedTimestamp.
+func ValidateResponseABI(args abi.Arguments) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected a single argument, got %d", len(args))
+	}
+
+	response := args[0].Type
+	if response.T != abi.TupleTy {
+		return fmt.Errorf("expected a tuple, got %s", response.String())
+	}
+
+	if len(response.TupleElems) < len(responseHeader) {
+		return fmt.Errorf("expected at least %d fields, got %d", len(responseHeader), len(response.TupleElems))
+	}
+
+	for i, field := range responseHeader {
+		if response.TupleRawNames[i] != field.name || response.TupleElems[i].String() != field.typ {
+			return fmt.Errorf("field %d is %s %s, expected %s %s", i, response.TupleElems[i].String(), response.TupleRawNames[i], field.typ, field.name)
+		}
+	}
+
+	return nil
+}
@@ -159,3 +159,27 @@ func TestRequest(t *testing.T) {
 		require.Equal(t, expectedMic, mic, fmt.Sprintf("error mic in test %d", i))
 	}
 }
+
+func TestValidateResponseABI(t *testing.T) {
+	args, _, err := config.ReadABI("../../tests/configs/abis/EVMTransaction.json")
+	require.NoError(t, err)
+	require.NoError(t, attestation.ValidateResponseABI(args))
+
+	tests := []struct {
+		abi string
+		err string
+	}{
+		{`{"name":"r","type":"uint64"}`, "expected a tuple"},
+		{`{"name":"r","type":"tuple","components":[{"name":"attestationType","type":"bytes32"},{"name":"sourceId","type":"bytes32"}]}`, "at least 4 fields"},
+		{`{"name":"r","type":"tuple","components":[{"name":"attestationType","type":"bytes32"},{"name":"sourceId","type":"bytes32"},{"name":"lowestUsedTimestamp","type":"uint64"},{"name":"votingRound","type":"uint64"}]}`, "field 2"},
+		{`{"name":"r","type":"tuple","components":[{"name":"attestationType","type":"bytes32"},{"name":"sourceId","type":"bytes32"},{"name":"votingRound","type":"uint64"},{"name":"lowestUsedTimestamp","type":"bytes32"}]}`, "field 3"},
+	}
+
+	for _, test := range tests {
+		args, err := config.ArgumentsFromABI([]byte(test.abi))
+		require.NoError(t, err)
+
+		err = attestation.ValidateResponseABI(args)
+		require.ErrorContains(t, err, test.err)
+	}
+}
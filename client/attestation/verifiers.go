@@ -9,15 +9,21 @@ import (
 	"io"
 	"net/http"
 	"strings"
-	"time"
+
+	"github.com/flare-foundation/fdc-client/client/config"
+	"github.com/flare-foundation/go-flare-common/pkg/logger"
 
 	"github.com/pkg/errors"
 )
 
-const timeout = 5 * time.Second    // maximal duration for the verifier to resolve the query
-const maxRespSize = 10 * (1 << 20) // 10 MB for maximal response size of the verifier
 const ValidResponseStatus = "VALID"
 
+// defaultClient is used for verifiers without a client.
+var defaultClient = &http.Client{Timeout: config.DefaultVerifierTimeout}
+
+// ErrNoQuorum is returned if the verifiers of a source in quorum mode respond, but not enough of them agree.
+var ErrNoQuorum = errors.New("verifiers disagree")
+
 type ABIEncodedRequestBody struct {
 	ABIEncodedRequest string `json:"abiEncodedRequest"`
 }
@@ -28,15 +34,231 @@ type ABIEncodedResponseBody struct {
 }
 
 type VerifierCredentials struct {
-	URL    string
-	apiKey string
+	URL             string
+	apiKey          string
+	apiKeyHeader    string       // defaults to config.DefaultAPIKeyHeader
+	apiKeyPrefix    string       // prepended to apiKey in the header
+	maxResponseSize int64        // defaults to config.DefaultVerifierMaxResponseSize
+	client          *http.Client // defaults to defaultClient
+	breaker         *Breaker     // nil if the verifier has no circuit breaker
+}
+
+// newVerifierCredentials returns credentials of the verifier with the access settings of source.
+// If breakers is not nil, the verifier's circuit breaker is attached.
+func newVerifierCredentials(verifier config.Verifier, source *config.Source, breakers *Breakers) VerifierCredentials {
+	creds := VerifierCredentials{
+		URL:             verifier.URL,
+		apiKey:          verifier.APIKey,
+		apiKeyHeader:    source.APIKeyHeader,
+		apiKeyPrefix:    source.APIKeyPrefix,
+		maxResponseSize: source.MaxResponseSize,
+		client:          source.Client,
+	}
+
+	if breakers != nil {
+		creds.breaker = breakers.get(creds, source.Breaker)
+	}
+
+	return creds
+}
+
+// available returns false if the verifier's circuit breaker is open.
+func (c *VerifierCredentials) available() bool {
+	return c.breaker == nil || c.breaker.Allow()
+}
+
+func (c *VerifierCredentials) httpClient() *http.Client {
+	if c.client == nil {
+		return defaultClient
+	}
+
+	return c.client
+}
+
+func (c *VerifierCredentials) header() string {
+	if c.apiKeyHeader == "" {
+		return config.DefaultAPIKeyHeader
+	}
+
+	return c.apiKeyHeader
+}
+
+// verifierResult is a result of a query to a single verifier.
+type verifierResult struct {
+	response  []byte
+	confirmed bool
+	err       error
 }
 
-// ResolveAttestationRequest sends the attestation request to the verifier server with verifierCredentials and stores the response.
-// Returns true if the response is "VALID" and false otherwise.
+// ResolveAttestationRequest sends the attestation request to the verifier servers of the attestation according to its verification mode.
+// Returns the response and true if the response is "VALID" and false otherwise.
 func ResolveAttestationRequest(ctx context.Context, att *Attestation) ([]byte, bool, error) {
-	client := &http.Client{Timeout: timeout}
-	requestBytes := att.Request
+	if len(att.Verifiers) == 0 {
+		return nil, false, errors.New("no verifiers")
+	}
+
+	switch att.VerificationMode {
+	case config.ModeRace:
+		return resolveRace(ctx, att.Verifiers, att.Request)
+	case config.ModeQuorum:
+		return resolveQuorum(ctx, att.Verifiers, att.Quorum, att.Request)
+	default:
+		return resolveFailover(ctx, att.Verifiers, att.Request)
+	}
+}
+
+// resolveFailover queries verifiers in order until one of them responds.
+func resolveFailover(ctx context.Context, verifiers []VerifierCredentials, request []byte) ([]byte, bool, error) {
+	var err error
+
+	for i := range verifiers {
+		var response []byte
+		var confirmed bool
+
+		response, confirmed, err = queryVerifier(ctx, &verifiers[i], request)
+		if err == nil {
+			return response, confirmed, nil
+		}
+
+		logger.Debugf("verifier %s failed: %s", verifiers[i].URL, err)
+	}
+
+	return nil, false, errors.Wrap(err, "all verifiers failed")
+}
+
+// resolveRace queries verifiers concurrently and returns the first "VALID" response.
+// The remaining queries are cancelled.
+func resolveRace(ctx context.Context, verifiers []VerifierCredentials, request []byte) ([]byte, bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := queryAll(ctx, verifiers, request)
+
+	responded := false
+	var err error
+
+	for range verifiers {
+		result := <-results
+		switch {
+		case result.err != nil:
+			err = result.err
+		case result.confirmed:
+			return result.response, true, nil
+		default:
+			responded = true
+		}
+	}
+
+	if responded {
+		return nil, false, nil
+	}
+
+	return nil, false, errors.Wrap(err, "all verifiers failed")
+}
+
+// resolveQuorum queries all verifiers concurrently and returns the response if at least quorum verifiers responded with the same response.
+// If the verifiers responded but not enough of them agree, or if more than one outcome reached the quorum, ErrNoQuorum is returned.
+func resolveQuorum(ctx context.Context, verifiers []VerifierCredentials, quorum int, request []byte) ([]byte, bool, error) {
+	results := queryAll(ctx, verifiers, request)
+
+	votes := make(map[string]int) // number of verifiers for each valid response
+	unconfirmed := 0
+	responded := 0
+	var err error
+
+	for range verifiers {
+		result := <-results
+		if result.err != nil {
+			err = result.err
+			continue
+		}
+
+		responded++
+
+		if !result.confirmed {
+			unconfirmed++
+			continue
+		}
+
+		votes[string(result.response)]++
+	}
+
+	// the outcome is accepted only if no other outcome reached the quorum, otherwise it would depend on the map iteration order
+	reached := 0
+	var response []byte
+	for r, count := range votes {
+		if count >= quorum {
+			reached++
+			response = []byte(r)
+		}
+	}
+	if unconfirmed >= quorum {
+		reached++
+	}
+
+	if reached > 1 {
+		return nil, false, errors.Wrapf(ErrNoQuorum, "%d outcomes reached quorum %d", reached, quorum)
+	}
+
+	if reached == 1 {
+		return response, response != nil, nil
+	}
+
+	if responded < quorum {
+		return nil, false, errors.Wrapf(err, "only %d of %d required verifiers responded", responded, quorum)
+	}
+
+	return nil, false, errors.Wrapf(ErrNoQuorum, "%d distinct valid responses and %d invalid responses, quorum %d", len(votes), unconfirmed, quorum)
+}
+
+// queryAll queries all verifiers concurrently. The results are sent to the returned channel in the order of arrival.
+func queryAll(ctx context.Context, verifiers []VerifierCredentials, request []byte) <-chan verifierResult {
+	results := make(chan verifierResult, len(verifiers))
+
+	for i := range verifiers {
+		go func(creds *VerifierCredentials) {
+			response, confirmed, err := queryVerifier(ctx, creds, request)
+			if err != nil {
+				err = errors.Wrapf(err, "verifier %s", creds.URL)
+			}
+
+			results <- verifierResult{response: response, confirmed: confirmed, err: err}
+		}(&verifiers[i])
+	}
+
+	return results
+}
+
+// queryVerifier sends the attestation request to the verifier server with credentials creds and records the outcome in its circuit breaker.
+// Verifiers with an open breaker are not queried.
+// Returns the response and true if the response is "VALID" and false otherwise.
+func queryVerifier(ctx context.Context, creds *VerifierCredentials, requestBytes []byte) ([]byte, bool, error) {
+	if !creds.available() {
+		return nil, false, errBreakerOpen
+	}
+
+	response, confirmed, err := postRequest(ctx, creds, requestBytes)
+
+	if creds.breaker != nil {
+		switch {
+		case err == nil:
+			creds.breaker.success()
+		case ctx.Err() == nil: // cancelled queries are not failures of the verifier
+			creds.breaker.failure(err)
+		}
+	}
+
+	return response, confirmed, err
+}
+
+// postRequest sends the attestation request to the verifier server with credentials creds.
+// Returns the response and true if the response is "VALID" and false otherwise.
+func postRequest(ctx context.Context, creds *VerifierCredentials, requestBytes []byte) ([]byte, bool, error) {
+	maxResponseSize := creds.maxResponseSize
+	if maxResponseSize == 0 {
+		maxResponseSize = config.DefaultVerifierMaxResponseSize
+	}
+
 	encoded := hex.EncodeToString(requestBytes)
 	payload := ABIEncodedRequestBody{ABIEncodedRequest: "0x" + encoded}
 
@@ -45,24 +267,25 @@ func ResolveAttestationRequest(ctx context.Context, att *Attestation) ([]byte, b
 		return nil, false, errors.Wrap(err, "failed to encode request body")
 	}
 
-	request, err := http.NewRequestWithContext(ctx, "POST", att.Credentials.URL, bytes.NewBuffer(encodedBody))
+	request, err := http.NewRequestWithContext(ctx, "POST", creds.URL, bytes.NewBuffer(encodedBody))
 	if err != nil {
 		return nil, false, errors.Wrap(err, "failed to create http request")
 	}
 	request.Header.Set("Content-Type", "application/json")
-	request.Header.Set("X-API-KEY", att.Credentials.apiKey)
+	request.Header.Set(creds.header(), creds.apiKeyPrefix+creds.apiKey)
 
-	resp, err := client.Do(request)
+	resp, err := creds.httpClient().Do(request)
 	if err != nil {
 		return nil, false, errors.Wrap(err, "failed to send http request")
 	}
+	// close response body after function ends
+	defer resp.Body.Close() //nolint:errcheck
+
 	if resp.StatusCode != http.StatusOK {
 		return nil, false, fmt.Errorf("request responded with code %d", resp.StatusCode)
 	}
 
-	respLimited := &io.LimitedReader{R: resp.Body, N: maxRespSize}
-	// close response body after function ends
-	defer resp.Body.Close() //nolint:errcheck
+	respLimited := &io.LimitedReader{R: resp.Body, N: maxResponseSize}
 
 	responseBody := ABIEncodedResponseBody{}
 
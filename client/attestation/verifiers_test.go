@@ -0,0 +1,179 @@
+package attestation_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flare-foundation/fdc-client/client/attestation"
+	"github.com/flare-foundation/fdc-client/client/config"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// verifierServer starts a verifier that responds with status and response after delay.
+// A verifier with empty status responds with an internal server error.
+func verifierServer(t *testing.T, status, response string, delay time.Duration) attestation.VerifierCredentials {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-request.Context().Done():
+			return
+		}
+
+		if status == "" {
+			writer.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		err := json.NewEncoder(writer).Encode(attestation.ABIEncodedResponseBody{Status: status, ABIEncodedResponse: response})
+		require.NoError(t, err)
+	}))
+	t.Cleanup(server.Close)
+
+	return attestation.VerifierCredentials{URL: server.URL}
+}
+
+func resolve(mode string, quorum int, verifiers ...attestation.VerifierCredentials) ([]byte, bool, error) {
+	att := &attestation.Attestation{
+		Request:          []byte{1},
+		Verifiers:        verifiers,
+		VerificationMode: mode,
+		Quorum:           quorum,
+	}
+
+	return attestation.ResolveAttestationRequest(context.Background(), att)
+}
+
+func TestResolveFailover(t *testing.T) {
+	down := verifierServer(t, "", "", 0)
+	valid := verifierServer(t, attestation.ValidResponseStatus, "0x01", 0)
+
+	response, confirmed, err := resolve(config.ModeFailover, 0, down, valid)
+	require.NoError(t, err)
+	require.True(t, confirmed)
+	require.Equal(t, []byte{1}, response)
+
+	_, _, err = resolve(config.ModeFailover, 0, down, down)
+	require.Error(t, err)
+}
+
+func TestResolveRace(t *testing.T) {
+	slow := verifierServer(t, attestation.ValidResponseStatus, "0x01", time.Second)
+	fast := verifierServer(t, attestation.ValidResponseStatus, "0x02", 0)
+	invalid := verifierServer(t, "INVALID", "", 0)
+
+	start := time.Now()
+	response, confirmed, err := resolve(config.ModeRace, 0, slow, invalid, fast)
+	require.NoError(t, err)
+	require.True(t, confirmed)
+	require.Equal(t, []byte{2}, response)
+	require.Less(t, time.Since(start), time.Second)
+
+	_, confirmed, err = resolve(config.ModeRace, 0, invalid, verifierServer(t, "", "", 0))
+	require.NoError(t, err)
+	require.False(t, confirmed)
+}
+
+func TestResolveQuorum(t *testing.T) {
+	a := verifierServer(t, attestation.ValidResponseStatus, "0x01", 0)
+	b := verifierServer(t, attestation.ValidResponseStatus, "0x01", 0)
+	other := verifierServer(t, attestation.ValidResponseStatus, "0x02", 0)
+	down := verifierServer(t, "", "", 0)
+
+	response, confirmed, err := resolve(config.ModeQuorum, 2, a, other, b)
+	require.NoError(t, err)
+	require.True(t, confirmed)
+	require.Equal(t, []byte{1}, response)
+
+	// quorum of two verifiers requires both
+	_, _, err = resolve(config.ModeQuorum, 2, a, other)
+	require.True(t, errors.Is(err, attestation.ErrNoQuorum))
+
+	_, _, err = resolve(config.ModeQuorum, 2, a, down)
+	require.Error(t, err)
+	require.False(t, errors.Is(err, attestation.ErrNoQuorum))
+
+	// with a majority quorum, a tie does not reach it
+	otherB := verifierServer(t, attestation.ValidResponseStatus, "0x02", 0)
+	_, _, err = resolve(config.ModeQuorum, 3, a, other, b, otherB)
+	require.True(t, errors.Is(err, attestation.ErrNoQuorum))
+
+	// the quorum is reached despite a failed verifier and a different response
+	c := verifierServer(t, attestation.ValidResponseStatus, "0x01", 0)
+	response, confirmed, err = resolve(config.ModeQuorum, 3, a, down, other, b, c)
+	require.NoError(t, err)
+	require.True(t, confirmed)
+	require.Equal(t, []byte{1}, response)
+
+	// a majority of invalid responses is not confirmed
+	invalid := verifierServer(t, "INVALID", "", 0)
+	invalidB := verifierServer(t, "INVALID", "", 0)
+	invalidC := verifierServer(t, "INVALID", "", 0)
+	_, confirmed, err = resolve(config.ModeQuorum, 3, a, invalid, b, invalidB, invalidC)
+	require.NoError(t, err)
+	require.False(t, confirmed)
+}
+
+func TestHandleDisagreement(t *testing.T) {
+	att := &attestation.Attestation{
+		Request: []byte{1},
+		Verifiers: []attestation.VerifierCredentials{
+			verifierServer(t, attestation.ValidResponseStatus, "0x01", 0),
+			verifierServer(t, attestation.ValidResponseStatus, "0x02", 0),
+		},
+		VerificationMode: config.ModeQuorum,
+		Quorum:           2,
+	}
+
+	err := att.Handle(context.Background())
+	require.Error(t, err)
+	require.Equal(t, attestation.Disagreement, att.Status)
+}
+
+func TestVerifierAccessSettings(t *testing.T) {
+	headers := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		headers <- request.Header.Get("Authorization")
+
+		err := json.NewEncoder(writer).Encode(attestation.ABIEncodedResponseBody{Status: "INVALID", ABIEncodedResponse: "0x" + strings.Repeat("00", 100)})
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	attType, err := config.StringToByte32("EVMTransaction")
+	require.NoError(t, err)
+
+	source, err := config.StringToByte32("ETH")
+	require.NoError(t, err)
+
+	sourceConfig := config.Source{
+		Verifiers:       []config.Verifier{{URL: server.URL, APIKey: "key"}},
+		Mode:            config.ModeFailover,
+		Client:          &http.Client{Timeout: time.Second},
+		MaxResponseSize: 1 << 10,
+		APIKeyHeader:    "Authorization",
+		APIKeyPrefix:    "Bearer ",
+	}
+	types := config.AttestationTypes{attType: {SourcesConfig: map[[32]byte]config.Source{source: sourceConfig}}}
+
+	att := &attestation.Attestation{Request: append(append(attType[:], source[:]...), make([]byte, 32)...)}
+	require.NoError(t, att.PrepareRequest(types, nil))
+
+	_, confirmed, err := attestation.ResolveAttestationRequest(context.Background(), att)
+	require.NoError(t, err)
+	require.False(t, confirmed)
+	require.Equal(t, "Bearer key", <-headers)
+
+	sourceConfig.MaxResponseSize = 64
+	types[attType].SourcesConfig[source] = sourceConfig
+	require.NoError(t, att.PrepareRequest(types, nil))
+
+	_, _, err = attestation.ResolveAttestationRequest(context.Background(), att)
+	require.Error(t, err)
+}
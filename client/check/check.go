@@ -0,0 +1,329 @@
+// Package check validates the configuration of the client without starting it.
+package check
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/flare-foundation/go-flare-common/pkg/database"
+
+	"github.com/flare-foundation/fdc-client/client/attestation"
+	"github.com/flare-foundation/fdc-client/client/collector"
+	"github.com/flare-foundation/fdc-client/client/config"
+	"github.com/flare-foundation/fdc-client/client/store"
+)
+
+type Status string
+
+const (
+	StatusOK      Status = "ok"
+	StatusWarning Status = "warning" // the client starts, but the setting is likely a mistake
+	StatusError   Status = "error"   // the client fails to start or cannot work correctly
+)
+
+// Result is an outcome of a single check.
+type Result struct {
+	Check   string `json:"check"`
+	Status  Status `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// Report holds the results of all checks in the order they were run.
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// Options of a configuration check.
+type Options struct {
+	UserFile        string
+	SystemDirectory string
+	Ping            bool // if true, verifiers and the indexer (DB or RPC node) are contacted
+}
+
+func (r *Report) add(check string, status Status, format string, args ...any) {
+	r.Results = append(r.Results, Result{Check: check, Status: status, Message: fmt.Sprintf(format, args...)})
+}
+
+// OK returns true if no check resulted in an error.
+func (r *Report) OK() bool {
+	return !slices.ContainsFunc(r.Results, func(result Result) bool { return result.Status == StatusError })
+}
+
+// Count returns the number of results with status.
+func (r *Report) Count(status Status) int {
+	count := 0
+	for i := range r.Results {
+		if r.Results[i].Status == status {
+			count++
+		}
+	}
+
+	return count
+}
+
+// WriteText writes the report as a table followed by a summary.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	for _, result := range r.Results {
+		_, err := fmt.Fprintf(tw, "%s\t%s\t%s\n", strings.ToUpper(string(result.Status)), result.Check, result.Message)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(tw, "\n%d ok, %d warnings, %d errors\n", r.Count(StatusOK), r.Count(StatusWarning), r.Count(StatusError))
+	if err != nil {
+		return err
+	}
+
+	return tw.Flush()
+}
+
+// WriteJSON writes the report as JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(r)
+}
+
+// Run checks the configuration described by opts and returns the report.
+// Checks that depend on a configuration that could not be read are not run.
+func Run(ctx context.Context, opts Options) *Report {
+	report := new(Report)
+
+	user, system, err := config.Read(opts.UserFile, opts.SystemDirectory)
+	if err != nil {
+		report.add("config", StatusError, "%s", err)
+		return report
+	}
+	report.add("config", StatusOK, "chain %s, protocol %d", user.Chain, user.ProtocolID)
+
+	checkCollector(report, user)
+	checkVoterRegistries(report, system)
+	checkRoundStore(report, user)
+
+	types, err := config.ParseAttestationTypes(user.AttestationTypeConfig)
+	switch {
+	case err != nil:
+		report.add("types", StatusError, "%s", err)
+	case len(types) == 0:
+		report.add("types", StatusError, "no attestation types configured")
+	default:
+		report.add("types", StatusOK, "%d attestation types", len(types))
+	}
+
+	usedQueues := checkTypes(ctx, report, user, opts.Ping)
+	checkQueues(report, user.Queues, usedQueues)
+
+	if opts.Ping {
+		pingIndexer(ctx, report, user)
+	}
+
+	return report
+}
+
+func checkCollector(report *Report, user *config.UserRaw) {
+	switch user.Collector.Source {
+	case "", collector.SourceDB:
+		report.add("collector", StatusOK, "source db %s:%d", user.DB.Host, user.DB.Port)
+	case collector.SourceRPC:
+		if _, err := url.ParseRequestURI(user.Collector.RPCURL); err != nil {
+			report.add("collector", StatusError, "invalid rpc_url %q", user.Collector.RPCURL)
+		} else {
+			report.add("collector", StatusOK, "source rpc %s", user.Collector.RPCURL)
+		}
+	default:
+		report.add("collector", StatusError, "unknown source %q", user.Collector.Source)
+	}
+}
+
+func checkVoterRegistries(report *Report, system *config.System) {
+	if err := collector.ValidateVoterRegistries(system.VoterRegistries); err != nil {
+		report.add("voter_registries", StatusError, "%s", err)
+		return
+	}
+
+	latest := system.VoterRegistries[len(system.VoterRegistries)-1]
+	report.add("voter_registries", StatusOK, "%d registries, latest %v (abi %s) from reward epoch %d", len(system.VoterRegistries), latest.Address, latest.ABIVersion, latest.FromRewardEpoch)
+}
+
+func checkRoundStore(report *Report, user *config.UserRaw) {
+	switch user.RoundStore.Type {
+	case store.TypeNone:
+		report.add("round_store", StatusOK, "disabled")
+	case store.TypeSQLite:
+		if user.RoundStore.Path == "" {
+			report.add("round_store", StatusError, "path not set")
+		} else {
+			report.add("round_store", StatusOK, "sqlite %s", user.RoundStore.Path)
+		}
+	default:
+		report.add("round_store", StatusError, "unknown type %q", user.RoundStore.Type)
+	}
+}
+
+// checkTypes checks the response ABI and sources of each attestation type and returns the names of the queues used by the sources.
+func checkTypes(ctx context.Context, report *Report, user *config.UserRaw, ping bool) map[string]bool {
+	usedQueues := make(map[string]bool)
+
+	for _, typeName := range sortedKeys(user.AttestationTypeConfig) {
+		unparsed := user.AttestationTypeConfig[typeName]
+		check := "type " + typeName
+
+		args, _, err := config.ReadABI(unparsed.ABIPath)
+		if err != nil {
+			report.add(check, StatusError, "%s", err)
+			continue
+		}
+
+		if err := attestation.ValidateResponseABI(args); err != nil {
+			report.add(check, StatusError, "abi %s: %s", unparsed.ABIPath, err)
+			continue
+		}
+
+		attType, err := config.ParseAttestationType(unparsed)
+		if err != nil {
+			report.add(check, StatusError, "%s", err)
+			continue
+		}
+
+		report.add(check, StatusOK, "abi %s", unparsed.ABIPath)
+
+		for _, sourceName := range sortedKeys(unparsed.Sources) {
+			sourceID, err := config.StringToByte32(sourceName)
+			if err != nil {
+				report.add(check+" source "+sourceName, StatusError, "%s", err)
+				continue
+			}
+
+			source := attType.SourcesConfig[sourceID]
+			usedQueues[source.QueueName] = true
+
+			checkSource(ctx, report, check+" source "+sourceName, &source, user.Queues, ping)
+		}
+	}
+
+	return usedQueues
+}
+
+func checkSource(ctx context.Context, report *Report, check string, source *config.Source, queues config.Queues, ping bool) {
+	var problems []string
+
+	if _, ok := queues[source.QueueName]; !ok {
+		problems = append(problems, fmt.Sprintf("queue %q not in [queues]", source.QueueName))
+	}
+
+	if source.LUTLimit == 0 {
+		problems = append(problems, "lut_limit is 0, all responses are rejected")
+	}
+
+	for _, verifier := range source.Verifiers {
+		if _, err := url.ParseRequestURI(verifier.URL); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid verifier url %q", verifier.URL))
+		}
+	}
+
+	if len(problems) > 0 {
+		report.add(check, StatusError, "%s", strings.Join(problems, "; "))
+		return
+	}
+
+	report.add(check, StatusOK, "%d verifiers, mode %s, queue %s", len(source.Verifiers), source.Mode, source.QueueName)
+
+	if !ping {
+		return
+	}
+
+	for _, verifier := range source.Verifiers {
+		if err := attestation.PingVerifier(ctx, verifier, source); err != nil {
+			report.add(check+" verifier", StatusError, "%s: %s", verifier.URL, err)
+		} else {
+			report.add(check+" verifier", StatusOK, "%s", verifier.URL)
+		}
+	}
+}
+
+func checkQueues(report *Report, queues config.Queues, used map[string]bool) {
+	for _, name := range sortedKeys(queues) {
+		if !used[name] {
+			report.add("queue "+name, StatusWarning, "not used by any source")
+		} else {
+			report.add("queue "+name, StatusOK, "")
+		}
+	}
+}
+
+// pingIndexer connects to the DB or the RPC node the collector reads from.
+func pingIndexer(ctx context.Context, report *Report, user *config.UserRaw) {
+	check := "ping db"
+	ping := func() (string, error) { return pingDB(ctx, &user.DB) }
+
+	switch user.Collector.Source {
+	case "", collector.SourceDB:
+	case collector.SourceRPC:
+		check = "ping rpc"
+		ping = func() (string, error) { return pingRPC(ctx, user.Collector.RPCURL) }
+	default:
+		return
+	}
+
+	message, err := ping()
+	if err != nil {
+		report.add(check, StatusError, "%s", err)
+	} else {
+		report.add(check, StatusOK, "%s", message)
+	}
+}
+
+func pingDB(ctx context.Context, cfg *database.Config) (string, error) {
+	db, err := database.Connect(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return "", err
+	}
+	defer sqlDB.Close() //nolint:errcheck
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s:%d/%s", cfg.Host, cfg.Port, cfg.Database), nil
+}
+
+func pingRPC(ctx context.Context, rpcURL string) (string, error) {
+	client, err := ethclient.DialContext(ctx, rpcURL)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	block, err := client.BlockNumber(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s at block %d", rpcURL, block), nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	return keys
+}
@@ -0,0 +1,160 @@
+package check_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/flare-foundation/fdc-client/client/check"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	userFile        = "../../tests/configs/testConfig.toml" // relative to test
+	systemDirectory = "../../configs/systemConfigs"         // relative to test
+	abiFile         = "../../tests/configs/abis/EVMTransaction.json"
+)
+
+// writeConfig writes the test user configuration with replacements applied to a temporary file.
+func writeConfig(t *testing.T, replacements ...string) string {
+	file, err := os.ReadFile(userFile)
+	require.NoError(t, err)
+
+	abiPath, err := filepath.Abs(abiFile)
+	require.NoError(t, err)
+
+	cfg := strings.ReplaceAll(string(file), "../../tests/configs/abis/EVMTransaction.json", abiPath)
+	cfg = strings.NewReplacer(replacements...).Replace(cfg)
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(cfg), 0o600))
+
+	return path
+}
+
+// result returns the first result of the check.
+func result(t *testing.T, report *check.Report, name string) check.Result {
+	for _, result := range report.Results {
+		if result.Check == name {
+			return result
+		}
+	}
+
+	require.Failf(t, "missing check", "check %s not in report", name)
+	return check.Result{}
+}
+
+func TestRun(t *testing.T) {
+	report := check.Run(context.Background(), check.Options{UserFile: userFile, SystemDirectory: systemDirectory})
+	require.True(t, report.OK(), report.Results)
+	require.Equal(t, check.StatusOK, result(t, report, "type EVMTransaction source ETH").Status)
+	require.Equal(t, check.StatusOK, result(t, report, "queue evmETH").Status)
+	require.Equal(t, check.StatusOK, result(t, report, "voter_registries").Status)
+
+	var text bytes.Buffer
+	require.NoError(t, report.WriteText(&text))
+	require.Contains(t, text.String(), "0 errors")
+
+	var decoded check.Report
+	var encoded bytes.Buffer
+	require.NoError(t, report.WriteJSON(&encoded))
+	require.NoError(t, json.Unmarshal(encoded.Bytes(), &decoded))
+	require.Equal(t, *report, decoded)
+}
+
+func TestRunErrors(t *testing.T) {
+	t.Run("unknown chain", func(t *testing.T) {
+		path := writeConfig(t, `chain = "coston"`, `chain = "coston3"`)
+
+		report := check.Run(context.Background(), check.Options{UserFile: path, SystemDirectory: systemDirectory})
+		require.False(t, report.OK())
+		require.Len(t, report.Results, 1)
+		require.Contains(t, report.Results[0].Message, `unknown chain "coston3"`)
+		require.Contains(t, report.Results[0].Message, "coston2")
+	})
+
+	t.Run("source", func(t *testing.T) {
+		path := writeConfig(t, `queue = "evmETH"`, `queue = "eth"`, `lut_limit = "18446744073709551615"`, `lut_limit = "0"`)
+
+		report := check.Run(context.Background(), check.Options{UserFile: path, SystemDirectory: systemDirectory})
+		require.False(t, report.OK())
+
+		source := result(t, report, "type EVMTransaction source ETH")
+		require.Equal(t, check.StatusError, source.Status)
+		require.Contains(t, source.Message, `queue "eth" not in [queues]`)
+		require.Contains(t, source.Message, "lut_limit is 0")
+
+		require.Equal(t, check.StatusWarning, result(t, report, "queue evmETH").Status)
+	})
+
+	t.Run("voter registries", func(t *testing.T) {
+		directory := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(directory, "200"), 0o700))
+
+		override := "[[voter_registries]]\nregistry_address = \"0x0000000000000000000000000000000000000002\"\nabi_version = \"v0\"\n"
+		require.NoError(t, os.WriteFile(filepath.Join(directory, "200", "coston.toml"), []byte(override), 0o600))
+
+		report := check.Run(context.Background(), check.Options{UserFile: userFile, SystemDirectory: directory})
+		require.False(t, report.OK())
+		require.Contains(t, result(t, report, "voter_registries").Message, `unknown voter registry abi version "v0"`)
+	})
+
+	t.Run("abi", func(t *testing.T) {
+		abi := filepath.Join(t.TempDir(), "abi.json")
+		require.NoError(t, os.WriteFile(abi, []byte(`{"name":"r","type":"tuple","components":[{"name":"value","type":"uint256"}]}`), 0o600))
+
+		path := writeConfig(t)
+		cfg, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		absABI, err := filepath.Abs(abiFile)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, []byte(strings.ReplaceAll(string(cfg), absABI, abi)), 0o600))
+
+		report := check.Run(context.Background(), check.Options{UserFile: path, SystemDirectory: systemDirectory})
+		require.False(t, report.OK())
+		require.Contains(t, result(t, report, "type EVMTransaction").Message, "expected at least 4 fields")
+	})
+}
+
+func TestRunPing(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+
+	verifier := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(int(status.Load()))
+	}))
+	defer verifier.Close()
+
+	path := writeConfig(t, "http://localhost:5556", verifier.URL, "# Queues", "[collector]\nsource = \"rpc\"\nrpc_url = \"http://127.0.0.1:1\"\n")
+
+	report := check.Run(context.Background(), check.Options{UserFile: path, SystemDirectory: systemDirectory, Ping: true})
+	require.Equal(t, check.StatusOK, result(t, report, "type EVMTransaction source ETH verifier").Status)
+	require.Equal(t, check.StatusError, result(t, report, "ping rpc").Status)
+
+	// verifiers accept only POST requests on their url
+	status.Store(http.StatusMethodNotAllowed)
+
+	report = check.Run(context.Background(), check.Options{UserFile: path, SystemDirectory: systemDirectory, Ping: true})
+	require.Equal(t, check.StatusOK, result(t, report, "type EVMTransaction source ETH verifier").Status)
+
+	for _, code := range []int32{http.StatusUnauthorized, http.StatusInternalServerError} {
+		status.Store(code)
+
+		report = check.Run(context.Background(), check.Options{UserFile: path, SystemDirectory: systemDirectory, Ping: true})
+		require.Equal(t, check.StatusError, result(t, report, "type EVMTransaction source ETH verifier").Status, code)
+	}
+
+	verifier.Close()
+
+	report = check.Run(context.Background(), check.Options{UserFile: path, SystemDirectory: systemDirectory, Ping: true})
+	require.Equal(t, check.StatusError, result(t, report, "type EVMTransaction source ETH verifier").Status)
+}
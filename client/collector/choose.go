@@ -2,6 +2,7 @@ package collector
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/flare-foundation/go-flare-common/pkg/database"
 	"github.com/flare-foundation/go-flare-common/pkg/logger"
@@ -12,14 +13,13 @@ import (
 	"github.com/flare-foundation/fdc-client/client/timing"
 
 	"github.com/ethereum/go-ethereum/common"
-	"gorm.io/gorm"
 )
 
 // BitVoteListener initiates a channel that servers payloads data submitted do submitContractAddress to method with funcSig for protocol.
 // Payloads for roundID are served whenever a trigger provides a roundID.
 func BitVoteListener(
 	ctx context.Context,
-	db *gorm.DB,
+	source IndexerSource,
 	submitContractAddress common.Address,
 	funcSel [4]byte,
 	protocol uint8,
@@ -38,39 +38,12 @@ func BitVoteListener(
 			return
 		}
 
-		params := database.TxParams{
-			ToAddress:   submitContractAddress,
-			FunctionSel: funcSel,
-			From:        int64(timing.ChooseStartTS(roundID)) - 1, // -1 to include first second of the choose phase and its bitVotes
-			To:          int64(timing.ChooseEndTS(roundID)) - 1,   // bitVotes that happen on the deadline are not considered valid
-		}
-
-		txs, err := database.FetchTransactionsByAddressAndSelectorTimestamp(
-			ctx,
-			db,
-			params,
-		)
+		bitVotes, err := FetchBitVotes(ctx, source, submitContractAddress, funcSel, protocol, roundID)
 		if err != nil {
 			logger.Errorf("fetch txs: %v", err)
 			continue
 		}
 
-		var bitVotes []payload.Message
-
-		for i := range txs {
-			tx := &txs[i]
-			payloads, err := payload.ExtractPayloads(tx)
-			if err != nil {
-				logger.Errorf("extract payload: %v", err)
-				continue
-			}
-
-			bitVote, ok := payloads[protocol]
-			if ok {
-				bitVotes = append(bitVotes, bitVote)
-			}
-		}
-
 		if len(bitVotes) > 0 {
 			logger.Infof("Received %d bitVotes for round %d", len(bitVotes), roundID)
 
@@ -86,32 +59,90 @@ func BitVoteListener(
 	}
 }
 
-// PrepareChooseTrigger tracks chain timestamps and passes roundID of the round whose choose phase has just ended to the trigger channel.
-func PrepareChooseTrigger(ctx context.Context, trigger chan uint32, db *gorm.DB) {
-	state, err := database.FetchState(ctx, db, nil)
+// FetchBitVotes returns payloads for protocol submitted to submitContractAddress to method with funcSel in the choose phase of roundID.
+// Payloads of transactions that cannot be parsed are skipped.
+func FetchBitVotes(
+	ctx context.Context,
+	source IndexerSource,
+	submitContractAddress common.Address,
+	funcSel [4]byte,
+	protocol uint8,
+	roundID uint32,
+) ([]payload.Message, error) {
+	params := database.TxParams{
+		ToAddress:   submitContractAddress,
+		FunctionSel: funcSel,
+		From:        int64(timing.ChooseStartTS(roundID)) - 1, // -1 to include first second of the choose phase and its bitVotes
+		To:          int64(timing.ChooseEndTS(roundID)) - 1,   // bitVotes that happen on the deadline are not considered valid
+	}
+
+	txs, err := source.FetchTransactionsByTimestamp(ctx, params)
 	if err != nil {
-		logger.Panicf("database: %v", err)
+		return nil, err
+	}
+
+	var bitVotes []payload.Message
+
+	for i := range txs {
+		tx := &txs[i]
+		payloads, err := payload.ExtractPayloads(tx)
+		if err != nil {
+			logger.Errorf("extract payload: %v", err)
+			continue
+		}
+
+		bitVote, ok := payloads[protocol]
+		if ok {
+			bitVotes = append(bitVotes, bitVote)
+		}
 	}
 
-	nextChoosePhaseRoundIDEnd := new(uint32)
-	nextChoosePhaseEndTimestamp := new(uint64)
+	return bitVotes, nil
+}
+
+// PrepareChooseTrigger tracks chain timestamps and passes roundID of the round whose choose phase has just ended to the trigger channel.
+//
+// An error is returned if the initial state of the indexer cannot be fetched. The trigger returns nil when ctx is done.
+func PrepareChooseTrigger(ctx context.Context, trigger chan uint32, source IndexerSource) error {
+	return prepareChooseTrigger(ctx, new(chooseCursor), trigger, source)
+}
+
+// chooseCursor is the progress of PrepareChooseTrigger that is kept when the trigger is restarted.
+type chooseCursor struct {
+	started                     bool // next choose phase end was set from the indexer state
+	nextChoosePhaseRoundIDEnd   uint32
+	nextChoosePhaseEndTimestamp uint64
+}
 
-	*nextChoosePhaseRoundIDEnd, *nextChoosePhaseEndTimestamp = timing.NextChooseEnd(state.BlockTimestamp)
+// prepareChooseTrigger is PrepareChooseTrigger that continues from cursor. The indexer state is used to set the next choose phase end only if the cursor is not started.
+func prepareChooseTrigger(ctx context.Context, cursor *chooseCursor, trigger chan uint32, source IndexerSource) error {
+	if !cursor.started {
+		state, err := source.FetchState(ctx)
+		if err != nil {
+			return fmt.Errorf("database: %s", err)
+		}
+
+		cursor.nextChoosePhaseRoundIDEnd, cursor.nextChoosePhaseEndTimestamp = timing.NextChooseEnd(state.BlockTimestamp)
+		cursor.started = true
+	} else {
+		logger.Infof("prepareChooseTriggers resuming at round %d", cursor.nextChoosePhaseRoundIDEnd)
+	}
 
 	bitVoteTicker := time.NewTicker(time.Hour) // timer will be reset to collect duration
-	go configureTicker(ctx, bitVoteTicker, time.Unix(int64(*nextChoosePhaseEndTimestamp), 0), bitVoteHeadStart)
+	defer bitVoteTicker.Stop()
+	go configureTicker(ctx, bitVoteTicker, time.Unix(int64(cursor.nextChoosePhaseEndTimestamp), 0), bitVoteHeadStart)
 
 	for {
 		ticker := time.NewTicker(databasePollTime)
 
 		for {
-			state, err := database.FetchState(ctx, db, nil)
+			state, err := source.FetchState(ctx)
 
 			if err != nil {
 				logger.Errorf("database: %v", err)
 			} else {
 				done := tryTriggerBitVote(
-					ctx, nextChoosePhaseRoundIDEnd, nextChoosePhaseEndTimestamp, state.BlockTimestamp, trigger,
+					ctx, &cursor.nextChoosePhaseRoundIDEnd, &cursor.nextChoosePhaseEndTimestamp, state.BlockTimestamp, trigger,
 				)
 
 				if done {
@@ -123,15 +154,19 @@ func PrepareChooseTrigger(ctx context.Context, trigger chan uint32, db *gorm.DB)
 			case <-ticker.C:
 
 			case <-ctx.Done():
+				ticker.Stop()
 				logger.Infof("prepareChooseTriggers exiting: %v", ctx.Err())
-				return
+				return nil
 			}
 		}
 
+		ticker.Stop()
+
 		select {
 		case <-bitVoteTicker.C:
 		case <-ctx.Done():
 			logger.Infof("prepareChooseTriggers exiting: %v", ctx.Err())
+			return nil
 		}
 	}
 }
@@ -3,7 +3,10 @@ package collector
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 
+	registryv1 "github.com/flare-foundation/go-flare-common/pkg/contracts/registry"
 	"github.com/flare-foundation/go-flare-common/pkg/contracts/relay"
 	"github.com/flare-foundation/go-flare-common/pkg/contracts/submission"
 
@@ -15,12 +18,13 @@ import (
 	"github.com/flare-foundation/fdc-client/client/collector/registry"
 
 	"github.com/flare-foundation/fdc-client/client/config"
+	"github.com/flare-foundation/fdc-client/client/health"
 	"github.com/flare-foundation/fdc-client/client/shared"
 
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
-	"gorm.io/gorm"
+	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 const (
@@ -35,9 +39,13 @@ const (
 	syncRetry = 30
 )
 
+const (
+	SourceDB  = "db"
+	SourceRPC = "rpc"
+)
+
 var signingPolicyInitializedEventSel common.Hash
 var AttestationRequestEventSel common.Hash
-var voterRegisteredEventSel common.Hash
 
 var Submit2FuncSel [4]byte
 
@@ -65,6 +73,18 @@ func init() {
 
 	AttestationRequestEventSel = requestEvent.ID
 
+	registryV1ABI, err := registryv1.RegistryMetaData.GetAbi()
+	if err != nil {
+		panic(fmt.Sprintf("cannot get registry v1 ABI: %v", err))
+	}
+
+	voterRegisteredV1Event, ok := registryV1ABI.Events["VoterRegistered"]
+	if !ok {
+		panic("cannot get VoterRegistered v1 event abi")
+	}
+
+	RegisterRegistryParser(RegistryABIv1, RegistryParser{EventSel: voterRegisteredV1Event.ID, Parse: parseRegistryV1})
+
 	registryABI, err := registry.RegistryMetaData.GetAbi()
 	if err != nil {
 		panic(fmt.Sprintf("cannot get registryABI: %v", err))
@@ -75,7 +95,7 @@ func init() {
 		panic("cannot get VoterRegistered event abi")
 	}
 
-	voterRegisteredEventSel = voterRegisteredEvent.ID
+	RegisterRegistryParser(RegistryABIv2, RegistryParser{EventSel: voterRegisteredEvent.ID, Parse: parseRegistryV2})
 
 	submissionABI, err := submission.SubmissionMetaData.GetAbi()
 	if err != nil {
@@ -85,91 +105,212 @@ func init() {
 }
 
 type Collector struct {
-	ProtocolID                   uint8
-	SubmitContractAddress        common.Address
-	FdcContractAddress           common.Address
-	RelayContractAddress         common.Address
-	VoterRegistryContractAddress common.Address
-
-	DB              *gorm.DB
+	ProtocolID            uint8
+	SubmitContractAddress common.Address
+	FdcContractAddress    common.Address
+	RelayContractAddress  common.Address
+	VoterRegistries       config.VoterRegistries
+	StartupLookbackRounds uint32
+
+	Source          IndexerSource
 	Requests        chan<- []database.Log
+	Reverted        chan<- []database.Log
 	BitVotes        chan<- payload.Round
 	SigningPolicies chan<- []shared.VotersData
+	Health          *health.Registry // readiness checks of the listeners and the indexer are registered on Run, can be nil
+
+	requestsMu      sync.Mutex
+	requestsStopped bool
+	stopRequests    context.CancelFunc
+	requestsStopAt  atomic.Uint64 // timestamp of the first block after which requests are not collected, 0 if not set
+	requestsDone    chan struct{} // closed when AttestationRequestListener stops
+	requestsClosed  bool          // requestsDone is closed
+}
+
+// NewSource connects to the IndexerSource configured in the collector section of the user config.
+func NewSource(user *config.UserRaw) (IndexerSource, error) {
+	switch user.Collector.Source {
+	case "", SourceDB:
+		db, err := database.Connect(&user.DB)
+		if err != nil {
+			return nil, fmt.Errorf("could not connect to database: %s", err)
+		}
+		return NewDBSource(db), nil
+	case SourceRPC:
+		client, err := ethclient.Dial(user.Collector.RPCURL)
+		if err != nil {
+			return nil, fmt.Errorf("could not connect to node: %s", err)
+		}
+		return NewRPCSource(client, user.Collector.MaxBlockRange, user.Collector.LookbackBlocks), nil
+	default:
+		return nil, fmt.Errorf("unknown collector source %s", user.Collector.Source)
+	}
 }
 
 // New creates new Collector from user and system configs.
 func New(user *config.UserRaw, system *config.System, sharedDataPipes *shared.DataPipes) *Collector {
-	db, err := database.Connect(&user.DB)
+	source, err := NewSource(user)
 	if err != nil {
-		logger.Panicf("Could not connect to database: %v", err)
+		logger.Panicf("Collector source: %v", err)
 	}
 
-	runner := Collector{
-		ProtocolID:                   user.ProtocolID,
-		SubmitContractAddress:        system.Addresses.SubmitContract,
-		FdcContractAddress:           system.Addresses.FdcContract,
-		RelayContractAddress:         system.Addresses.RelayContract,
-		VoterRegistryContractAddress: system.Addresses.VoterRegistryContract,
+	if err := ValidateVoterRegistries(system.VoterRegistries); err != nil {
+		logger.Panicf("Invalid voter registries: %v", err)
+	}
 
-		DB:              db,
+	runner := Collector{
+		ProtocolID:            user.ProtocolID,
+		SubmitContractAddress: system.Addresses.SubmitContract,
+		FdcContractAddress:    system.Addresses.FdcContract,
+		RelayContractAddress:  system.Addresses.RelayContract,
+		VoterRegistries:       system.VoterRegistries,
+		StartupLookbackRounds: user.Collector.StartupLookbackRounds,
+
+		Source:          source,
 		SigningPolicies: sharedDataPipes.Voters,
 		BitVotes:        sharedDataPipes.BitVotes,
 		Requests:        sharedDataPipes.Requests,
+		Reverted:        sharedDataPipes.Reverted,
+		Health:          sharedDataPipes.Health,
 	}
 
 	return &runner
 }
 
 // Run starts SigningPolicyInitializedListener, BitVoteListener, and AttestationRequestListener in go routines.
+// AttestationRequestListener can be stopped earlier with StopRequests.
+//
+// The listeners are supervised. A listener that fails, e.g., because the indexer is not reachable on startup,
+// is restarted with exponential backoff and continues from where it failed.
+//
+// If Health is set, the readiness checks "collector", which fails if any listener stopped or is degraded, and "indexer",
+// which fails if the latest indexed block is older than outOfSyncTolerance, are registered.
 func (c *Collector) Run(ctx context.Context) {
-	go SigningPolicyInitializedListener(ctx, c.DB, c.RelayContractAddress, c.VoterRegistryContractAddress, c.SigningPolicies)
-	go AttestationRequestListener(ctx, c.DB, c.FdcContractAddress, requestListenerInterval, c.Requests)
+	c.requestsMu.Lock()
+	requestsCtx, stopRequests := context.WithCancel(ctx)
+	c.stopRequests = stopRequests
+	if c.requestsStopped {
+		stopRequests()
+	}
+	c.requestsMu.Unlock()
+
+	source := c.Source
+	sup := newSupervisor()
+	if c.Health != nil {
+		latest := new(atomic.Uint64)
+		source = trackedSource{IndexerSource: c.Source, latest: latest}
+
+		c.Health.Register("collector", sup.check)
+		c.Health.Register("indexer", func() error { return indexerLag(latest.Load()) })
+	}
+
+	signingPolicyCursor := new(signingPolicyCursor)
+	sup.run(ctx, "SigningPolicyInitializedListener", func(ctx context.Context) error {
+		return signingPolicyInitializedListener(ctx, signingPolicyCursor, source, c.RelayContractAddress, c.VoterRegistries, c.SigningPolicies)
+	})
+
+	requestCursor := &requestCursor{stopAt: &c.requestsStopAt}
+	sup.run(requestsCtx, "AttestationRequestListener", func(ctx context.Context) error {
+		err := attestationRequestListener(ctx, requestCursor, source, c.FdcContractAddress, requestListenerInterval, c.StartupLookbackRounds, c.Requests, c.Reverted)
+		if err == nil {
+			c.closeRequestsDone()
+		}
+
+		return err
+	})
 
 	chooseTrigger := make(chan uint32)
-	go BitVoteListener(ctx, c.DB, c.SubmitContractAddress, Submit2FuncSel, c.ProtocolID, chooseTrigger, c.BitVotes)
-	go PrepareChooseTrigger(ctx, chooseTrigger, c.DB)
+	sup.run(ctx, "BitVoteListener", func(ctx context.Context) error {
+		BitVoteListener(ctx, source, c.SubmitContractAddress, Submit2FuncSel, c.ProtocolID, chooseTrigger, c.BitVotes)
+		return nil
+	})
+
+	chooseCursor := new(chooseCursor)
+	sup.run(ctx, "PrepareChooseTrigger", func(ctx context.Context) error {
+		return prepareChooseTrigger(ctx, chooseCursor, chooseTrigger, source)
+	})
+}
+
+// StopRequests stops AttestationRequestListener, while the other listeners keep running.
+// It is used on shutdown, so that no requests are collected for rounds that will not be finalized.
+func (c *Collector) StopRequests() {
+	c.requestsMu.Lock()
+	c.requestsStopped = true
+	if c.stopRequests != nil {
+		c.stopRequests()
+	}
+	c.requestsMu.Unlock()
+
+	c.closeRequestsDone()
+}
+
+// StopRequestsAfter stops AttestationRequestListener once it has queried a block with timestamp at least ts,
+// so that the requests emitted before ts are collected even if the indexer lags behind.
+// The returned channel is closed when the listener stops.
+func (c *Collector) StopRequestsAfter(ts uint64) <-chan struct{} {
+	c.requestsMu.Lock()
+	defer c.requestsMu.Unlock()
+
+	c.requestsStopAt.Store(ts)
+
+	return c.requestsDoneChan()
 }
 
-// WaitForDBToSync waits for db to sync. After many unsuccessful attempts it panics.
-func (c *Collector) WaitForDBToSync(ctx context.Context) {
-	k := 0
-	for k < syncRetry {
+// closeRequestsDone closes the channel returned by StopRequestsAfter if it is not closed yet.
+func (c *Collector) closeRequestsDone() {
+	c.requestsMu.Lock()
+	defer c.requestsMu.Unlock()
+
+	done := c.requestsDoneChan()
+	if !c.requestsClosed {
+		close(done)
+		c.requestsClosed = true
+	}
+}
+
+// requestsDoneChan returns requestsDone and creates it if needed. The caller must hold requestsMu.
+func (c *Collector) requestsDoneChan() chan struct{} {
+	if c.requestsDone == nil {
+		c.requestsDone = make(chan struct{})
+	}
+
+	return c.requestsDone
+}
+
+// WaitForDBToSync waits for db to sync. Errors fetching the state of the database are logged and retried.
+// An error is returned if the database is not in sync after syncRetry retries or ctx is done.
+func (c *Collector) WaitForDBToSync(ctx context.Context) error {
+	for k := 0; ; k++ {
 		if k > 0 {
 			logger.Debugf("Checking database for %v/%v time", k, syncRetry)
 		}
-		state, err := database.FetchState(ctx, c.DB, nil)
+
+		sleepTime := minSleepTime
+
+		state, err := c.Source.FetchState(ctx)
 		if err != nil {
-			logger.Panicf("database: %v", err)
+			logger.Warnf("database: %v", err)
+		} else {
+			outOfSync := time.Since(time.Unix(int64(state.BlockTimestamp), 0))
+			if outOfSync < outOfSyncTolerance {
+				logger.Debug("Database in sync")
+				return nil
+			}
+
+			logger.Warnf("Database out of sync. Delayed for %v", outOfSync)
+			err = fmt.Errorf("delayed for %v", outOfSync)
+			sleepTime = max(min(maxSleepTime, outOfSync/20), minSleepTime)
 		}
 
-		dbTime := time.Unix(int64(state.BlockTimestamp), 0)
-
-		outOfSync := time.Since(dbTime)
-		if outOfSync < outOfSyncTolerance {
-			logger.Debug("Database in sync")
-			return
+		if k >= syncRetry {
+			return fmt.Errorf("database not in sync after %v retries: %s", syncRetry, err)
 		}
 
-		logger.Warnf("Database out of sync. Delayed for %v", outOfSync)
-		sleepTime := min(maxSleepTime, outOfSync/20)
-		sleepTime = max(sleepTime, minSleepTime)
 		logger.Warnf("Sleeping for %v", sleepTime)
-		k++
-		time.Sleep(sleepTime)
-	}
-
-	logger.Warnf("Checking database for the final time")
-	state, err := database.FetchState(ctx, c.DB, nil)
-	if err != nil {
-		logger.Panicf("database: %v", err)
-	}
-
-	dbTime := time.Unix(int64(state.BlockTimestamp), 0)
-
-	outOfSync := time.Since(dbTime)
-	if outOfSync > outOfSyncTolerance {
-		logger.Panicf("Database out of sync after %v retries. Delayed for %v", syncRetry, outOfSync)
-	} else {
-		logger.Debug("Database in sync")
+		select {
+		case <-time.After(sleepTime):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
 }
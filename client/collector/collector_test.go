@@ -3,21 +3,25 @@ package collector_test
 import (
 	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
+	"sync/atomic"
 
 	"testing"
 	"time"
 
 	"github.com/flare-foundation/fdc-client/client/collector"
+	"github.com/flare-foundation/fdc-client/client/health"
+	"github.com/flare-foundation/fdc-client/client/shared"
 	"github.com/flare-foundation/fdc-client/client/timing"
+	"github.com/flare-foundation/fdc-client/tests/mocks"
 
 	"github.com/flare-foundation/go-flare-common/pkg/database"
 	"github.com/flare-foundation/go-flare-common/pkg/payload"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/require"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
 const (
@@ -36,13 +40,8 @@ var (
 	funcSel            = [4]byte{1, 2, 3, 4}
 )
 
-func InMemoryDB(t *testing.T, name string) *gorm.DB {
-	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", name)
-
-	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
-		// Logger: logger.Default.LogMode(logger.Info),
-	})
-
+func newIndexerDB(t *testing.T, name string) *mocks.IndexerDB {
+	db, err := mocks.NewIndexerDB(name)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -53,28 +52,21 @@ func TestPrepareChooseTrigger(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	db := InMemoryDB(t, "choose")
+	db := newIndexerDB(t, "choose")
 
 	now := uint64(time.Now().Unix())
 
-	state := database.State{
-		Name: "last_database_block", Index: 12, BlockTimestamp: now, Updated: time.Now()}
-
-	err := db.AutoMigrate(&database.State{})
+	err := db.SetState(12, now)
 	require.NoError(t, err)
 
-	db.Create(&state)
-
 	trigger := make(chan uint32)
 
 	go collector.PrepareChooseTrigger(ctx, trigger, db)
 
 	time.Sleep(1 * time.Second)
 
-	state.Index = 13
-	state.BlockTimestamp += 90
-
-	db.Save(&state)
+	err = db.SetState(13, now+90)
+	require.NoError(t, err)
 
 	expectedID, _ := timing.NextChooseEnd(now)
 
@@ -91,10 +83,7 @@ func TestBitVoteListener(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	db := InMemoryDB(t, "bitvote")
-
-	err := db.AutoMigrate(&database.Transaction{})
-	require.NoError(t, err)
+	db := newIndexerDB(t, "bitvote")
 
 	trigger := make(chan uint32)
 	bitVotesChan := make(chan payload.Round, 2)
@@ -117,7 +106,8 @@ func TestBitVoteListener(t *testing.T) {
 		Timestamp:   timestamp,
 	}
 
-	db.Create(&tx)
+	err = db.AddTransactions(tx)
+	require.NoError(t, err)
 
 	go collector.BitVoteListener(
 		ctx,
@@ -151,19 +141,11 @@ func TestAttestationRequestListener(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	db := InMemoryDB(t, "requests")
+	db := newIndexerDB(t, "requests")
 
 	now := uint64(time.Now().Unix())
 
-	state := database.State{
-		Name: "last_database_block", Index: 205597800, BlockTimestamp: now, Updated: time.Now()}
-
-	err := db.AutoMigrate(&database.State{})
-	require.NoError(t, err)
-
-	db.Create(&state)
-
-	err = db.AutoMigrate(&database.Log{})
+	err := db.SetState(205597800, now)
 	require.NoError(t, err)
 
 	requestLog := database.Log{
@@ -179,7 +161,8 @@ func TestAttestationRequestListener(t *testing.T) {
 		BlockNumber:     205597799,
 	}
 
-	db.Create(&requestLog)
+	err = db.AddLogs(requestLog)
+	require.NoError(t, err)
 
 	requestChan := make(chan []database.Log, 10)
 
@@ -188,7 +171,9 @@ func TestAttestationRequestListener(t *testing.T) {
 		db,
 		fdcContractAddr,
 		listenerInterval,
+		0,
 		requestChan,
+		make(chan []database.Log, 10),
 	)
 
 	select {
@@ -198,3 +183,299 @@ func TestAttestationRequestListener(t *testing.T) {
 		t.Fatal("context cancelled")
 	}
 }
+
+func TestAttestationRequestListenerLookback(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	db := newIndexerDB(t, "requestsLookback")
+
+	now := uint64(time.Now().Unix())
+
+	err := db.SetState(205597800, now)
+	require.NoError(t, err)
+
+	oldestUnfinished, _ := timing.OldestUnfinishedRound(now)
+
+	// requests from the oldest unfinished round, from two rounds before it and from three rounds before it
+	for i, roundID := range []uint32{oldestUnfinished, oldestUnfinished - 2, oldestUnfinished - 3} {
+		requestLog := database.Log{
+			Address:         hex.EncodeToString(fdcContractAddr[:]),
+			Data:            "00",
+			Topic0:          hex.EncodeToString(collector.AttestationRequestEventSel[:]),
+			Topic1:          "NULL",
+			Topic2:          "NULL",
+			Topic3:          "NULL",
+			TransactionHash: fmt.Sprintf("%064x", i),
+			LogIndex:        uint64(i),
+			Timestamp:       timing.RoundStartTS(roundID),
+			BlockNumber:     205597790 + uint64(i),
+		}
+
+		err = db.AddLogs(requestLog)
+		require.NoError(t, err)
+	}
+
+	requestChan := make(chan []database.Log, 10)
+
+	go collector.AttestationRequestListener(
+		ctx,
+		db,
+		fdcContractAddr,
+		listenerInterval,
+		2,
+		requestChan,
+		make(chan []database.Log, 10),
+	)
+
+	select {
+	case logs := <-requestChan:
+		require.Len(t, logs, 2)
+	case <-ctx.Done():
+		t.Fatal("context cancelled")
+	}
+}
+
+// requestLogAt returns a request log emitted by fdcContractAddr in transaction i at blockNumber.
+func requestLogAt(i int, blockNumber, timestamp uint64) database.Log {
+	return database.Log{
+		Address:         hex.EncodeToString(fdcContractAddr[:]),
+		Data:            "00",
+		Topic0:          hex.EncodeToString(collector.AttestationRequestEventSel[:]),
+		Topic1:          "NULL",
+		Topic2:          "NULL",
+		Topic3:          "NULL",
+		TransactionHash: fmt.Sprintf("%064x", i),
+		TransactionID:   uint64(i),
+		Timestamp:       timestamp,
+		BlockNumber:     blockNumber,
+	}
+}
+
+func receiveLogs(ctx context.Context, t *testing.T, c <-chan []database.Log) []database.Log {
+	select {
+	case logs := <-c:
+		return logs
+	case <-ctx.Done():
+		t.Fatal("context cancelled")
+		return nil
+	}
+}
+
+func TestAttestationRequestListenerRollback(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	db := newIndexerDB(t, "requestsRollback")
+
+	now := uint64(time.Now().Unix())
+
+	require.NoError(t, db.SetState(100, now))
+
+	first := requestLogAt(1, 99, now-1)
+	require.NoError(t, db.AddLogs(first))
+
+	requestChan := make(chan []database.Log, 10)
+	revertedChan := make(chan []database.Log, 10)
+
+	go collector.AttestationRequestListener(ctx, db, fdcContractAddr, 100*time.Millisecond, 0, requestChan, revertedChan)
+
+	logs := receiveLogs(ctx, t, requestChan)
+	require.Len(t, logs, 1)
+
+	// the indexer is rewound and the block with the request is indexed again without it
+	require.NoError(t, db.RemoveLogs(first))
+	require.NoError(t, db.SetState(98, now))
+
+	logs = receiveLogs(ctx, t, revertedChan)
+	require.Len(t, logs, 1)
+	require.Equal(t, first.TransactionHash, logs[0].TransactionHash)
+
+	second := requestLogAt(2, 100, now)
+	require.NoError(t, db.AddLogs(second))
+	require.NoError(t, db.SetState(101, now+1))
+
+	logs = receiveLogs(ctx, t, requestChan)
+	require.Len(t, logs, 1)
+	require.Equal(t, second.TransactionHash, logs[0].TransactionHash)
+}
+
+func TestAttestationRequestListenerReorg(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	db := newIndexerDB(t, "requestsReorg")
+
+	now := uint64(time.Now().Unix())
+
+	require.NoError(t, db.SetState(100, now))
+
+	first := requestLogAt(1, 99, now-1)
+	require.NoError(t, db.AddTransactions(database.Transaction{
+		BaseEntity:  database.BaseEntity{ID: 1},
+		Hash:        first.TransactionHash,
+		BlockNumber: first.BlockNumber,
+		BlockHash:   fmt.Sprintf("%064x", 0xaa),
+	}))
+	require.NoError(t, db.AddLogs(first))
+
+	requestChan := make(chan []database.Log, 10)
+	revertedChan := make(chan []database.Log, 10)
+
+	go collector.AttestationRequestListener(ctx, db, fdcContractAddr, 100*time.Millisecond, 0, requestChan, revertedChan)
+
+	logs := receiveLogs(ctx, t, requestChan)
+	require.Len(t, logs, 1)
+
+	// block 99 is replaced by a block with a different request, the latest block stays the same
+	second := requestLogAt(2, 99, now-1)
+	require.NoError(t, db.AddTransactions(database.Transaction{
+		BaseEntity:  database.BaseEntity{ID: 2},
+		Hash:        second.TransactionHash,
+		BlockNumber: second.BlockNumber,
+		BlockHash:   fmt.Sprintf("%064x", 0xbb),
+	}))
+	require.NoError(t, db.RemoveLogs(first))
+	require.NoError(t, db.AddLogs(second))
+
+	logs = receiveLogs(ctx, t, revertedChan)
+	require.Len(t, logs, 1)
+	require.Equal(t, first.TransactionHash, logs[0].TransactionHash)
+
+	logs = receiveLogs(ctx, t, requestChan)
+	require.Len(t, logs, 1)
+	require.Equal(t, second.TransactionHash, logs[0].TransactionHash)
+}
+
+// flakySource is an IndexerSource whose initial request logs fail to be fetched the first failures times.
+type flakySource struct {
+	collector.IndexerSource
+	failures atomic.Int32
+}
+
+func (s *flakySource) FetchLogsFromTimestampToBlock(ctx context.Context, params database.LogsParams) ([]database.Log, error) {
+	if s.failures.Add(-1) >= 0 {
+		return nil, errors.New("connection refused")
+	}
+
+	return s.IndexerSource.FetchLogsFromTimestampToBlock(ctx, params)
+}
+
+func TestAttestationRequestListenerInitialError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	db := newIndexerDB(t, "requestsInitialError")
+	require.NoError(t, db.SetState(100, uint64(time.Now().Unix())))
+
+	source := &flakySource{IndexerSource: db}
+	source.failures.Store(1)
+
+	err := collector.AttestationRequestListener(ctx, source, fdcContractAddr, 100*time.Millisecond, 0, make(chan []database.Log, 10), make(chan []database.Log, 10))
+	require.ErrorContains(t, err, "connection refused")
+}
+
+func TestRunRestartsListeners(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	db := newIndexerDB(t, "run")
+
+	now := uint64(time.Now().Unix())
+	require.NoError(t, db.SetState(100, now))
+	require.NoError(t, db.AddLogs(requestLogAt(1, 99, now-1)))
+
+	source := &flakySource{IndexerSource: db}
+	source.failures.Store(1)
+
+	requestChan := make(chan []database.Log, 10)
+	registry := health.NewRegistry()
+
+	c := collector.Collector{
+		FdcContractAddress: fdcContractAddr,
+		Source:             source,
+		Requests:           requestChan,
+		Reverted:           make(chan []database.Log, 10),
+		BitVotes:           make(chan payload.Round, 10),
+		SigningPolicies:    make(chan []shared.VotersData, 10),
+		Health:             registry,
+	}
+	c.Run(ctx)
+
+	collectorCheck := func() health.Check {
+		for _, check := range registry.Ready().Checks {
+			if check.Name == "collector" {
+				return check
+			}
+		}
+
+		return health.Check{}
+	}
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(collectorCheck().Message, "AttestationRequestListener (1 failures, last: fetch initial logs: connection refused)")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the listener is restarted after the backoff
+	logs := receiveLogs(ctx, t, requestChan)
+	require.Len(t, logs, 1)
+}
+
+func TestStopRequestsAfter(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	db := newIndexerDB(t, "stopRequests")
+
+	now := uint64(time.Now().Unix())
+	require.NoError(t, db.SetState(100, now-5))
+	require.NoError(t, db.AddLogs(requestLogAt(1, 99, now-6)))
+
+	requestChan := make(chan []database.Log, 10)
+
+	c := collector.Collector{
+		FdcContractAddress: fdcContractAddr,
+		Source:             db,
+		Requests:           requestChan,
+		Reverted:           make(chan []database.Log, 10),
+		BitVotes:           make(chan payload.Round, 10),
+		SigningPolicies:    make(chan []shared.VotersData, 10),
+	}
+	c.Run(ctx)
+
+	logs := receiveLogs(ctx, t, requestChan)
+	require.Len(t, logs, 1)
+
+	// the indexer lags behind, so the listener keeps collecting requests
+	collected := c.StopRequestsAfter(now)
+	require.Never(t, func() bool {
+		select {
+		case <-collected:
+			return true
+		default:
+			return false
+		}
+	}, listenerInterval+500*time.Millisecond, 100*time.Millisecond)
+
+	// the listeners of the collector query the database concurrently, so writes are retried while it is locked
+	write := func(f func() error) {
+		require.Eventually(t, func() bool { return f() == nil }, 5*time.Second, 10*time.Millisecond)
+	}
+
+	// a request from the end of the collect phase is collected once it is indexed
+	write(func() error { return db.AddLogs(requestLogAt(2, 101, now-1)) })
+	write(func() error { return db.SetState(101, now-1) })
+
+	logs = receiveLogs(ctx, t, requestChan)
+	require.Len(t, logs, 1)
+	require.Equal(t, fmt.Sprintf("%064x", 2), logs[0].TransactionHash)
+
+	// the listener stops once a block after the collect phase is queried
+	write(func() error { return db.SetState(102, now) })
+
+	select {
+	case <-collected:
+	case <-ctx.Done():
+		t.Fatal("requests not stopped")
+	}
+}
@@ -0,0 +1,40 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/flare-foundation/go-flare-common/pkg/database"
+)
+
+// trackedSource is an IndexerSource that records the timestamp of the latest indexed block it fetched.
+type trackedSource struct {
+	IndexerSource
+	latest *atomic.Uint64
+}
+
+func (s trackedSource) FetchState(ctx context.Context) (database.State, error) {
+	state, err := s.IndexerSource.FetchState(ctx)
+	if err == nil {
+		s.latest.Store(state.BlockTimestamp)
+	}
+
+	return state, err
+}
+
+// indexerLag returns an error if the latest fetched indexed block is older than outOfSyncTolerance.
+func indexerLag(latest uint64) error {
+	if latest == 0 {
+		return errors.New("indexer state not fetched yet")
+	}
+
+	lag := time.Since(time.Unix(int64(latest), 0))
+	if lag > outOfSyncTolerance {
+		return fmt.Errorf("latest indexed block is %v old, tolerance %v", lag.Round(time.Second), outOfSyncTolerance)
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/flare-foundation/go-flare-common/pkg/database"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// checkpoint is a block whose hash was observed when its logs were queried.
+type checkpoint struct {
+	number    uint64
+	hash      common.Hash
+	timestamp uint64
+}
+
+// logKey identifies a log in a chain.
+type logKey struct {
+	transactionHash string
+	logIndex        uint64
+	blockNumber     uint64
+}
+
+func keyOf(log *database.Log) logKey {
+	return logKey{transactionHash: log.TransactionHash, logIndex: log.LogIndex, blockNumber: log.BlockNumber}
+}
+
+// requestTracker keeps recently queried request logs and block hashes to detect chain reorganisations and indexer rollbacks.
+type requestTracker struct {
+	checkpoints []checkpoint   // ordered by block number
+	logs        []database.Log // ordered by block number
+}
+
+// track records logs and the hashes of their blocks and of the block tip.
+func (t *requestTracker) track(ctx context.Context, source IndexerSource, logs []database.Log, tip database.State) error {
+	for i := range logs {
+		if len(t.checkpoints) > 0 && t.checkpoints[len(t.checkpoints)-1].number >= logs[i].BlockNumber {
+			t.logs = append(t.logs, logs[i])
+			continue
+		}
+
+		hash, err := source.FetchBlockHash(ctx, logs[i].BlockNumber)
+		if err != nil {
+			return fmt.Errorf("fetching hash of block %d: %s", logs[i].BlockNumber, err)
+		}
+
+		t.checkpoints = append(t.checkpoints, checkpoint{number: logs[i].BlockNumber, hash: hash, timestamp: logs[i].Timestamp})
+		t.logs = append(t.logs, logs[i])
+	}
+
+	if len(t.checkpoints) > 0 && t.checkpoints[len(t.checkpoints)-1].number >= tip.Index {
+		return nil
+	}
+
+	hash, err := source.FetchBlockHash(ctx, tip.Index)
+	if err != nil {
+		return fmt.Errorf("fetching hash of block %d: %s", tip.Index, err)
+	}
+
+	t.checkpoints = append(t.checkpoints, checkpoint{number: tip.Index, hash: hash, timestamp: tip.BlockTimestamp})
+
+	return nil
+}
+
+// forkPoint returns the highest tracked block that is still valid, given that lastQueriedBlock was queried last and
+// the source is now at block tip. The second return value is false if no block after it is invalid.
+//
+// A block is invalid if it is higher than tip (the source was rolled back) or if its hash changed (the chain was reorganised).
+// Blocks with unknown hash are assumed to be valid if a higher block with known hash is valid.
+func (t *requestTracker) forkPoint(ctx context.Context, source IndexerSource, lastQueriedBlock, tip uint64) (uint64, bool, error) {
+	fork := lastQueriedBlock
+	reorged := false
+
+	if tip < lastQueriedBlock {
+		fork = tip
+		reorged = true
+	}
+
+	for i := len(t.checkpoints) - 1; i >= 0; i-- {
+		c := t.checkpoints[i]
+		if c.number > fork || c.hash == (common.Hash{}) {
+			continue
+		}
+
+		hash, err := source.FetchBlockHash(ctx, c.number)
+		if err != nil {
+			return 0, false, fmt.Errorf("fetching hash of block %d: %s", c.number, err)
+		}
+
+		if hash == c.hash {
+			return fork, reorged, nil
+		}
+
+		// the block was replaced, so were all blocks after the previous checkpoint
+		fork = max(c.number, 1) - 1
+		reorged = true
+	}
+
+	return fork, reorged, nil
+}
+
+// revert forgets checkpoints and logs after block fork and returns the forgotten logs.
+func (t *requestTracker) revert(fork uint64) []database.Log {
+	t.checkpoints = slices.DeleteFunc(t.checkpoints, func(c checkpoint) bool { return c.number > fork })
+
+	i, _ := slices.BinarySearchFunc(t.logs, fork+1, func(l database.Log, n uint64) int {
+		switch {
+		case l.BlockNumber < n:
+			return -1
+		case l.BlockNumber > n:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	reverted := slices.Clone(t.logs[i:])
+	t.logs = t.logs[:i]
+
+	return reverted
+}
+
+// prune forgets checkpoints and logs with timestamps before timestamp.
+// The latest checkpoint is always kept.
+func (t *requestTracker) prune(timestamp uint64) {
+	if len(t.checkpoints) > 1 {
+		i := 0
+		for i < len(t.checkpoints)-1 && t.checkpoints[i].timestamp < timestamp {
+			i++
+		}
+		t.checkpoints = t.checkpoints[i:]
+	}
+
+	t.logs = slices.DeleteFunc(t.logs, func(l database.Log) bool { return l.Timestamp < timestamp })
+}
+
+// diffLogs returns logs that are in old but not in new (removed) and logs that are in new but not in old (added).
+func diffLogs(old, new []database.Log) (removed, added []database.Log) {
+	oldKeys := make(map[logKey]bool, len(old))
+	for i := range old {
+		oldKeys[keyOf(&old[i])] = true
+	}
+
+	newKeys := make(map[logKey]bool, len(new))
+	for i := range new {
+		key := keyOf(&new[i])
+		newKeys[key] = true
+
+		if !oldKeys[key] {
+			added = append(added, new[i])
+		}
+	}
+
+	for i := range old {
+		if !newKeys[keyOf(&old[i])] {
+			removed = append(removed, old[i])
+		}
+	}
+
+	return removed, added
+}
@@ -2,95 +2,160 @@ package collector
 
 import (
 	"context"
+	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/flare-foundation/go-flare-common/pkg/database"
 	"github.com/flare-foundation/go-flare-common/pkg/logger"
 
+	"github.com/flare-foundation/fdc-client/client/metrics"
 	"github.com/flare-foundation/fdc-client/client/timing"
 
 	"github.com/ethereum/go-ethereum/common"
-	"gorm.io/gorm"
 )
 
 // AttestationRequestListener initiates a channel that serves attestation request events emitted by fdcHub.
+//
+// On start, requests of all rounds whose choose phase has not ended yet and of additional lookbackRounds earlier rounds are fetched
+// and sent to the channel before the live polling begins.
+//
+// If the source goes back or a hash of a queried block changes, the affected blocks are queried again.
+// Requests that are no longer on the chain are sent to revertedChan and the new ones to logChan.
+//
+// An error is returned if the initial requests cannot be fetched. The listener returns nil when ctx is done.
 func AttestationRequestListener(
 	ctx context.Context,
-	db *gorm.DB,
+	source IndexerSource,
 	fdcHub common.Address,
 	listenerInterval time.Duration,
+	lookbackRounds uint32,
 	logChan chan<- []database.Log,
-) {
-	trigger := time.NewTicker(listenerInterval)
+	revertedChan chan<- []database.Log,
+) error {
+	return attestationRequestListener(ctx, new(requestCursor), source, fdcHub, listenerInterval, lookbackRounds, logChan, revertedChan)
+}
 
-	// initial query
-	_, startTimestamp, err := timing.LastCollectPhaseStart(uint64(time.Now().Unix()))
-	if err != nil {
-		logger.Panicf("time: %v", err)
-	}
+// requestCursor is the progress of AttestationRequestListener that is kept when the listener is restarted.
+type requestCursor struct {
+	started          bool // initial requests were fetched
+	lastQueriedBlock uint64
+	tracker          *requestTracker
+	stopAt           *atomic.Uint64 // if set to a nonzero timestamp, the listener returns once it has queried a block with at least that timestamp, can be nil
+}
 
-	state, err := database.FetchState(ctx, db, nil)
-	if err != nil {
-		logger.Panicf("fetch initial state: %v", err)
+// stopped returns true if the listener should stop after querying blocks up to the latest one in state.
+func (c *requestCursor) stopped(state database.State) bool {
+	if c.stopAt == nil {
+		return false
 	}
 
-	lastQueriedBlock := state.Index
+	stopAt := c.stopAt.Load()
 
-	params := database.LogsParams{
-		Address: fdcHub,
-		Topic0:  AttestationRequestEventSel,
-		From:    int64(startTimestamp),
-		To:      int64(state.Index),
-	}
+	return stopAt != 0 && state.BlockTimestamp >= stopAt
+}
 
-	logs, err := database.FetchLogsByAddressAndTopic0FromTimestampToBlockNumber(
-		ctx, db, params,
-	)
-	if err != nil {
-		logger.Panic("fetch initial logs")
-	}
+// attestationRequestListener is AttestationRequestListener that continues from cursor. The initial requests are fetched only if the cursor is not started.
+func attestationRequestListener(
+	ctx context.Context,
+	cursor *requestCursor,
+	source IndexerSource,
+	fdcHub common.Address,
+	listenerInterval time.Duration,
+	lookbackRounds uint32,
+	logChan chan<- []database.Log,
+	revertedChan chan<- []database.Log,
+) error {
+	trigger := time.NewTicker(listenerInterval)
+	defer trigger.Stop()
 
-	// add requests to the channel
-	if len(logs) > 0 {
-		select {
-		case logChan <- logs:
-		case <-ctx.Done():
-			logger.Infof("AttestationRequestListener exiting: %v", ctx.Err())
-			return
+	if !cursor.started {
+		logs, err := initialRequests(ctx, cursor, source, fdcHub, lookbackRounds)
+		if err != nil {
+			return err
 		}
+
+		// add requests to the channel
+		if len(logs) > 0 {
+			select {
+			case logChan <- logs:
+			case <-ctx.Done():
+				logger.Infof("AttestationRequestListener exiting: %v", ctx.Err())
+				return nil
+			}
+		}
+	} else {
+		logger.Infof("AttestationRequestListener resuming after block %d", cursor.lastQueriedBlock)
 	}
 
+	tracker := cursor.tracker
+
 	// infinite loop, making query once per listenerInterval from last queried block to the latest confirmed block in indexer db
 	for {
 		select {
 		case <-trigger.C:
 		case <-ctx.Done():
 			logger.Infof("AttestationRequestListener exiting: %v", ctx.Err())
-			return
+			return nil
 		}
 
-		state, err = database.FetchState(ctx, db, nil)
+		state, err := source.FetchState(ctx)
 		if err != nil {
 			logger.Errorf("fetch state: %v", err)
 			continue
 		}
 
+		metrics.CollectorLagBlocks.Set(float64(max(int64(state.Index)-int64(cursor.lastQueriedBlock), 0)))
+		metrics.CollectorLagSeconds.Set(float64(time.Now().Unix() - int64(state.BlockTimestamp)))
+
+		fork, reorged, err := tracker.forkPoint(ctx, source, cursor.lastQueriedBlock, state.Index)
+		if err != nil {
+			logger.Errorf("checking for reorg: %v", err)
+			continue
+		}
+
+		if reorged {
+			logger.Warnf("blocks after %d changed (last queried block %d, latest block %d), querying them again requests", fork, cursor.lastQueriedBlock, state.Index)
+		}
+
 		params := database.LogsParams{
 			Address: fdcHub,
 			Topic0:  AttestationRequestEventSel,
-			From:    int64(lastQueriedBlock),
+			From:    int64(fork),
 			To:      int64(state.Index),
 		}
 
-		logs, err := database.FetchLogsByAddressAndTopic0BlockNumber(
-			ctx, db, params,
-		)
+		logs, err := source.FetchLogsByBlockNumber(ctx, params)
 		if err != nil {
 			logger.Errorf("fetch logs: %v", err)
 			continue
 		}
 
-		lastQueriedBlock = state.Index
+		cursor.lastQueriedBlock = state.Index
+
+		queried := logs
+
+		var reverted []database.Log
+		if reorged {
+			reverted, logs = diffLogs(tracker.revert(fork), queried)
+			logger.Warnf("%d requests reverted, %d requests added", len(reverted), len(logs))
+		}
+
+		if err := tracker.track(ctx, source, queried, state); err != nil {
+			logger.Warnf("tracking requests: %v", err)
+		}
+		oldestUnfinished, _ := timing.OldestUnfinishedRound(state.BlockTimestamp)
+		tracker.prune(timing.RoundStartTS(max(oldestUnfinished, 1) - 1))
+
+		// remove reverted requests
+		if len(reverted) > 0 {
+			select {
+			case revertedChan <- reverted:
+			case <-ctx.Done():
+				logger.Infof("AttestationRequestListener exiting: %v", ctx.Err())
+				return nil
+			}
+		}
 
 		// add requests to the channel
 		if len(logs) > 0 {
@@ -98,8 +163,55 @@ func AttestationRequestListener(
 			case logChan <- logs:
 			case <-ctx.Done():
 				logger.Infof("AttestationRequestListener exiting: %v", ctx.Err())
-				return
+				return nil
 			}
 		}
+
+		if cursor.stopped(state) {
+			logger.Infof("AttestationRequestListener stopping: requests up to block %d with timestamp %d collected", state.Index, state.BlockTimestamp)
+			return nil
+		}
+	}
+}
+
+// initialRequests fetches requests of all rounds whose choose phase has not ended yet and of additional lookbackRounds earlier rounds
+// and starts the cursor at the latest indexed block.
+func initialRequests(ctx context.Context, cursor *requestCursor, source IndexerSource, fdcHub common.Address, lookbackRounds uint32) ([]database.Log, error) {
+	startRoundID, _ := timing.OldestUnfinishedRound(uint64(time.Now().Unix()))
+	if startRoundID > lookbackRounds {
+		startRoundID -= lookbackRounds
+	} else {
+		startRoundID = 0
 	}
+	startTimestamp := timing.RoundStartTS(startRoundID)
+
+	state, err := source.FetchState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetch initial state: %s", err)
+	}
+
+	params := database.LogsParams{
+		Address: fdcHub,
+		Topic0:  AttestationRequestEventSel,
+		From:    int64(startTimestamp),
+		To:      int64(state.Index),
+	}
+
+	logs, err := source.FetchLogsFromTimestampToBlock(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("fetch initial logs: %s", err)
+	}
+
+	logger.Infof("backfilled %d attestation requests from round %d", len(logs), startRoundID)
+
+	tracker := new(requestTracker)
+	if err := tracker.track(ctx, source, logs, state); err != nil {
+		logger.Warnf("tracking requests: %v", err)
+	}
+
+	cursor.started = true
+	cursor.lastQueriedBlock = state.Index
+	cursor.tracker = tracker
+
+	return logs, nil
 }
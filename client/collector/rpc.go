@@ -0,0 +1,404 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/flare-foundation/go-flare-common/pkg/database"
+	"github.com/flare-foundation/go-flare-common/pkg/logger"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	defaultMaxBlockRange  = 1000
+	defaultLookbackBlocks = 2_000_000
+)
+
+// ChainClient is the subset of the go-ethereum client used by RPCSource.
+// It is implemented by ethclient.Client and by the client of the simulated backend.
+type ChainClient interface {
+	ethereum.ChainReader
+	ethereum.ChainIDReader
+	ethereum.LogFilterer
+	ethereum.TransactionReader
+}
+
+// RPCSource is an IndexerSource that reads directly from an EVM node over JSON-RPC.
+//
+// Logs are queried in chunks of at most maxBlockRange blocks. Queries that are not bounded by a block range
+// (latest logs and voter registered events) only search the last lookbackBlocks blocks.
+type RPCSource struct {
+	client         ChainClient
+	maxBlockRange  uint64
+	lookbackBlocks uint64
+}
+
+// NewRPCSource returns an IndexerSource reading from client. Zero maxBlockRange and lookbackBlocks are replaced by defaults.
+func NewRPCSource(client ChainClient, maxBlockRange, lookbackBlocks uint64) *RPCSource {
+	if maxBlockRange == 0 {
+		maxBlockRange = defaultMaxBlockRange
+	}
+	if lookbackBlocks == 0 {
+		lookbackBlocks = defaultLookbackBlocks
+	}
+
+	return &RPCSource{client: client, maxBlockRange: maxBlockRange, lookbackBlocks: lookbackBlocks}
+}
+
+func (s *RPCSource) FetchState(ctx context.Context) (database.State, error) {
+	return retry(ctx, func() (database.State, error) { return s.fetchState(ctx) }, "fetching state")
+}
+
+func (s *RPCSource) FetchLatestLogs(ctx context.Context, params database.LatestLogsParams) ([]database.Log, error) {
+	return retry(ctx, func() ([]database.Log, error) { return s.fetchLatestLogs(ctx, params) }, "fetching logs")
+}
+
+func (s *RPCSource) FetchLogsByTimestamp(ctx context.Context, params database.LogsParams) ([]database.Log, error) {
+	return retry(ctx, func() ([]database.Log, error) { return s.fetchLogsByTimestamp(ctx, params) }, "fetching logs")
+}
+
+func (s *RPCSource) FetchLogsFromTimestampToBlock(ctx context.Context, params database.LogsParams) ([]database.Log, error) {
+	return retry(ctx, func() ([]database.Log, error) { return s.fetchLogsFromTimestampToBlock(ctx, params) }, "fetching logs")
+}
+
+func (s *RPCSource) FetchLogsByBlockNumber(ctx context.Context, params database.LogsParams) ([]database.Log, error) {
+	return retry(ctx, func() ([]database.Log, error) { return s.fetchLogsByBlockNumber(ctx, params) }, "fetching logs")
+}
+
+func (s *RPCSource) FetchTransactionsByTimestamp(ctx context.Context, params database.TxParams) ([]database.Transaction, error) {
+	return retry(ctx, func() ([]database.Transaction, error) { return s.fetchTransactionsByTimestamp(ctx, params) }, "fetching transactions")
+}
+
+func (s *RPCSource) FetchVoterRegisteredEvents(ctx context.Context, params VoterRegisteredParams) ([]database.Log, error) {
+	return retry(ctx, func() ([]database.Log, error) { return s.fetchVoterRegisteredEvents(ctx, params) }, "fetching voterRegistered logs")
+}
+
+func (s *RPCSource) fetchState(ctx context.Context) (database.State, error) {
+	header, err := s.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return database.State{}, fmt.Errorf("fetching latest header: %s", err)
+	}
+
+	return database.State{
+		Name:           "last_chain_block",
+		Index:          header.Number.Uint64(),
+		BlockTimestamp: header.Time,
+		Updated:        time.Now(),
+	}, nil
+}
+
+func (s *RPCSource) fetchLatestLogs(ctx context.Context, params database.LatestLogsParams) ([]database.Log, error) {
+	latest, err := s.latestBlock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	floor := s.floor(latest)
+
+	var logs []database.Log
+
+	// search backwards chunk by chunk until enough logs are found
+	for to := latest; len(logs) < params.Number; to -= s.maxBlockRange {
+		from := floor
+		if to-floor >= s.maxBlockRange {
+			from = to - s.maxBlockRange + 1
+		}
+
+		chunk, err := s.filterLogs(ctx, from, to, params.Address, [][]common.Hash{{params.Topic0}})
+		if err != nil {
+			return nil, err
+		}
+
+		slices.Reverse(chunk)
+		logs = append(logs, chunk...)
+
+		if from == floor {
+			break
+		}
+	}
+
+	if len(logs) > params.Number {
+		logs = logs[:params.Number]
+	}
+
+	return logs, nil
+}
+
+func (s *RPCSource) fetchLogsByTimestamp(ctx context.Context, params database.LogsParams) ([]database.Log, error) {
+	from, err := s.firstBlockAfter(ctx, params.From)
+	if err != nil {
+		return nil, err
+	}
+	toNext, err := s.firstBlockAfter(ctx, params.To)
+	if err != nil {
+		return nil, err
+	}
+	if toNext <= from {
+		return nil, nil
+	}
+
+	return s.filterLogs(ctx, from, toNext-1, params.Address, [][]common.Hash{{params.Topic0}})
+}
+
+func (s *RPCSource) fetchLogsFromTimestampToBlock(ctx context.Context, params database.LogsParams) ([]database.Log, error) {
+	from, err := s.firstBlockAfter(ctx, params.From-1)
+	if err != nil {
+		return nil, err
+	}
+	if params.To < 0 || uint64(params.To) < from {
+		return nil, nil
+	}
+
+	return s.filterLogs(ctx, from, uint64(params.To), params.Address, [][]common.Hash{{params.Topic0}})
+}
+
+func (s *RPCSource) fetchLogsByBlockNumber(ctx context.Context, params database.LogsParams) ([]database.Log, error) {
+	if params.To <= params.From {
+		return nil, nil
+	}
+
+	return s.filterLogs(ctx, uint64(params.From+1), uint64(params.To), params.Address, [][]common.Hash{{params.Topic0}})
+}
+
+func (s *RPCSource) fetchTransactionsByTimestamp(ctx context.Context, params database.TxParams) ([]database.Transaction, error) {
+	from, err := s.firstBlockAfter(ctx, params.From)
+	if err != nil {
+		return nil, err
+	}
+	toNext, err := s.firstBlockAfter(ctx, params.To)
+	if err != nil {
+		return nil, err
+	}
+
+	chainID, err := s.client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching chain ID: %s", err)
+	}
+	signer := types.LatestSignerForChainID(chainID)
+
+	var txs []database.Transaction
+
+	for number := from; number < toNext; number++ {
+		block, err := s.client.BlockByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return nil, fmt.Errorf("fetching block %d: %s", number, err)
+		}
+
+		for i, tx := range block.Transactions() {
+			if tx.To() == nil || *tx.To() != params.ToAddress || !bytes.HasPrefix(tx.Data(), params.FunctionSel[:]) {
+				continue
+			}
+
+			dbTx, err := s.transaction(ctx, signer, block, uint64(i), tx)
+			if err != nil {
+				return nil, err
+			}
+
+			txs = append(txs, dbTx)
+		}
+	}
+
+	return txs, nil
+}
+
+func (s *RPCSource) fetchVoterRegisteredEvents(ctx context.Context, params VoterRegisteredParams) ([]database.Log, error) {
+	latest, err := s.latestBlock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	epochID := common.BigToHash(new(big.Int).SetUint64(params.RewardEpochID))
+
+	return s.filterLogs(ctx, s.floor(latest), latest, params.Address, [][]common.Hash{{params.Topic0}, nil, {epochID}})
+}
+
+func (s *RPCSource) FetchBlockHash(ctx context.Context, blockNumber uint64) (common.Hash, error) {
+	return retry(ctx, func() (common.Hash, error) { return s.fetchBlockHash(ctx, blockNumber) }, "fetching block hash")
+}
+
+// retry calls query until success or 15 seconds with exponential backoff, the same as database.RetryWrapper.
+func retry[T any](ctx context.Context, query func() (T, error), errorMsg string) (T, error) {
+	var returnValue T
+
+	err := backoff.RetryNotify(
+		func() error {
+			var err error
+			returnValue, err = query()
+			return err
+		},
+		backoff.WithContext(backoff.NewExponentialBackOff(backoff.WithMaxElapsedTime(15*time.Second)), ctx),
+		func(err error, duration time.Duration) {
+			logger.Errorf("error %s: %v, retrying after %v", errorMsg, err, duration)
+		},
+	)
+
+	return returnValue, err
+}
+
+func (s *RPCSource) fetchBlockHash(ctx context.Context, blockNumber uint64) (common.Hash, error) {
+	header, err := s.client.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("fetching header %d: %s", blockNumber, err)
+	}
+
+	return header.Hash(), nil
+}
+
+// latestBlock returns the number of the latest block.
+func (s *RPCSource) latestBlock(ctx context.Context) (uint64, error) {
+	header, err := s.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("fetching latest header: %s", err)
+	}
+
+	return header.Number.Uint64(), nil
+}
+
+// floor returns the lowest block that is searched by the queries without a block range.
+func (s *RPCSource) floor(latest uint64) uint64 {
+	if latest < s.lookbackBlocks {
+		return 0
+	}
+
+	return latest - s.lookbackBlocks
+}
+
+// firstBlockAfter returns the number of the first block with timestamp strictly greater than timestamp.
+// If there is no such block, the number following the latest block is returned.
+func (s *RPCSource) firstBlockAfter(ctx context.Context, timestamp int64) (uint64, error) {
+	if timestamp < 0 {
+		return 0, nil
+	}
+
+	latest, err := s.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("fetching latest header: %s", err)
+	}
+
+	if latest.Time <= uint64(timestamp) {
+		return latest.Number.Uint64() + 1, nil
+	}
+
+	// binary search on [low, high], the block high always has timestamp greater than timestamp
+	low, high := uint64(0), latest.Number.Uint64()
+	for low < high {
+		mid := low + (high-low)/2
+
+		header, err := s.client.HeaderByNumber(ctx, new(big.Int).SetUint64(mid))
+		if err != nil {
+			return 0, fmt.Errorf("fetching header %d: %s", mid, err)
+		}
+
+		if header.Time > uint64(timestamp) {
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+
+	return low, nil
+}
+
+// filterLogs fetches logs emitted by address matching topics in block range [from, to] in chunks of at most maxBlockRange blocks.
+func (s *RPCSource) filterLogs(ctx context.Context, from, to uint64, address common.Address, topics [][]common.Hash) ([]database.Log, error) {
+	var logs []database.Log
+
+	timestamps := make(map[uint64]uint64)
+
+	for start := from; start <= to; start += s.maxBlockRange {
+		end := min(to, start+s.maxBlockRange-1)
+
+		query := ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Addresses: []common.Address{address},
+			Topics:    topics,
+		}
+
+		chainLogs, err := s.client.FilterLogs(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("filtering logs in blocks %d-%d: %s", start, end, err)
+		}
+
+		for i := range chainLogs {
+			timestamp, ok := timestamps[chainLogs[i].BlockNumber]
+			if !ok {
+				header, err := s.client.HeaderByNumber(ctx, new(big.Int).SetUint64(chainLogs[i].BlockNumber))
+				if err != nil {
+					return nil, fmt.Errorf("fetching header %d: %s", chainLogs[i].BlockNumber, err)
+				}
+				timestamp = header.Time
+				timestamps[chainLogs[i].BlockNumber] = timestamp
+			}
+
+			logs = append(logs, logFromChain(&chainLogs[i], timestamp))
+		}
+
+		if end == to {
+			break
+		}
+	}
+
+	return logs, nil
+}
+
+// transaction builds the indexer representation of the i-th transaction in block.
+func (s *RPCSource) transaction(ctx context.Context, signer types.Signer, block *types.Block, i uint64, tx *types.Transaction) (database.Transaction, error) {
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return database.Transaction{}, fmt.Errorf("recovering sender of tx %s: %s", tx.Hash(), err)
+	}
+
+	receipt, err := s.client.TransactionReceipt(ctx, tx.Hash())
+	if err != nil {
+		return database.Transaction{}, fmt.Errorf("fetching receipt of tx %s: %s", tx.Hash(), err)
+	}
+
+	hash := tx.Hash()
+	blockHash := block.Hash()
+
+	return database.Transaction{
+		Hash:             hex.EncodeToString(hash[:]),
+		FunctionSig:      hex.EncodeToString(tx.Data()[:4]),
+		Input:            hex.EncodeToString(tx.Data()),
+		BlockNumber:      block.NumberU64(),
+		BlockHash:        hex.EncodeToString(blockHash[:]),
+		TransactionIndex: i,
+		FromAddress:      hex.EncodeToString(from[:]),
+		ToAddress:        hex.EncodeToString(tx.To()[:]),
+		Status:           receipt.Status,
+		Value:            tx.Value().String(),
+		GasPrice:         tx.GasPrice().String(),
+		Gas:              tx.Gas(),
+		Timestamp:        block.Time(),
+	}, nil
+}
+
+// logFromChain converts a chain log to the indexer representation. Missing topics are encoded as "NULL".
+func logFromChain(chainLog *types.Log, timestamp uint64) database.Log {
+	topics := [4]string{"NULL", "NULL", "NULL", "NULL"}
+	for i := range min(len(chainLog.Topics), 4) {
+		topics[i] = hex.EncodeToString(chainLog.Topics[i][:])
+	}
+
+	return database.Log{
+		Address:         hex.EncodeToString(chainLog.Address[:]),
+		Data:            hex.EncodeToString(chainLog.Data),
+		Topic0:          topics[0],
+		Topic1:          topics[1],
+		Topic2:          topics[2],
+		Topic3:          topics[3],
+		TransactionHash: hex.EncodeToString(chainLog.TxHash[:]),
+		LogIndex:        uint64(chainLog.Index),
+		Timestamp:       timestamp,
+		BlockNumber:     chainLog.BlockNumber,
+	}
+}
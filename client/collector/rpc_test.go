@@ -0,0 +1,236 @@
+package collector_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/flare-foundation/fdc-client/client/collector"
+
+	"github.com/flare-foundation/go-flare-common/pkg/database"
+	"github.com/flare-foundation/go-flare-common/pkg/payload"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+)
+
+// emitterCode deploys a contract that emits a log with the three topics given in calldata and no data.
+var emitterCode = common.FromHex("600f600c600039600f6000f3" + "60403560203560003560006000a300")
+
+type simulatedChain struct {
+	t       *testing.T
+	backend *simulated.Backend
+	key     *ecdsa.PrivateKey
+	nonce   uint64
+}
+
+func newSimulatedChain(t *testing.T) *simulatedChain {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	alloc := types.GenesisAlloc{crypto.PubkeyToAddress(key.PublicKey): {Balance: big.NewInt(1e18)}}
+	backend := simulated.NewBackend(alloc)
+	t.Cleanup(func() { backend.Close() }) //nolint:errcheck
+
+	return &simulatedChain{t: t, backend: backend, key: key}
+}
+
+// send sends a transaction with data to address (nil for contract creation) and commits a block.
+func (c *simulatedChain) send(to *common.Address, data []byte) *types.Receipt {
+	ctx := context.Background()
+	client := c.backend.Client()
+
+	chainID, err := client.ChainID(ctx)
+	require.NoError(c.t, err)
+
+	head, err := client.HeaderByNumber(ctx, nil)
+	require.NoError(c.t, err)
+
+	tx, err := types.SignNewTx(c.key, types.LatestSignerForChainID(chainID), &types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     c.nonce,
+		GasTipCap: big.NewInt(params.GWei),
+		GasFeeCap: new(big.Int).Add(head.BaseFee, big.NewInt(params.GWei)),
+		Gas:       200_000,
+		To:        to,
+		Data:      data,
+	})
+	require.NoError(c.t, err)
+	c.nonce++
+
+	require.NoError(c.t, client.SendTransaction(ctx, tx))
+	c.backend.Commit()
+
+	var receipt *types.Receipt
+	require.Eventually(c.t, func() bool {
+		receipt, err = client.TransactionReceipt(ctx, tx.Hash())
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	return receipt
+}
+
+// emit emits a log from emitter with the given topics.
+func (c *simulatedChain) emit(emitter common.Address, topic0, topic1, topic2 common.Hash) *types.Receipt {
+	data := append(append(topic0.Bytes(), topic1.Bytes()...), topic2.Bytes()...)
+	return c.send(&emitter, data)
+}
+
+func (c *simulatedChain) header(number uint64) *types.Header {
+	header, err := c.backend.Client().HeaderByNumber(context.Background(), new(big.Int).SetUint64(number))
+	require.NoError(c.t, err)
+
+	return header
+}
+
+func TestRPCSourceLogs(t *testing.T) {
+	ctx := context.Background()
+	chain := newSimulatedChain(t)
+
+	emitter := chain.send(nil, emitterCode).ContractAddress
+
+	topic := common.HexToHash("0x1234")
+	first := chain.emit(emitter, topic, common.Hash{}, common.Hash{})
+	chain.backend.Commit()
+	second := chain.emit(emitter, topic, common.Hash{}, common.Hash{})
+	chain.emit(emitter, common.HexToHash("0x5678"), common.Hash{}, common.Hash{})
+
+	source := collector.NewRPCSource(chain.backend.Client(), 2, 0)
+
+	state, err := source.FetchState(ctx)
+	require.NoError(t, err)
+	require.Equal(t, second.BlockNumber.Uint64()+1, state.Index)
+
+	latest, err := source.FetchLatestLogs(ctx, database.LatestLogsParams{Address: emitter, Topic0: topic, Number: 1})
+	require.NoError(t, err)
+	require.Len(t, latest, 1)
+	require.Equal(t, second.BlockNumber.Uint64(), latest[0].BlockNumber)
+
+	all, err := source.FetchLatestLogs(ctx, database.LatestLogsParams{Address: emitter, Topic0: topic, Number: 5})
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	require.Equal(t, first.BlockNumber.Uint64(), all[1].BlockNumber)
+
+	byBlock, err := source.FetchLogsByBlockNumber(ctx, database.LogsParams{
+		Address: emitter,
+		Topic0:  topic,
+		From:    int64(first.BlockNumber.Uint64()),
+		To:      int64(state.Index),
+	})
+	require.NoError(t, err)
+	require.Len(t, byBlock, 1)
+
+	log := byBlock[0]
+	require.Equal(t, hex.EncodeToString(emitter[:]), log.Address)
+	require.Equal(t, hex.EncodeToString(topic[:]), log.Topic0)
+	require.Equal(t, hex.EncodeToString(second.TxHash[:]), log.TransactionHash)
+	require.Equal(t, chain.header(second.BlockNumber.Uint64()).Time, log.Timestamp)
+
+	firstTimestamp := chain.header(first.BlockNumber.Uint64()).Time
+
+	byTimestamp, err := source.FetchLogsByTimestamp(ctx, database.LogsParams{
+		Address: emitter,
+		Topic0:  topic,
+		From:    int64(firstTimestamp) - 1,
+		To:      int64(firstTimestamp),
+	})
+	require.NoError(t, err)
+	require.Len(t, byTimestamp, 1)
+	require.Equal(t, first.BlockNumber.Uint64(), byTimestamp[0].BlockNumber)
+
+	fromTimestamp, err := source.FetchLogsFromTimestampToBlock(ctx, database.LogsParams{
+		Address: emitter,
+		Topic0:  topic,
+		From:    int64(firstTimestamp),
+		To:      int64(state.Index),
+	})
+	require.NoError(t, err)
+	require.Len(t, fromTimestamp, 2)
+}
+
+func TestRPCSourceVoterRegisteredEvents(t *testing.T) {
+	ctx := context.Background()
+	chain := newSimulatedChain(t)
+
+	emitter := chain.send(nil, emitterCode).ContractAddress
+
+	topic := common.HexToHash("0x1234")
+	voter := common.HexToHash("0xac872479e5EFc21989A4183Dc580C8264C9e54f5")
+	chain.emit(emitter, topic, voter, common.BigToHash(big.NewInt(10)))
+	chain.emit(emitter, topic, voter, common.BigToHash(big.NewInt(11)))
+
+	source := collector.NewRPCSource(chain.backend.Client(), 0, 0)
+
+	logs, err := source.FetchVoterRegisteredEvents(ctx, collector.VoterRegisteredParams{Address: emitter, Topic0: topic, RewardEpochID: 11})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, hex.EncodeToString(voter[:]), logs[0].Topic1)
+	require.Equal(t, "NULL", logs[0].Topic3)
+}
+
+func TestRPCSourceTransactions(t *testing.T) {
+	ctx := context.Background()
+	chain := newSimulatedChain(t)
+
+	pyld, err := hex.DecodeString("0100050b")
+	require.NoError(t, err)
+
+	msg := payload.BuildMessage(protocol, roundID, pyld)
+	input := append(funcSel[:], common.FromHex(msg)...)
+
+	receipt := chain.send(&submitContractAddr, input)
+	chain.send(&submitContractAddr, []byte{9, 9, 9, 9})
+
+	timestamp := chain.header(receipt.BlockNumber.Uint64()).Time
+
+	source := collector.NewRPCSource(chain.backend.Client(), 0, 0)
+
+	txs, err := source.FetchTransactionsByTimestamp(ctx, database.TxParams{
+		ToAddress:   submitContractAddr,
+		FunctionSel: funcSel,
+		From:        int64(timestamp) - 1,
+		To:          int64(timestamp) + 100,
+	})
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+
+	sender := crypto.PubkeyToAddress(chain.key.PublicKey)
+	require.Equal(t, hex.EncodeToString(sender[:]), txs[0].FromAddress)
+	require.Equal(t, types.ReceiptStatusSuccessful, txs[0].Status)
+
+	payloads, err := payload.ExtractPayloads(&txs[0])
+	require.NoError(t, err)
+	require.Equal(t, sender, payloads[protocol].From)
+	require.Equal(t, roundID, payloads[protocol].VotingRound)
+}
+
+func TestAttestationRequestListenerRPC(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	chain := newSimulatedChain(t)
+
+	emitter := chain.send(nil, emitterCode).ContractAddress
+
+	source := collector.NewRPCSource(chain.backend.Client(), 0, 0)
+	requestChan := make(chan []database.Log, 10)
+
+	go collector.AttestationRequestListener(ctx, source, emitter, 100*time.Millisecond, 0, requestChan, make(chan []database.Log, 10))
+
+	chain.emit(emitter, collector.AttestationRequestEventSel, common.Hash{}, common.Hash{})
+
+	select {
+	case logs := <-requestChan:
+		require.Len(t, logs, 1)
+		require.Equal(t, hex.EncodeToString(collector.AttestationRequestEventSel[:]), logs[0].Topic0)
+	case <-ctx.Done():
+		t.Fatal("context cancelled")
+	}
+}
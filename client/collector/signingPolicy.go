@@ -3,95 +3,141 @@ package collector
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/flare-foundation/go-flare-common/pkg/database"
 	"github.com/flare-foundation/go-flare-common/pkg/logger"
 	"github.com/flare-foundation/go-flare-common/pkg/policy"
 
+	"github.com/flare-foundation/fdc-client/client/config"
 	"github.com/flare-foundation/fdc-client/client/shared"
 	"github.com/flare-foundation/fdc-client/client/timing"
 
 	"github.com/ethereum/go-ethereum/common"
-	"gorm.io/gorm"
 )
 
 // SigningPolicyInitializedListener initiates a channel that serves signingPolicyInitialized events emitted by relayContractAddress.
+//
+// An error is returned if the initial signing policies cannot be fetched. The listener returns nil when ctx is done.
 func SigningPolicyInitializedListener(
 	ctx context.Context,
-	db *gorm.DB,
+	source IndexerSource,
 	relayContractAddress common.Address,
-	registryContractAddress common.Address,
+	voterRegistries config.VoterRegistries,
 	votersDataChan chan<- []shared.VotersData,
-) {
-	// initial query
+) error {
+	return signingPolicyInitializedListener(ctx, new(signingPolicyCursor), source, relayContractAddress, voterRegistries, votersDataChan)
+}
+
+// signingPolicyCursor is the progress of SigningPolicyInitializedListener that is kept when the listener is restarted.
+type signingPolicyCursor struct {
+	initialized                  bool // initial signing policies were sent
+	lastInitializedRewardEpochID uint64
+	latestQuery                  time.Time
+}
+
+// signingPolicyInitializedListener is SigningPolicyInitializedListener that continues from cursor.
+// The initial signing policies are fetched only if the cursor is not initialized.
+func signingPolicyInitializedListener(
+	ctx context.Context,
+	cursor *signingPolicyCursor,
+	source IndexerSource,
+	relayContractAddress common.Address,
+	voterRegistries config.VoterRegistries,
+	votersDataChan chan<- []shared.VotersData,
+) error {
+	if !cursor.initialized {
+		sorted, err := initialSigningPolicies(ctx, cursor, source, relayContractAddress, voterRegistries)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case votersDataChan <- sorted:
+		case <-ctx.Done():
+			logger.Infof("SigningPolicyInitializedListener exiting: %v", ctx.Err())
+			return nil
+		}
+
+		cursor.initialized = true
+	} else {
+		logger.Infof("SigningPolicyInitializedListener resuming after reward epoch %d", cursor.lastInitializedRewardEpochID)
+	}
+
+	spiTargetedListener(ctx, cursor, source, relayContractAddress, voterRegistries, votersDataChan)
+
+	return nil
+}
+
+// initialSigningPolicies fetches the latest signing policies with submit addresses in increasing order and sets the cursor to the latest one.
+func initialSigningPolicies(
+	ctx context.Context,
+	cursor *signingPolicyCursor,
+	source IndexerSource,
+	relayContractAddress common.Address,
+	voterRegistries config.VoterRegistries,
+) ([]shared.VotersData, error) {
 	params := database.LatestLogsParams{
 		Address: relayContractAddress,
 		Topic0:  signingPolicyInitializedEventSel,
 		Number:  3,
 	}
 
-	logs, err := database.FetchLatestLogsByAddressAndTopic0(
-		ctx, db, params,
-	)
+	logs, err := source.FetchLatestLogs(ctx, params)
 	if err != nil {
-		logger.Panicf("fetching initial logs: %v", err)
+		return nil, fmt.Errorf("fetching initial logs: %s", err)
 	}
 
 	latestQuery := time.Now()
 	logger.Debugf("Logs length: %d", len(logs))
 	if len(logs) == 0 {
-		logger.Panic("No initial signing policies found:")
+		return nil, errors.New("no initial signing policies found")
+	}
+
+	lastSigningPolicy, err := policy.ParseSigningPolicyInitializedEvent(logs[0])
+	if err != nil {
+		return nil, fmt.Errorf("parsing initial logs: %s", err)
 	}
 
 	// signingPolicyStorage expects policies in increasing order
 	sorted := make([]shared.VotersData, 0, len(logs))
 
 	for i := range logs {
-		votersData, err := AddSubmitAddressesToSigningPolicy(ctx, db, registryContractAddress, logs[len(logs)-i-1])
+		votersData, err := AddSubmitAddressesToSigningPolicy(ctx, source, voterRegistries, logs[len(logs)-i-1])
 		if err != nil {
-			logger.Panicf("fetching initial signing policies with submit addresses: %v", err)
+			return nil, fmt.Errorf("fetching initial signing policies with submit addresses: %s", err)
 		}
 
 		sorted = append(sorted, votersData)
 		logger.Infof("fetched initial policy for round %v", votersData.Policy.RewardEpochId)
 	}
 
-	select {
-	case votersDataChan <- sorted:
-	case <-ctx.Done():
-		logger.Infof("SigningPolicyInitializedListener exiting: %v", ctx.Err())
-	}
+	cursor.lastInitializedRewardEpochID = lastSigningPolicy.RewardEpochId.Uint64()
+	cursor.latestQuery = latestQuery
 
-	spiTargetedListener(ctx, db, relayContractAddress, registryContractAddress, logs[0], latestQuery, votersDataChan)
+	return sorted, nil
 }
 
 // spiTargetedListener that only starts aggressive queries for new signingPolicyInitialized events a bit before the expected emission and stops once it gets one and waits until the next window.
+// The cursor is advanced with each served signing policy.
 //
 // spi = signingPolicyInitialized.
 func spiTargetedListener(
 	ctx context.Context,
-	db *gorm.DB,
+	cursor *signingPolicyCursor,
+	source IndexerSource,
 	relayContractAddress common.Address,
-	registryContractAddress common.Address,
-	lastLog database.Log,
-	latestQuery time.Time,
+	voterRegistries config.VoterRegistries,
 	votersDataChan chan<- []shared.VotersData,
 ) {
-	lastSigningPolicy, err := policy.ParseSigningPolicyInitializedEvent(lastLog)
-	if err != nil {
-		logger.Panicf("parsing initial logs: %v", err)
-	}
-
-	lastInitializedRewardEpochID := lastSigningPolicy.RewardEpochId.Uint64()
-
 	startOffset := int64(10) // Start collecting signing policy event 10 voting epochs before the expected start of the next reward epoch
 	if (timing.Chain.RewardEpochLength/20)+1 < 10 {
 		startOffset = int64(timing.Chain.RewardEpochLength/20) + 1 // Start 1/20 of voting epochs if 1/20 of all voting epochs in reward epoch is less than 10
 	}
 
 	for {
-		expectedSPIStart := timing.ExpectedRewardEpochStartTS(lastInitializedRewardEpochID + 1)
+		expectedSPIStart := timing.ExpectedRewardEpochStartTS(cursor.lastInitializedRewardEpochID + 1)
 		untilStart := time.Until(time.Unix(int64(expectedSPIStart)-int64(timing.Chain.CollectDurationSec)*startOffset, 0)) // head start for querying of signing policy
 		timer := time.NewTimer(untilStart)
 
@@ -100,11 +146,12 @@ func spiTargetedListener(
 		case <-timer.C:
 			logger.Debug("querying for next signing policy")
 		case <-ctx.Done():
+			timer.Stop()
 			logger.Infof("spiTargetedListener exiting: %v", ctx.Err())
 			return
 		}
 
-		logsWithSubmitAddresses, err := queryNextSPI(ctx, db, relayContractAddress, registryContractAddress, latestQuery, lastInitializedRewardEpochID)
+		logsWithSubmitAddresses, err := queryNextSPI(ctx, source, relayContractAddress, voterRegistries, cursor.latestQuery, cursor.lastInitializedRewardEpochID)
 		if err != nil {
 			if errors.Is(err, ctx.Err()) {
 				logger.Infof("spiTargetedListener exiting: %v", err)
@@ -114,18 +161,24 @@ func spiTargetedListener(
 			logger.Errorf("querying next SPI event: %v", err)
 			continue
 		}
-		votersDataChan <- logsWithSubmitAddresses
 
-		latestQuery = time.Now()
-		lastInitializedRewardEpochID++
+		select {
+		case votersDataChan <- logsWithSubmitAddresses:
+		case <-ctx.Done():
+			logger.Infof("spiTargetedListener exiting: %v", ctx.Err())
+			return
+		}
+
+		cursor.latestQuery = time.Now()
+		cursor.lastInitializedRewardEpochID++
 	}
 }
 
 func queryNextSPI(
 	ctx context.Context,
-	db *gorm.DB,
+	source IndexerSource,
 	relayContractAddress common.Address,
-	registryContractAddress common.Address,
+	voterRegistries config.VoterRegistries,
 	latestQuery time.Time,
 	latestRewardEpoch uint64,
 ) (
@@ -144,9 +197,7 @@ func queryNextSPI(
 			To:      now.Unix(),
 		}
 
-		logs, err := database.FetchLogsByAddressAndTopic0Timestamp(
-			ctx, db, params,
-		)
+		logs, err := source.FetchLogsByTimestamp(ctx, params)
 		if err != nil {
 			return nil, err
 		}
@@ -158,7 +209,7 @@ func queryNextSPI(
 				logger.Warnf("More than one signing policy initialized event found in the same reward epoch query window (reward epoch %d)", latestRewardEpoch)
 			}
 			for i := range logs {
-				votersData, err := AddSubmitAddressesToSigningPolicy(ctx, db, registryContractAddress, logs[i])
+				votersData, err := AddSubmitAddressesToSigningPolicy(ctx, source, voterRegistries, logs[i])
 				if err != nil {
 					return nil, err
 				}
@@ -181,3 +232,48 @@ func queryNextSPI(
 		}
 	}
 }
+
+// SigningPolicyForRound fetches the signing policy that is active in the voting round roundID together with the submit addresses of its voters.
+// Signing policies initialized in the two reward epochs before the round are considered.
+func SigningPolicyForRound(
+	ctx context.Context,
+	source IndexerSource,
+	relayContractAddress common.Address,
+	voterRegistries config.VoterRegistries,
+	roundID uint32,
+) (shared.VotersData, error) {
+	roundStart := timing.RoundStartTS(roundID)
+	window := 2 * timing.Chain.RewardEpochLength * timing.Chain.CollectDurationSec
+
+	params := database.LogsParams{
+		Address: relayContractAddress,
+		Topic0:  signingPolicyInitializedEventSel,
+		From:    int64(roundStart) - int64(min(window, roundStart)) - 1,
+		To:      int64(roundStart),
+	}
+
+	logs, err := source.FetchLogsByTimestamp(ctx, params)
+	if err != nil {
+		return shared.VotersData{}, fmt.Errorf("fetching signing policies: %s", err)
+	}
+
+	active := -1
+	var activeStart uint32
+	for i := range logs {
+		signingPolicy, err := policy.ParseSigningPolicyInitializedEvent(logs[i])
+		if err != nil {
+			return shared.VotersData{}, fmt.Errorf("parsing signing policy: %s", err)
+		}
+
+		if signingPolicy.StartVotingRoundId <= roundID && (active < 0 || signingPolicy.StartVotingRoundId >= activeStart) {
+			active = i
+			activeStart = signingPolicy.StartVotingRoundId
+		}
+	}
+
+	if active < 0 {
+		return shared.VotersData{}, fmt.Errorf("no signing policy for round %d", roundID)
+	}
+
+	return AddSubmitAddressesToSigningPolicy(ctx, source, voterRegistries, logs[active])
+}
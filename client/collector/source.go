@@ -0,0 +1,113 @@
+package collector
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+
+	"github.com/flare-foundation/go-flare-common/pkg/database"
+	"github.com/flare-foundation/go-flare-common/pkg/logger"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+)
+
+// IndexerSource provides the chain data (state, logs and transactions) that the collector needs.
+type IndexerSource interface {
+	// FetchState returns the latest indexed state.
+	FetchState(ctx context.Context) (database.State, error)
+	// FetchLatestLogs returns the latest params.Number logs matching Address and Topic0, ordered from the latest.
+	FetchLatestLogs(ctx context.Context, params database.LatestLogsParams) ([]database.Log, error)
+	// FetchLogsByTimestamp returns logs matching Address and Topic0 from timestamp range (From, To], ordered by timestamp.
+	FetchLogsByTimestamp(ctx context.Context, params database.LogsParams) ([]database.Log, error)
+	// FetchLogsFromTimestampToBlock returns logs matching Address and Topic0 with timestamp at least From and block number at most To, ordered by timestamp.
+	FetchLogsFromTimestampToBlock(ctx context.Context, params database.LogsParams) ([]database.Log, error)
+	// FetchLogsByBlockNumber returns logs matching Address and Topic0 from block range (From, To], ordered by timestamp.
+	FetchLogsByBlockNumber(ctx context.Context, params database.LogsParams) ([]database.Log, error)
+	// FetchTransactionsByTimestamp returns transactions to ToAddress calling FunctionSel from timestamp range (From, To], ordered by timestamp.
+	FetchTransactionsByTimestamp(ctx context.Context, params database.TxParams) ([]database.Transaction, error)
+	// FetchVoterRegisteredEvents returns logs matching Address and Topic0 for reward epoch RewardEpochID.
+	FetchVoterRegisteredEvents(ctx context.Context, params VoterRegisteredParams) ([]database.Log, error)
+	// FetchBlockHash returns the hash of the block with blockNumber or zero hash if the hash is not known to the source.
+	FetchBlockHash(ctx context.Context, blockNumber uint64) (common.Hash, error)
+}
+
+// DBSource is an IndexerSource that reads from the C-chain indexer database.
+type DBSource struct {
+	DB *gorm.DB
+}
+
+// NewDBSource returns an IndexerSource reading from db.
+func NewDBSource(db *gorm.DB) *DBSource {
+	return &DBSource{DB: db}
+}
+
+func (s *DBSource) FetchState(ctx context.Context) (database.State, error) {
+	return database.FetchState(ctx, s.DB, nil)
+}
+
+func (s *DBSource) FetchLatestLogs(ctx context.Context, params database.LatestLogsParams) ([]database.Log, error) {
+	return database.FetchLatestLogsByAddressAndTopic0(ctx, s.DB, params)
+}
+
+func (s *DBSource) FetchLogsByTimestamp(ctx context.Context, params database.LogsParams) ([]database.Log, error) {
+	return database.FetchLogsByAddressAndTopic0Timestamp(ctx, s.DB, params)
+}
+
+func (s *DBSource) FetchLogsFromTimestampToBlock(ctx context.Context, params database.LogsParams) ([]database.Log, error) {
+	return database.FetchLogsByAddressAndTopic0FromTimestampToBlockNumber(ctx, s.DB, params)
+}
+
+func (s *DBSource) FetchLogsByBlockNumber(ctx context.Context, params database.LogsParams) ([]database.Log, error) {
+	return database.FetchLogsByAddressAndTopic0BlockNumber(ctx, s.DB, params)
+}
+
+func (s *DBSource) FetchTransactionsByTimestamp(ctx context.Context, params database.TxParams) ([]database.Transaction, error) {
+	return database.FetchTransactionsByAddressAndSelectorTimestamp(ctx, s.DB, params)
+}
+
+func (s *DBSource) FetchVoterRegisteredEvents(ctx context.Context, params VoterRegisteredParams) ([]database.Log, error) {
+	return database.RetryWrapper(fetchVoterRegisteredEvents, "fetching voterRegistered logs")(ctx, s.DB, params)
+}
+
+func (s *DBSource) FetchBlockHash(ctx context.Context, blockNumber uint64) (common.Hash, error) {
+	return database.RetryWrapper(fetchBlockHash, "fetching block hash")(ctx, s.DB, blockNumber)
+}
+
+// fetchBlockHash returns the hash of the block through the transaction of any log in the block.
+// The indexer only stores blocks with logs of the indexed contracts, for other blocks zero hash is returned.
+func fetchBlockHash(ctx context.Context, db *gorm.DB, blockNumber uint64) (common.Hash, error) {
+	var blockHashes []string
+
+	err := db.WithContext(ctx).Model(&database.Log{}).
+		Joins("JOIN transactions ON transactions.id = logs.transaction_id").
+		Where("logs.block_number = ?", blockNumber).
+		Limit(1).
+		Pluck("transactions.block_hash", &blockHashes).Error
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	if len(blockHashes) == 0 {
+		return common.Hash{}, nil
+	}
+
+	return common.HexToHash(blockHashes[0]), nil
+}
+
+func fetchVoterRegisteredEvents(ctx context.Context, db *gorm.DB, params VoterRegisteredParams) ([]database.Log, error) {
+	var logs []database.Log
+
+	epochID := common.BigToHash(new(big.Int).SetUint64(params.RewardEpochID))
+
+	logger.Debugf("voterRegistry query params: address %s, eventSelector %s, epochID %s", hex.EncodeToString(params.Address[:]), hex.EncodeToString(params.Topic0[:]), hex.EncodeToString(epochID[:]))
+
+	err := db.WithContext(ctx).Where(
+		"address = ? AND topic0 = ? AND topic2 = ?",
+		hex.EncodeToString(params.Address[:]), // encodes without 0x prefix and without checksum
+		hex.EncodeToString(params.Topic0[:]),
+		hex.EncodeToString(epochID[:]),
+	).Find(&logs).Error
+
+	return logs, err
+}
@@ -0,0 +1,145 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flare-foundation/go-flare-common/pkg/logger"
+
+	"github.com/flare-foundation/fdc-client/client/metrics"
+)
+
+const (
+	minRestartBackoff = 1 * time.Second
+	maxRestartBackoff = 1 * time.Minute
+	recoveredAfter    = 30 * time.Second // a restarted listener that runs this long without failing is no longer degraded
+)
+
+// listenerState is the state of a supervised listener.
+type listenerState struct {
+	running  bool
+	failures int // consecutive failures
+	lastErr  error
+}
+
+// supervisor runs the listeners of the collector and restarts the ones that fail with exponential backoff.
+// Listeners keep their progress in cursors that outlive restarts, so a restarted listener continues where it failed.
+type supervisor struct {
+	mu        sync.Mutex
+	listeners map[string]*listenerState
+}
+
+func newSupervisor() *supervisor {
+	return &supervisor{listeners: make(map[string]*listenerState)}
+}
+
+// run runs listener in a new goroutine under name.
+// If the listener returns an error, it is restarted after a backoff that doubles with each consecutive failure.
+// If it returns nil or ctx is done, it is not restarted.
+func (s *supervisor) run(ctx context.Context, name string, listener func(context.Context) error) {
+	s.mu.Lock()
+	s.listeners[name] = &listenerState{running: true}
+	s.mu.Unlock()
+
+	go s.supervise(ctx, name, listener)
+}
+
+func (s *supervisor) supervise(ctx context.Context, name string, listener func(context.Context) error) {
+	defer s.update(name, func(l *listenerState) { l.running = false })
+
+	for {
+		recovered := time.AfterFunc(recoveredAfter, func() {
+			s.update(name, func(l *listenerState) {
+				if l.failures > 0 {
+					logger.Infof("%s recovered after %d failures", name, l.failures)
+				}
+				l.failures = 0
+				l.lastErr = nil
+			})
+		})
+
+		err := listener(ctx)
+		recovered.Stop()
+
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+
+		var failures int
+		s.update(name, func(l *listenerState) {
+			l.failures++
+			l.lastErr = err
+			failures = l.failures
+		})
+
+		backoff := restartBackoff(failures)
+		logger.Errorf("%s failed (%d in a row): %v, restarting in %v", name, failures, err, backoff)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+
+		metrics.CollectorRestarts.WithLabelValues(name).Inc()
+	}
+}
+
+// restartBackoff returns the delay before the restart after the given number of consecutive failures.
+func restartBackoff(failures int) time.Duration {
+	backoff := minRestartBackoff
+	for i := 1; i < failures && backoff < maxRestartBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, maxRestartBackoff)
+}
+
+func (s *supervisor) update(name string, f func(*listenerState)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f(s.listeners[name])
+}
+
+// check returns an error listing the listeners that stopped and the degraded ones, i.e., those that failed and have not recovered yet.
+func (s *supervisor) check() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.listeners))
+	for name := range s.listeners {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var stopped, degraded []string
+	for _, name := range names {
+		l := s.listeners[name]
+		switch {
+		case !l.running:
+			stopped = append(stopped, name)
+		case l.failures > 0:
+			degraded = append(degraded, fmt.Sprintf("%s (%d failures, last: %v)", name, l.failures, l.lastErr))
+		}
+	}
+
+	var problems []string
+	if len(stopped) > 0 {
+		problems = append(problems, "stopped: "+strings.Join(stopped, ", "))
+	}
+	if len(degraded) > 0 {
+		problems = append(problems, "degraded: "+strings.Join(degraded, ", "))
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return errors.New(strings.Join(problems, "; "))
+}
@@ -2,140 +2,145 @@ package collector
 
 import (
 	"context"
-	"encoding/hex"
-	"math/big"
+	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/flare-foundation/go-flare-common/pkg/database"
 	"github.com/flare-foundation/go-flare-common/pkg/logger"
 	"github.com/flare-foundation/go-flare-common/pkg/policy"
 
 	"github.com/flare-foundation/fdc-client/client/collector/registry"
+	"github.com/flare-foundation/fdc-client/client/config"
 	"github.com/flare-foundation/fdc-client/client/shared"
 
-	"fmt"
-
 	"github.com/ethereum/go-ethereum/common"
-	"gorm.io/gorm"
 )
 
+// ABI versions of the VoterRegistry contract.
 const (
-	breakingEpochCoston = 5450 // 5451 uses new address
-
-	breakingEpochCoston2 = 5338
+	RegistryABIv1 = "v1" // VoterRegistered with uint24 reward epoch, used by the first registries on songbird, flare and coston2
+	RegistryABIv2 = "v2" // VoterRegistered with uint32 reward epoch, public key and signature
 )
-const (
-	//  new ABI
-	newRegistryCoston  = "0x42F4526BFC6f892DB515a832a52eFc9edFADf6c0"
-	newRegistryCoston2 = "0x6a0AF07b7972177B176d3D422555cbc98DfDe914"
 
-	oldRegistryCoston = "0xB4B93a3A3ADa93a574E6efeb5f295bf882934cB6" // old message
+// RegistryParser reads VoterRegistered events of a version of the VoterRegistry ABI.
+type RegistryParser struct {
+	EventSel common.Hash
+	Parse    func(database.Log) (submitAddress, signingPolicyAddress common.Address, err error)
+}
 
-	// old ABI
-	oldRegistrySongbird = "0x31B9EC65C731c7D973a33Ef3FC83B653f540dC8D"
-	oldRegistryCoston2  = "0xc6E40401395DCc648bC4bBb38fE4552423cD9BAC"
-	oldRegistryFlare    = "0x2580101692366e2f331e891180d9ffdF861Fce83"
-)
+var registryParsers = make(map[string]RegistryParser)
 
-type VoterRegisteredParams struct {
-	Address       common.Address
-	RewardEpochID uint64
+// RegisterRegistryParser registers parser under abiVersion, the name used in the voter_registries of the system config.
+func RegisterRegistryParser(abiVersion string, parser RegistryParser) {
+	registryParsers[abiVersion] = parser
 }
 
-// FetchVoterRegisteredEventsForRewardEpoch fetches all VoterRegisteredEvents emitted by Address for RewardEpochID.
-func FetchVoterRegisteredEventsForRewardEpoch(ctx context.Context, db *gorm.DB, params VoterRegisteredParams) ([]database.Log, error) {
-	return database.RetryWrapper(fetchVoterRegisteredEventsForRewardEpoch, "fetching voterRegistered logs")(ctx, db, params)
+func registryParser(abiVersion string) (RegistryParser, error) {
+	parser, ok := registryParsers[abiVersion]
+	if !ok {
+		return RegistryParser{}, fmt.Errorf("unknown voter registry abi version %q, available: %s", abiVersion, strings.Join(RegistryABIVersions(), ", "))
+	}
+
+	return parser, nil
 }
 
-func fetchVoterRegisteredEventsForRewardEpoch(ctx context.Context, db *gorm.DB, params VoterRegisteredParams) ([]database.Log, error) {
-	var logs []database.Log
+// RegistryABIVersions returns the sorted names of the registered registry parsers.
+func RegistryABIVersions() []string {
+	versions := make([]string, 0, len(registryParsers))
+	for version := range registryParsers {
+		versions = append(versions, version)
+	}
+	slices.Sort(versions)
 
-	epochIDBig := new(big.Int).SetUint64(params.RewardEpochID)
+	return versions
+}
 
-	eventSelector := voterRegisteredEventSel
-	switch params.Address {
-	case
-		common.HexToAddress(oldRegistrySongbird),
-		common.HexToAddress(oldRegistryCoston2),
-		common.HexToAddress(oldRegistryFlare):
-		eventSelector = common.HexToHash("0x824bc2cc10bfe21ead60b8c8a90716eb325b9335aa73eaede799abf38fce062c")
+// ValidateVoterRegistries checks that there is a parser for the ABI version of each registry.
+func ValidateVoterRegistries(registries config.VoterRegistries) error {
+	for i := range registries {
+		if _, err := registryParser(registries[i].ABIVersion); err != nil {
+			return fmt.Errorf("voter registry %v: %s", registries[i].Address, err)
+		}
 	}
 
-	epochID := common.BigToHash(epochIDBig)
-
-	logger.Debugf("voterRegistry query params: address %s, eventSelector %s, epochID %s", hex.EncodeToString(params.Address[:]), hex.EncodeToString(eventSelector[:]), hex.EncodeToString(epochID[:]))
+	return nil
+}
 
-	err := db.WithContext(ctx).Where(
-		"address = ? AND topic0 = ? AND topic2 = ?",
-		hex.EncodeToString(params.Address[:]), // encodes without 0x prefix and without checksum
-		hex.EncodeToString(eventSelector[:]),
-		hex.EncodeToString(epochID[:]),
-	).Find(&logs).Error
+func parseRegistryV1(dbLog database.Log) (common.Address, common.Address, error) {
+	event, err := policy.ParseVoterRegisteredEvent(dbLog)
+	if err != nil {
+		return common.Address{}, common.Address{}, err
+	}
 
-	return logs, err
+	return event.SubmitAddress, event.SigningPolicyAddress, nil
 }
 
-// BuildSubmitToSigningPolicyAddressNew builds a map from VoterRegisteredEvents mapping submit addresses to signingPolicy addresses.
-func BuildSubmitToSigningPolicyAddressNew(registryEvents []database.Log) (map[common.Address]common.Address, error) {
-	submitToSigning := make(map[common.Address]common.Address)
+func parseRegistryV2(dbLog database.Log) (common.Address, common.Address, error) {
+	event, err := registry.ParseVoterRegisteredEvent(dbLog)
+	if err != nil {
+		return common.Address{}, common.Address{}, err
+	}
 
-	for i := range registryEvents {
-		event, err := registry.ParseVoterRegisteredEvent(registryEvents[i])
-		if err != nil {
-			return nil, err
-		}
+	return event.SubmitAddress, event.SigningPolicyAddress, nil
+}
 
-		submitToSigning[event.SubmitAddress] = event.SigningPolicyAddress
+type VoterRegisteredParams struct {
+	Address       common.Address
+	Topic0        common.Hash
+	RewardEpochID uint64
+}
+
+// FetchVoterRegisteredEventsForRewardEpoch fetches all VoterRegisteredEvents emitted by voterRegistry for rewardEpochID.
+func FetchVoterRegisteredEventsForRewardEpoch(ctx context.Context, source IndexerSource, voterRegistry config.VoterRegistry, rewardEpochID uint64) ([]database.Log, error) {
+	parser, err := registryParser(voterRegistry.ABIVersion)
+	if err != nil {
+		return nil, err
 	}
 
-	return submitToSigning, nil
+	return source.FetchVoterRegisteredEvents(ctx, VoterRegisteredParams{voterRegistry.Address, parser.EventSel, rewardEpochID})
 }
 
-func BuildSubmitToSigningPolicyAddressOld(registryEvents []database.Log) (map[common.Address]common.Address, error) {
+// BuildSubmitToSigningPolicyAddress builds a map from VoterRegisteredEvents of ABI version abiVersion mapping submit addresses to signingPolicy addresses.
+func BuildSubmitToSigningPolicyAddress(abiVersion string, registryEvents []database.Log) (map[common.Address]common.Address, error) {
+	parser, err := registryParser(abiVersion)
+	if err != nil {
+		return nil, err
+	}
+
 	submitToSigning := make(map[common.Address]common.Address)
 
 	for i := range registryEvents {
-		event, err := policy.ParseVoterRegisteredEvent(registryEvents[i])
+		submitAddress, signingPolicyAddress, err := parser.Parse(registryEvents[i])
 		if err != nil {
 			return nil, err
 		}
 
-		submitToSigning[event.SubmitAddress] = event.SigningPolicyAddress
+		submitToSigning[submitAddress] = signingPolicyAddress
 	}
 
 	return submitToSigning, nil
 }
 
 // SubmitToSigningPolicyAddress builds a map for rewardEpochID mapping submit addresses to signingPolicy addresses.
-func SubmitToSigningPolicyAddress(ctx context.Context, db *gorm.DB, registryContractAddress common.Address, rewardEpochID uint64) (map[common.Address]common.Address, error) {
-	logger.Debugf("fetching voter registered events for %d from %v", rewardEpochID, registryContractAddress)
-	logs, err := FetchVoterRegisteredEventsForRewardEpoch(ctx, db, VoterRegisteredParams{registryContractAddress, rewardEpochID})
+func SubmitToSigningPolicyAddress(ctx context.Context, source IndexerSource, voterRegistry config.VoterRegistry, rewardEpochID uint64) (map[common.Address]common.Address, error) {
+	logger.Debugf("fetching voter registered events for %d from %v (abi %s)", rewardEpochID, voterRegistry.Address, voterRegistry.ABIVersion)
+	logs, err := FetchVoterRegisteredEventsForRewardEpoch(ctx, source, voterRegistry, rewardEpochID)
 	if err != nil {
 		return nil, fmt.Errorf("fetching registered events: %s", err)
 	}
 
-	var submitToSigning map[common.Address]common.Address
-
-	switch registryContractAddress {
-	case
-		common.HexToAddress(oldRegistrySongbird),
-		common.HexToAddress(oldRegistryCoston2),
-		common.HexToAddress(oldRegistryFlare):
-		submitToSigning, err = BuildSubmitToSigningPolicyAddressOld(logs)
-		if err != nil {
-			return nil, fmt.Errorf("old building submitToSigning map: %s", err)
-		}
-	default:
-		submitToSigning, err = BuildSubmitToSigningPolicyAddressNew(logs)
-		if err != nil {
-			return nil, fmt.Errorf("new building submitToSigning map: %s", err)
-		}
+	submitToSigning, err := BuildSubmitToSigningPolicyAddress(voterRegistry.ABIVersion, logs)
+	if err != nil {
+		return nil, fmt.Errorf("building submitToSigning map: %s", err)
 	}
 
 	return submitToSigning, nil
 }
 
 // AddSubmitAddressesToSigningPolicy parses SigningPolicyInitialized event, assembles map from submit addresses to signingPolicy addresses, and returns them as VotersData.
-func AddSubmitAddressesToSigningPolicy(ctx context.Context, db *gorm.DB, registryContractAddress common.Address, signingPolicyLog database.Log) (shared.VotersData, error) {
+// The submit addresses are read from the voter registry used for the reward epoch of the signing policy.
+func AddSubmitAddressesToSigningPolicy(ctx context.Context, source IndexerSource, voterRegistries config.VoterRegistries, signingPolicyLog database.Log) (shared.VotersData, error) {
 	data, err := policy.ParseSigningPolicyInitializedEvent(signingPolicyLog)
 	if err != nil {
 		return shared.VotersData{}, err
@@ -148,13 +153,12 @@ func AddSubmitAddressesToSigningPolicy(ctx context.Context, db *gorm.DB, registr
 
 	rewardEpochID := data.RewardEpochId.Uint64()
 
-	if rewardEpochID <= breakingEpochCoston2 && registryContractAddress == common.HexToAddress(newRegistryCoston2) {
-		registryContractAddress = common.HexToAddress(oldRegistryCoston2)
-	} else if rewardEpochID <= breakingEpochCoston && registryContractAddress == common.HexToAddress(newRegistryCoston) {
-		registryContractAddress = common.HexToAddress(oldRegistryCoston)
+	voterRegistry, ok := voterRegistries.ForRewardEpoch(rewardEpochID)
+	if !ok {
+		return shared.VotersData{}, fmt.Errorf("no voter registry for reward epoch %d", rewardEpochID)
 	}
 
-	submitToSigning, err := SubmitToSigningPolicyAddress(ctx, db, registryContractAddress, rewardEpochID)
+	submitToSigning, err := SubmitToSigningPolicyAddress(ctx, source, voterRegistry, rewardEpochID)
 	if err != nil {
 		return shared.VotersData{}, fmt.Errorf("adding submit addresses: %s", err)
 	}
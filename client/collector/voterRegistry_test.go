@@ -39,7 +39,13 @@ var policyLogs = []database.Log{
 }
 
 func TestBuildSubmitToSignature(t *testing.T) {
-	subToSign, err := collector.BuildSubmitToSigningPolicyAddressNew(policyLogs)
+	subToSign, err := collector.BuildSubmitToSigningPolicyAddress(collector.RegistryABIv2, policyLogs)
 	require.NoError(t, err)
 	require.Equal(t, 2, len(subToSign))
+
+	_, err = collector.BuildSubmitToSigningPolicyAddress(collector.RegistryABIv1, policyLogs)
+	require.Error(t, err)
+
+	_, err = collector.BuildSubmitToSigningPolicyAddress("v0", policyLogs)
+	require.ErrorContains(t, err, "available: v1, v2")
 }
@@ -2,6 +2,8 @@ package config
 
 import (
 	"math/big"
+	"net/http"
+	"time"
 
 	"github.com/flare-foundation/go-flare-common/pkg/database"
 	"github.com/flare-foundation/go-flare-common/pkg/logger"
@@ -18,6 +20,10 @@ type userCommon struct {
 	RestServer RestServer      `toml:"rest_server"`
 	Queues     Queues          `toml:"queues"`
 	Logging    logger.Config   `toml:"logger"`
+	RoundStore RoundStore      `toml:"round_store"`
+	Collector  Collector       `toml:"collector"`
+	Shutdown   Shutdown        `toml:"shutdown"`
+	Consensus  Consensus       `toml:"consensus"`
 }
 
 type UserRaw struct {
@@ -31,8 +37,29 @@ type User struct {
 }
 
 type System struct {
-	Addresses Addresses `toml:"addresses"`
-	Timing    Timing    `toml:"timing"`
+	Addresses       Addresses         `toml:"addresses"`
+	VoterRegistries VoterRegistries   `toml:"voter_registries"`
+	Timing          Timing            `toml:"timing"`
+	Consensus       ConsensusProtocol `toml:"consensus"`
+}
+
+// DefaultConsensusMaxOperations is the maximal number of operations of each strategy of the consensus bitVote computation if it is not set for the chain.
+const DefaultConsensusMaxOperations = 20_000_000
+
+// ConsensusProtocol configures the consensus bitVote computation of a chain. All data providers of the chain must use the same values,
+// so they are set in the system configuration and changes apply from the same voting round.
+type ConsensusProtocol struct {
+	MaxOperations       int    `toml:"max_operations"`         // maximal number of operations of each strategy, 0 for default
+	ExactVotesFromRound uint32 `toml:"exact_votes_from_round"` // first voting round computed with the exact search over votes, 0 if it is not activated
+}
+
+// Operations returns the maximal number of operations of each strategy or the default if it is not set.
+func (c ConsensusProtocol) Operations() int {
+	if c.MaxOperations <= 0 {
+		return DefaultConsensusMaxOperations
+	}
+
+	return c.MaxOperations
 }
 
 type RestServer struct {
@@ -47,29 +74,126 @@ type RestServer struct {
 	DATitle    string `toml:"da_sub_router_title"`
 	DAPSubpath string `toml:"da_sub_router_path"`
 
+	StatusTitle   string `toml:"status_sub_router_title"`
+	StatusSubpath string `toml:"status_sub_router_path"`
+
 	Version     string `toml:"version"`
 	SwaggerPath string `toml:"swagger_path"`
 }
 
+type Collector struct {
+	StartupLookbackRounds uint32 `toml:"startup_lookback_rounds"` // number of rounds before the oldest unfinished round whose requests are fetched on startup
+
+	Source         string `toml:"source"`          // "db" (C-chain indexer, default) or "rpc" (EVM JSON-RPC node)
+	RPCURL         string `toml:"rpc_url"`         // url of the JSON-RPC node, used if source is "rpc"
+	MaxBlockRange  uint64 `toml:"max_block_range"` // maximal number of blocks in a single eth_getLogs query, used if source is "rpc"
+	LookbackBlocks uint64 `toml:"lookback_blocks"` // number of latest blocks searched for signing policies and voter registrations, used if source is "rpc"
+}
+
+type Shutdown struct {
+	Timeout time.Duration `toml:"timeout"` // maximal duration of waiting for the Merkle root of the current round on shutdown, 0 for the time until shortly after the end of its choose phase
+}
+
+// Consensus configures the consensus bitVote computation of the node. The operations budget is set in the system configuration.
+type Consensus struct {
+	Timeout time.Duration `toml:"timeout"` // maximal duration of the consensus bitVote computation, 0 for the time until the signing deadline of the round
+}
+
+type RoundStore struct {
+	Type         string `toml:"type"`          // "" (disabled) or "sqlite"
+	Path         string `toml:"path"`          // path to the database file
+	ReloadRounds uint32 `toml:"reload_rounds"` // number of latest rounds restored on startup
+}
+
 type Addresses struct {
-	SubmitContract        common.Address `toml:"submit_contract"`
-	RelayContract         common.Address `toml:"relay_contract"`
-	FdcContract           common.Address `toml:"fdc_contract"`
-	VoterRegistryContract common.Address `toml:"voter_registry_contract"`
+	SubmitContract common.Address `toml:"submit_contract"`
+	RelayContract  common.Address `toml:"relay_contract"`
+	FdcContract    common.Address `toml:"fdc_contract"`
+}
+
+// VoterRegistry is a VoterRegistry contract from which voter registrations are read starting with reward epoch FromRewardEpoch.
+type VoterRegistry struct {
+	Address         common.Address `toml:"registry_address"`
+	ABIVersion      string         `toml:"abi_version"` // name of the parser of the VoterRegistered events emitted by the contract
+	FromRewardEpoch uint64         `toml:"from_reward_epoch"`
+}
+
+// VoterRegistries lists VoterRegistry contracts of a chain in increasing order of FromRewardEpoch.
+type VoterRegistries []VoterRegistry
+
+// ForRewardEpoch returns the registry used for rewardEpochID, i.e., the last registry whose FromRewardEpoch is not greater than rewardEpochID.
+// If there is no such registry, false is returned.
+func (r VoterRegistries) ForRewardEpoch(rewardEpochID uint64) (VoterRegistry, bool) {
+	for i := len(r) - 1; i >= 0; i-- {
+		if r[i].FromRewardEpoch <= rewardEpochID {
+			return r[i], true
+		}
+	}
+
+	return VoterRegistry{}, false
+}
+
+// Verification modes of a source with several verifiers.
+const (
+	ModeFailover = "failover" // verifiers are queried in order until one responds
+	ModeRace     = "race"     // verifiers are queried concurrently and the first valid response is used
+	ModeQuorum   = "quorum"   // verifiers are queried concurrently and Quorum identical valid responses are required
+)
+
+type Verifier struct {
+	URL    string `toml:"url"`
+	APIKey string `toml:"api_key"`
+}
+
+// Defaults of the verifier access settings of a source.
+const (
+	DefaultVerifierTimeout         = 5 * time.Second // maximal duration for the verifier to resolve the query
+	DefaultVerifierMaxResponseSize = 10 * (1 << 20)  // 10 MB for maximal response size of the verifier
+	DefaultAPIKeyHeader            = "X-API-KEY"
+	DefaultBreakerThreshold        = 5
+	DefaultProbeInterval           = 10 * time.Second
+)
+
+// Breaker holds the circuit breaker settings of the verifiers of a source.
+type Breaker struct {
+	Threshold     int           // number of consecutive failures that open the breaker
+	ProbeInterval time.Duration // duration between probes of a verifier with an open breaker
+	HealthPath    string        // path, resolved against the verifier url, that is probed with GET
+	ProbeRequest  []byte        // request that is sent to the verifier as a probe if HealthPath is not set
 }
 
 type Source struct {
-	URL       string
-	APIKey    string
-	LUTLimit  uint64
-	QueueName string // name of the queue that manages access to the Source
+	Verifiers       []Verifier
+	Mode            string // one of ModeFailover, ModeRace, ModeQuorum
+	Quorum          int    // number of identical responses required in ModeQuorum
+	LUTLimit        uint64
+	QueueName       string       // name of the queue that manages access to the Source
+	Client          *http.Client // client used to query the verifiers, with the timeout and TLS settings of the Source
+	MaxResponseSize int64        // maximal size of the verifier response in bytes
+	APIKeyHeader    string       // name of the header with the API key
+	APIKeyPrefix    string       // prefix of the API key in the header, e.g. "Bearer "
+	Breaker         Breaker
 }
 
 type sourceBig struct {
-	URL       string   `toml:"url"`
-	APIKey    string   `toml:"api_key"`
-	LUTLimit  *big.Int `toml:"lut_limit"`
-	QueueName string   `toml:"queue"`
+	URL              string        `toml:"url"`
+	APIKey           string        `toml:"api_key"`
+	Verifiers        []Verifier    `toml:"verifiers"` // additional verifiers, used after the one given by url
+	Mode             string        `toml:"mode"`
+	Quorum           int           `toml:"quorum"`
+	LUTLimit         *big.Int      `toml:"lut_limit"`
+	QueueName        string        `toml:"queue"`
+	Timeout          time.Duration `toml:"timeout"`
+	MaxResponseSize  int64         `toml:"max_response_size"`
+	APIKeyHeader     string        `toml:"api_key_header"`
+	APIKeyPrefix     string        `toml:"api_key_prefix"`
+	CAFile           string        `toml:"ca_file"`          // PEM file with CA certificates trusted in addition to the system ones
+	ClientCertFile   string        `toml:"client_cert_file"` // PEM file with the client certificate for mTLS
+	ClientKeyFile    string        `toml:"client_key_file"`  // PEM file with the client key for mTLS
+	BreakerThreshold int           `toml:"breaker_threshold"`
+	ProbeInterval    time.Duration `toml:"probe_interval"`
+	HealthPath       string        `toml:"health_path"`
+	ProbeRequest     string        `toml:"probe_request"` // hex encoded
 }
 
 type AttestationType struct {
@@ -1,8 +1,14 @@
 package config
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 )
@@ -40,25 +46,158 @@ func ArgumentsFromABI(abiBytes []byte) (abi.Arguments, error) {
 	return abi.Arguments{arg}, nil
 }
 
-// parseSource takes sourceBig and converts LUTLimit from big.int to uint64.
+// parseSource takes sourceBig, converts LUTLimit from big.int to uint64 and collects the verifiers.
 func parseSource(sourceConfigBig sourceBig) (Source, error) {
-	if !sourceConfigBig.LUTLimit.IsUint64() {
-		return Source{
-				URL:       sourceConfigBig.URL,
-				APIKey:    sourceConfigBig.APIKey,
-				LUTLimit:  0,
-				QueueName: sourceConfigBig.QueueName,
-			},
-			errors.New("lutLimit does not fit in uint64")
-	}
-
-	return Source{
-			URL:       sourceConfigBig.URL,
-			APIKey:    sourceConfigBig.APIKey,
-			LUTLimit:  sourceConfigBig.LUTLimit.Uint64(),
-			QueueName: sourceConfigBig.QueueName,
-		},
-		nil
+	verifiers := make([]Verifier, 0, len(sourceConfigBig.Verifiers)+1)
+	if sourceConfigBig.URL != "" || len(sourceConfigBig.Verifiers) == 0 {
+		verifiers = append(verifiers, Verifier{URL: sourceConfigBig.URL, APIKey: sourceConfigBig.APIKey})
+	}
+	verifiers = append(verifiers, sourceConfigBig.Verifiers...)
+
+	source := Source{
+		Verifiers: verifiers,
+		Mode:      sourceConfigBig.Mode,
+		Quorum:    sourceConfigBig.Quorum,
+		QueueName: sourceConfigBig.QueueName,
+	}
+
+	switch source.Mode {
+	case "":
+		source.Mode = ModeFailover
+	case ModeFailover, ModeRace, ModeQuorum:
+	default:
+		return source, fmt.Errorf("unknown mode %s", source.Mode)
+	}
+
+	if source.Mode == ModeQuorum {
+		if source.Quorum == 0 {
+			source.Quorum = len(verifiers)/2 + 1
+		}
+
+		// with a majority quorum, at most one response can reach it
+		if source.Quorum <= len(verifiers)/2 || source.Quorum > len(verifiers) {
+			return source, fmt.Errorf("quorum %d not in range [%d, %d]", source.Quorum, len(verifiers)/2+1, len(verifiers))
+		}
+	}
+
+	if sourceConfigBig.LUTLimit == nil || !sourceConfigBig.LUTLimit.IsUint64() {
+		return source, errors.New("lutLimit does not fit in uint64")
+	}
+
+	source.LUTLimit = sourceConfigBig.LUTLimit.Uint64()
+
+	source.MaxResponseSize = sourceConfigBig.MaxResponseSize
+	if source.MaxResponseSize == 0 {
+		source.MaxResponseSize = DefaultVerifierMaxResponseSize
+	}
+	if source.MaxResponseSize < 0 {
+		return source, fmt.Errorf("negative max_response_size %d", source.MaxResponseSize)
+	}
+
+	source.APIKeyHeader = sourceConfigBig.APIKeyHeader
+	if source.APIKeyHeader == "" {
+		source.APIKeyHeader = DefaultAPIKeyHeader
+	}
+	source.APIKeyPrefix = sourceConfigBig.APIKeyPrefix
+
+	breaker, err := parseBreaker(sourceConfigBig)
+	if err != nil {
+		return source, fmt.Errorf("breaker: %s", err)
+	}
+	source.Breaker = breaker
+
+	client, err := verifierClient(sourceConfigBig)
+	if err != nil {
+		return source, fmt.Errorf("building verifier client: %s", err)
+	}
+	source.Client = client
+
+	return source, nil
+}
+
+// parseBreaker reads circuit breaker settings of the source and sets the defaults.
+func parseBreaker(sourceConfigBig sourceBig) (Breaker, error) {
+	breaker := Breaker{
+		Threshold:     sourceConfigBig.BreakerThreshold,
+		ProbeInterval: sourceConfigBig.ProbeInterval,
+		HealthPath:    sourceConfigBig.HealthPath,
+	}
+
+	if breaker.Threshold == 0 {
+		breaker.Threshold = DefaultBreakerThreshold
+	}
+	if breaker.Threshold < 0 {
+		return breaker, fmt.Errorf("negative breaker_threshold %d", breaker.Threshold)
+	}
+
+	if breaker.ProbeInterval == 0 {
+		breaker.ProbeInterval = DefaultProbeInterval
+	}
+	if breaker.ProbeInterval < 0 {
+		return breaker, fmt.Errorf("negative probe_interval %s", breaker.ProbeInterval)
+	}
+
+	if sourceConfigBig.ProbeRequest != "" {
+		probeRequest, err := hex.DecodeString(strings.TrimPrefix(sourceConfigBig.ProbeRequest, "0x"))
+		if err != nil {
+			return breaker, fmt.Errorf("decoding probe_request: %s", err)
+		}
+		breaker.ProbeRequest = probeRequest
+	}
+
+	return breaker, nil
+}
+
+// verifierClient builds an http client with the timeout and TLS settings of the source.
+func verifierClient(sourceConfigBig sourceBig) (*http.Client, error) {
+	timeout := sourceConfigBig.Timeout
+	if timeout == 0 {
+		timeout = DefaultVerifierTimeout
+	}
+	if timeout < 0 {
+		return nil, fmt.Errorf("negative timeout %s", timeout)
+	}
+
+	client := &http.Client{Timeout: timeout}
+
+	if sourceConfigBig.CAFile == "" && sourceConfigBig.ClientCertFile == "" && sourceConfigBig.ClientKeyFile == "" {
+		return client, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if sourceConfigBig.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		caPEM, err := os.ReadFile(sourceConfigBig.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading ca_file: %s", err)
+		}
+
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates in ca_file %s", sourceConfigBig.CAFile)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if sourceConfigBig.ClientCertFile != "" || sourceConfigBig.ClientKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(sourceConfigBig.ClientCertFile, sourceConfigBig.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %s", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	client.Transport = transport
+
+	return client, nil
 }
 
 // ParseAttestationType parses attestation type configurations.
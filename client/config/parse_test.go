@@ -1,10 +1,22 @@
 package config_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/flare-foundation/fdc-client/client/config"
 
@@ -37,7 +49,9 @@ func TestReadUserRaw(t *testing.T) {
 
 	sourceConfig, ok := typeConfigs.SourcesConfig[source]
 	require.True(t, ok)
-	require.Equal(t, "12345", sourceConfig.APIKey)
+	require.Len(t, sourceConfig.Verifiers, 1)
+	require.Equal(t, "12345", sourceConfig.Verifiers[0].APIKey)
+	require.Equal(t, config.ModeFailover, sourceConfig.Mode)
 }
 
 func TestRead(t *testing.T) {
@@ -62,6 +76,278 @@ func TestReadSystem(t *testing.T) {
 	require.Equal(t, uint64(240), sysCfg.Timing.RewardEpochLength)
 }
 
+func TestReadSystemEmbedded(t *testing.T) {
+	for _, chain := range []string{"coston", "coston2", "songbird", "flare"} {
+		sysCfg, err := config.ReadSystem("", chain, 200)
+		require.NoError(t, err, chain)
+		require.NotZero(t, sysCfg.Timing.T0, chain)
+	}
+
+	_, err := config.ReadSystem("", "coston3", 200)
+	require.ErrorContains(t, err, `unknown chain "coston3"`)
+	require.ErrorContains(t, err, "coston, coston2, flare")
+
+	require.Contains(t, config.SystemChains("", 200), "songbird")
+}
+
+func TestReadSystemOverride(t *testing.T) {
+	directory := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(directory, "200"), 0o700))
+
+	override := "[addresses]\nfdc_contract = \"0x0000000000000000000000000000000000000001\"\n[timing]\nreward_epoch_length = 5\n"
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "200", "coston.toml"), []byte(override), 0o600))
+
+	embedded, err := config.ReadSystem("", "coston", 200)
+	require.NoError(t, err)
+
+	sysCfg, err := config.ReadSystem(directory, "coston", 200)
+	require.NoError(t, err)
+	require.Equal(t, common.HexToAddress("0x01"), sysCfg.Addresses.FdcContract)
+	require.Equal(t, embedded.Addresses.RelayContract, sysCfg.Addresses.RelayContract)
+	require.Equal(t, uint64(5), sysCfg.Timing.RewardEpochLength)
+	require.Equal(t, embedded.Timing.T0, sysCfg.Timing.T0)
+
+	// chain without an embedded config is read from the directory only
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "200", "devnet.toml"), []byte(override), 0o600))
+	_, err = config.ReadSystem(directory, "devnet", 200)
+	require.ErrorContains(t, err, "missing addresses.submit_contract, addresses.relay_contract, voter_registries, timing.t0")
+	require.Contains(t, config.SystemChains(directory, 200), "devnet")
+}
+
+func TestReadSystemVoterRegistries(t *testing.T) {
+	sysCfg, err := config.ReadSystem("", "coston2", 200)
+	require.NoError(t, err)
+
+	tests := []struct {
+		rewardEpochID uint64
+		address       common.Address
+		abiVersion    string
+	}{
+		{0, common.HexToAddress("0xc6E40401395DCc648bC4bBb38fE4552423cD9BAC"), "v1"},
+		{5338, common.HexToAddress("0xc6E40401395DCc648bC4bBb38fE4552423cD9BAC"), "v1"},
+		{5339, common.HexToAddress("0x6a0AF07b7972177B176d3D422555cbc98DfDe914"), "v2"},
+		{9000, common.HexToAddress("0x6a0AF07b7972177B176d3D422555cbc98DfDe914"), "v2"},
+	}
+
+	for _, test := range tests {
+		registry, ok := sysCfg.VoterRegistries.ForRewardEpoch(test.rewardEpochID)
+		require.True(t, ok, test.rewardEpochID)
+		require.Equal(t, test.address, registry.Address, test.rewardEpochID)
+		require.Equal(t, test.abiVersion, registry.ABIVersion, test.rewardEpochID)
+	}
+
+	_, ok := config.VoterRegistries{{FromRewardEpoch: 10}}.ForRewardEpoch(9)
+	require.False(t, ok)
+
+	directory := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(directory, "200"), 0o700))
+	write := func(content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(directory, "200", "coston2.toml"), []byte(content), 0o600))
+	}
+
+	// the list in the file replaces the embedded one
+	write("[[voter_registries]]\nregistry_address = \"0x0000000000000000000000000000000000000002\"\nabi_version = \"v3\"\nfrom_reward_epoch = 7\n")
+	sysCfg, err = config.ReadSystem(directory, "coston2", 200)
+	require.NoError(t, err)
+	require.Equal(t, config.VoterRegistries{{Address: common.HexToAddress("0x02"), ABIVersion: "v3", FromRewardEpoch: 7}}, sysCfg.VoterRegistries)
+
+	write("[[voter_registries]]\nregistry_address = \"0x0000000000000000000000000000000000000002\"\nabi_version = \"v2\"\nfrom_reward_epoch = 7\n" +
+		"[[voter_registries]]\nregistry_address = \"0x0000000000000000000000000000000000000003\"\nabi_version = \"v2\"\nfrom_reward_epoch = 7\n")
+	_, err = config.ReadSystem(directory, "coston2", 200)
+	require.ErrorContains(t, err, "voter_registries[1]: from_reward_epoch 7 not greater than 7")
+
+	write("[addresses]\nvoter_registry_contract = \"0x0000000000000000000000000000000000000002\"\n")
+	_, err = config.ReadSystem(directory, "coston2", 200)
+	require.ErrorContains(t, err, "use [[voter_registries]]")
+}
+
+func TestReadSystemConsensus(t *testing.T) {
+	sysCfg, err := config.ReadSystem("", "coston2", 200)
+	require.NoError(t, err)
+	require.Zero(t, sysCfg.Consensus.ExactVotesFromRound)
+	require.Equal(t, config.DefaultConsensusMaxOperations, sysCfg.Consensus.Operations())
+
+	directory := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(directory, "200"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "200", "coston2.toml"), []byte("[consensus]\nmax_operations = 5000000\nexact_votes_from_round = 1000000\n"), 0o600))
+
+	sysCfg, err = config.ReadSystem(directory, "coston2", 200)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1_000_000), sysCfg.Consensus.ExactVotesFromRound)
+	require.Equal(t, 5_000_000, sysCfg.Consensus.Operations())
+	require.NotEmpty(t, sysCfg.VoterRegistries)
+}
+
+// parseETHSource parses configuration of source ETH of type EVMTransaction with the given toml fields.
+func parseETHSource(t *testing.T, fields string) (config.Source, error) {
+	t.Helper()
+
+	abiPath, err := filepath.Abs("../../tests/configs/abis/EVMTransaction.json")
+	require.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), "config.toml")
+	content := fmt.Sprintf("[types.EVMTransaction]\nabi_path = %q\n[types.EVMTransaction.Sources.ETH]\nlut_limit = \"10\"\n%s\n", abiPath, fields)
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+
+	cfg, err := config.ReadUserRaw(file)
+	require.NoError(t, err)
+
+	parsed, err := config.ParseAttestationTypes(cfg.AttestationTypeConfig)
+	if err != nil {
+		return config.Source{}, err
+	}
+
+	attType, err := config.StringToByte32("EVMTransaction")
+	require.NoError(t, err)
+
+	source, err := config.StringToByte32("ETH")
+	require.NoError(t, err)
+
+	return parsed[attType].SourcesConfig[source], nil
+}
+
+func TestParseSourceVerifiers(t *testing.T) {
+	tests := []struct {
+		sources   string
+		verifiers []config.Verifier
+		mode      string
+		quorum    int
+		err       bool
+	}{
+		{
+			sources:   `url = "http://a"` + "\n" + `api_key = "key"`,
+			verifiers: []config.Verifier{{URL: "http://a", APIKey: "key"}},
+			mode:      config.ModeFailover,
+		},
+		{
+			sources:   `url = "http://a"` + "\n" + `mode = "race"` + "\n" + `verifiers = [{url = "http://b", api_key = "key"}]`,
+			verifiers: []config.Verifier{{URL: "http://a"}, {URL: "http://b", APIKey: "key"}},
+			mode:      config.ModeRace,
+		},
+		{
+			sources:   `mode = "quorum"` + "\n" + `verifiers = [{url = "http://a"}, {url = "http://b"}, {url = "http://c"}]`,
+			verifiers: []config.Verifier{{URL: "http://a"}, {URL: "http://b"}, {URL: "http://c"}},
+			mode:      config.ModeQuorum,
+			quorum:    2,
+		},
+		{
+			sources: `mode = "quorum"` + "\n" + `quorum = 3` + "\n" + `verifiers = [{url = "http://a"}, {url = "http://b"}]`,
+			err:     true,
+		},
+		{
+			sources: `mode = "quorum"` + "\n" + `quorum = 2` + "\n" + `verifiers = [{url = "http://a"}, {url = "http://b"}, {url = "http://c"}, {url = "http://d"}]`,
+			err:     true,
+		},
+		{
+			sources: `url = "http://a"` + "\n" + `mode = "fastest"`,
+			err:     true,
+		},
+	}
+
+	for i, test := range tests {
+		sourceConfig, err := parseETHSource(t, test.sources)
+		if test.err {
+			require.Error(t, err, fmt.Sprintf("test %d", i))
+			continue
+		}
+		require.NoError(t, err, fmt.Sprintf("test %d", i))
+
+		require.Equal(t, test.verifiers, sourceConfig.Verifiers, fmt.Sprintf("test %d", i))
+		require.Equal(t, test.mode, sourceConfig.Mode, fmt.Sprintf("test %d", i))
+		require.Equal(t, test.quorum, sourceConfig.Quorum, fmt.Sprintf("test %d", i))
+	}
+}
+
+func TestParseSourceAccess(t *testing.T) {
+	sourceConfig, err := parseETHSource(t, `url = "http://a"`)
+	require.NoError(t, err)
+	require.Equal(t, config.DefaultVerifierTimeout, sourceConfig.Client.Timeout)
+	require.Equal(t, int64(config.DefaultVerifierMaxResponseSize), sourceConfig.MaxResponseSize)
+	require.Equal(t, config.DefaultAPIKeyHeader, sourceConfig.APIKeyHeader)
+	require.Empty(t, sourceConfig.APIKeyPrefix)
+	require.Equal(t, config.Breaker{Threshold: config.DefaultBreakerThreshold, ProbeInterval: config.DefaultProbeInterval}, sourceConfig.Breaker)
+
+	sourceConfig, err = parseETHSource(t, `url = "http://a"
+timeout = "30s"
+max_response_size = 1024
+api_key_header = "Authorization"
+api_key_prefix = "Bearer "
+breaker_threshold = 3
+probe_interval = "1m"
+health_path = "/health"
+probe_request = "0x0102"`)
+	require.NoError(t, err)
+	require.Equal(t, config.Breaker{Threshold: 3, ProbeInterval: time.Minute, HealthPath: "/health", ProbeRequest: []byte{1, 2}}, sourceConfig.Breaker)
+	require.Equal(t, 30*time.Second, sourceConfig.Client.Timeout)
+	require.Equal(t, int64(1024), sourceConfig.MaxResponseSize)
+	require.Equal(t, "Authorization", sourceConfig.APIKeyHeader)
+	require.Equal(t, "Bearer ", sourceConfig.APIKeyPrefix)
+
+	_, err = parseETHSource(t, `url = "http://a"`+"\n"+`ca_file = "missing.pem"`)
+	require.Error(t, err)
+}
+
+func TestParseSourceMTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+
+	caFile := filepath.Join(dir, "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, caPEM, 0o600))
+
+	certFile, keyFile := writeClientCert(t, dir)
+
+	sourceConfig, err := parseETHSource(t, fmt.Sprintf("url = %q\nca_file = %q", server.URL, caFile))
+	require.NoError(t, err)
+
+	_, err = sourceConfig.Client.Get(server.URL)
+	require.Error(t, err) // no client certificate
+
+	sourceConfig, err = parseETHSource(t, fmt.Sprintf("url = %q\nca_file = %q\nclient_cert_file = %q\nclient_key_file = %q", server.URL, caFile, certFile, keyFile))
+	require.NoError(t, err)
+
+	resp, err := sourceConfig.Client.Get(server.URL)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+// writeClientCert writes a self-signed client certificate and its key to dir.
+func writeClientCert(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fdc-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, "client.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0o600))
+
+	keyFile := filepath.Join(dir, "client.key")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	return certFile, keyFile
+}
+
 func TestStringToByte32(t *testing.T) {
 	const a = "12!Ab( )"
 
@@ -1,21 +1,28 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path"
+	"slices"
 	"strconv"
 	"strings"
 	"unicode"
 
 	"github.com/BurntSushi/toml"
 	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/kelseyhightower/envconfig"
+
+	"github.com/flare-foundation/fdc-client/configs"
 )
 
 // Read reads user and system configurations from userFilePath and systemDirectoryPath.
 //
 // System configurations are read for Chain and protocolID set in the user configurations.
+// See ReadSystem for how the embedded system configurations are overridden by the ones in systemDirectoryPath.
 //
 // DB settings are overridden by environment variables if they exist.
 // The following environment variables are used:
@@ -47,12 +54,149 @@ func ReadUserRaw(filePath string) (UserRaw, error) {
 	return readToml[UserRaw](filePath)
 }
 
+// ReadSystem returns the system configuration for chain and protocolID.
+//
+// The configuration embedded in the binary is used as the base. If directory is not empty and contains
+// <protocolID>/<chain>.toml, the values set in the file override the embedded ones field by field.
+// The voter_registries list is an exception, a list in the file replaces the embedded one.
+// An error is returned if there is no configuration for the chain or if any contract address, voter registry, t0 or reward epoch length is missing.
 func ReadSystem(directory, chain string, protocolID uint8) (System, error) {
-	chain += ".toml"
+	var system System
+
+	filePath := path.Join(strconv.FormatUint(uint64(protocolID), 10), chain+".toml")
+	found := false
+
+	embedded, err := fs.ReadFile(configs.System, path.Join(configs.SystemDirectory, filePath))
+	if err == nil {
+		if err := decodeSystem(embedded, &system); err != nil {
+			return system, fmt.Errorf("failed unmarshaling embedded system config %s with: %s", filePath, err)
+		}
+		found = true
+	}
+
+	if directory != "" {
+		overridePath := path.Join(directory, filePath)
+
+		override, err := os.ReadFile(overridePath)
+		switch {
+		case err == nil:
+			if err := decodeSystem(override, &system); err != nil {
+				return system, fmt.Errorf("failed unmarshaling file %s with: %s", overridePath, err)
+			}
+			found = true
+		case !errors.Is(err, fs.ErrNotExist):
+			return system, fmt.Errorf("failed reading file %s with: %s", overridePath, err)
+		}
+	}
+
+	if !found {
+		return system, fmt.Errorf("unknown chain %q for protocol %d, available: %s", chain, protocolID, strings.Join(SystemChains(directory, protocolID), ", "))
+	}
+
+	if err := system.validate(); err != nil {
+		return system, fmt.Errorf("system config for chain %s and protocol %d: %s", chain, protocolID, err)
+	}
+
+	return system, nil
+}
+
+// decodeSystem decodes data onto system. The voter registries of system are replaced if data defines voter_registries.
+func decodeSystem(data []byte, system *System) error {
+	registries := system.VoterRegistries
+	system.VoterRegistries = nil
+
+	metadata, err := toml.Decode(string(data), system)
+	if err != nil {
+		return err
+	}
+
+	if metadata.IsDefined("addresses", "voter_registry_contract") {
+		return errors.New("addresses.voter_registry_contract is no longer supported, use [[voter_registries]]")
+	}
+
+	if !metadata.IsDefined("voter_registries") {
+		system.VoterRegistries = registries
+	}
+
+	return nil
+}
+
+// SystemChains returns the sorted names of the chains with a system configuration for protocolID, either embedded or in directory.
+func SystemChains(directory string, protocolID uint8) []string {
 	protocolStr := strconv.FormatUint(uint64(protocolID), 10)
-	filePath := path.Join(directory, protocolStr, chain)
 
-	return readToml[System](filePath)
+	var chains []string
+	add := func(entries []fs.DirEntry) {
+		for _, entry := range entries {
+			if chain, ok := strings.CutSuffix(entry.Name(), ".toml"); ok && !entry.IsDir() && !slices.Contains(chains, chain) {
+				chains = append(chains, chain)
+			}
+		}
+	}
+
+	embedded, _ := fs.ReadDir(configs.System, path.Join(configs.SystemDirectory, protocolStr))
+	add(embedded)
+
+	if directory != "" {
+		onDisk, _ := os.ReadDir(path.Join(directory, protocolStr))
+		add(onDisk)
+	}
+
+	slices.Sort(chains)
+
+	return chains
+}
+
+// validate checks that the addresses of all contracts, the voter registries and the timing of reward epochs are set.
+func (s *System) validate() error {
+	addresses := []struct {
+		name    string
+		address common.Address
+	}{
+		{"submit_contract", s.Addresses.SubmitContract},
+		{"relay_contract", s.Addresses.RelayContract},
+		{"fdc_contract", s.Addresses.FdcContract},
+	}
+
+	var missing []string
+	for _, a := range addresses {
+		if a.address == (common.Address{}) {
+			missing = append(missing, "addresses."+a.name)
+		}
+	}
+
+	if len(s.VoterRegistries) == 0 {
+		missing = append(missing, "voter_registries")
+	}
+
+	if s.Timing.T0 == 0 {
+		missing = append(missing, "timing.t0")
+	}
+	if s.Timing.RewardEpochLength == 0 {
+		missing = append(missing, "timing.reward_epoch_length")
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("missing %s", strings.Join(missing, ", "))
+	}
+
+	return s.VoterRegistries.validate()
+}
+
+// validate checks that the registries have addresses and ABI versions and are ordered by strictly increasing FromRewardEpoch.
+func (r VoterRegistries) validate() error {
+	for i := range r {
+		switch {
+		case r[i].Address == (common.Address{}):
+			return fmt.Errorf("voter_registries[%d]: missing registry_address", i)
+		case r[i].ABIVersion == "":
+			return fmt.Errorf("voter_registries[%d]: missing abi_version", i)
+		case i > 0 && r[i].FromRewardEpoch <= r[i-1].FromRewardEpoch:
+			return fmt.Errorf("voter_registries[%d]: from_reward_epoch %d not greater than %d of the previous registry", i, r[i].FromRewardEpoch, r[i-1].FromRewardEpoch)
+		}
+	}
+
+	return nil
 }
 
 func readToml[C any](filePath string) (C, error) {
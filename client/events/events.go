@@ -0,0 +1,123 @@
+// Package events publishes round lifecycle events to subscribers, e.g. clients of the server-sent events endpoint.
+package events
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/flare-foundation/go-flare-common/pkg/logger"
+)
+
+const subscriberBufferSize = 256 // events buffered per subscriber, later events are dropped for slow subscribers
+
+type Type string
+
+const (
+	RoundCreated        Type = "roundCreated"
+	RequestAdded        Type = "requestAdded"
+	AttestationVerified Type = "attestationVerified"
+	BitVotesCollected   Type = "bitVotesCollected"
+	ConsensusComputed   Type = "consensusComputed"
+	MerkleRootFinalised Type = "merkleRootFinalised"
+)
+
+// Event is a round lifecycle event. Only the fields relevant for the Type are set.
+type Event struct {
+	Type            Type   `json:"type"`
+	RoundID         uint32 `json:"roundId"`
+	Timestamp       int64  `json:"timestamp"`                 // unix timestamp of the event
+	AttestationType string `json:"attestationType,omitempty"` // RequestAdded, AttestationVerified
+	Source          string `json:"source,omitempty"`          // RequestAdded, AttestationVerified
+	Request         string `json:"request,omitempty"`         // RequestAdded, AttestationVerified
+	Status          string `json:"status,omitempty"`          // AttestationVerified
+	BitVotes        int    `json:"bitVotes,omitempty"`        // BitVotesCollected, number of valid bitVotes
+	BitVoteWeight   uint16 `json:"bitVoteWeight,omitempty"`   // BitVotesCollected, total weight of the voters of valid bitVotes
+	BitVote         string `json:"bitVote,omitempty"`         // ConsensusComputed, consensus bitVote
+	MerkleRoot      string `json:"merkleRoot,omitempty"`      // MerkleRootFinalised
+}
+
+// Filter selects events by attestation type and source. Empty lists match everything.
+// Round level events, which have no attestation type and source, always match.
+type Filter struct {
+	AttestationTypes []string
+	Sources          []string
+}
+
+// Match returns true if the event passes the filter.
+func (f Filter) Match(e Event) bool {
+	if e.AttestationType == "" && e.Source == "" {
+		return true
+	}
+
+	if len(f.AttestationTypes) > 0 && !slices.Contains(f.AttestationTypes, e.AttestationType) {
+		return false
+	}
+
+	if len(f.Sources) > 0 && !slices.Contains(f.Sources, e.Source) {
+		return false
+	}
+
+	return true
+}
+
+type subscriber struct {
+	filter Filter
+	events chan Event
+}
+
+// Hub distributes published events to subscribers. A nil Hub discards all events.
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+}
+
+// NewHub returns a hub without subscribers.
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[*subscriber]struct{})}
+}
+
+// Publish sends the event to all subscribers whose filter matches it. It never blocks.
+// If Timestamp is not set, it is set to the current time.
+func (h *Hub) Publish(e Event) {
+	if h == nil {
+		return
+	}
+
+	if e.Timestamp == 0 {
+		e.Timestamp = time.Now().Unix()
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for s := range h.subscribers {
+		if !s.filter.Match(e) {
+			continue
+		}
+
+		select {
+		case s.events <- e:
+		default:
+			logger.Debugf("event %s for round %d dropped for a slow subscriber", e.Type, e.RoundID)
+		}
+	}
+}
+
+// Subscribe returns a channel of events matching filter and a function that cancels the subscription.
+func (h *Hub) Subscribe(filter Filter) (<-chan Event, func()) {
+	s := &subscriber{filter: filter, events: make(chan Event, subscriberBufferSize)}
+
+	h.mu.Lock()
+	h.subscribers[s] = struct{}{}
+	h.mu.Unlock()
+
+	cancel := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		delete(h.subscribers, s)
+	}
+
+	return s.events, cancel
+}
@@ -0,0 +1,49 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/flare-foundation/fdc-client/client/events"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter(t *testing.T) {
+	filter := events.Filter{AttestationTypes: []string{"EVMTransaction"}, Sources: []string{"ETH", "FLR"}}
+
+	require.True(t, filter.Match(events.Event{Type: events.RoundCreated}))
+	require.True(t, filter.Match(events.Event{Type: events.RequestAdded, AttestationType: "EVMTransaction", Source: "FLR"}))
+	require.False(t, filter.Match(events.Event{Type: events.RequestAdded, AttestationType: "EVMTransaction", Source: "SGB"}))
+	require.False(t, filter.Match(events.Event{Type: events.RequestAdded, AttestationType: "Payment", Source: "ETH"}))
+
+	require.True(t, events.Filter{}.Match(events.Event{Type: events.RequestAdded, AttestationType: "Payment", Source: "BTC"}))
+}
+
+func TestHub(t *testing.T) {
+	hub := events.NewHub()
+
+	all, cancelAll := hub.Subscribe(events.Filter{})
+	defer cancelAll()
+
+	payments, cancelPayments := hub.Subscribe(events.Filter{AttestationTypes: []string{"Payment"}})
+
+	hub.Publish(events.Event{Type: events.RequestAdded, RoundID: 1, AttestationType: "EVMTransaction", Source: "ETH"})
+	hub.Publish(events.Event{Type: events.ConsensusComputed, RoundID: 1, BitVote: "0x0001"})
+
+	e := <-all
+	require.Equal(t, events.RequestAdded, e.Type)
+	require.NotZero(t, e.Timestamp)
+	require.Equal(t, events.ConsensusComputed, (<-all).Type)
+
+	require.Equal(t, events.ConsensusComputed, (<-payments).Type)
+	require.Empty(t, payments)
+
+	cancelPayments()
+	hub.Publish(events.Event{Type: events.RoundCreated, RoundID: 2})
+	require.Empty(t, payments)
+	require.Equal(t, events.RoundCreated, (<-all).Type)
+
+	// a nil hub discards events
+	var nilHub *events.Hub
+	nilHub.Publish(events.Event{Type: events.RoundCreated})
+}
@@ -0,0 +1,73 @@
+// Package health collects readiness checks of the components of the client.
+// The readiness report is served by the server on /health/ready.
+package health
+
+import (
+	"slices"
+	"sync"
+)
+
+// Check is the result of a single readiness check.
+type Check struct {
+	Name    string `json:"name"`
+	OK      bool   `json:"ok"`
+	Message string `json:"message,omitempty"`
+}
+
+// Report lists the results of all readiness checks. The client is ready if all checks pass.
+type Report struct {
+	Ready  bool    `json:"ready"`
+	Checks []Check `json:"checks"`
+}
+
+type namedCheck struct {
+	name  string
+	check func() error
+}
+
+// Registry holds named readiness checks. A check returns nil if the component is ready or an error describing the problem.
+type Registry struct {
+	mu     sync.RWMutex
+	checks []namedCheck
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds check under name. A check registered earlier under the same name is replaced.
+func (r *Registry) Register(name string, check func() error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := slices.IndexFunc(r.checks, func(c namedCheck) bool { return c.name == name })
+	if i >= 0 {
+		r.checks[i].check = check
+		return
+	}
+
+	r.checks = append(r.checks, namedCheck{name: name, check: check})
+}
+
+// Ready runs all checks in the order they were registered.
+// The client is not ready if no check is registered, e.g., before the components are started.
+func (r *Registry) Ready() Report {
+	r.mu.RLock()
+	checks := slices.Clone(r.checks)
+	r.mu.RUnlock()
+
+	report := Report{Ready: len(checks) > 0, Checks: make([]Check, 0, len(checks))}
+
+	for _, c := range checks {
+		result := Check{Name: c.name, OK: true}
+		if err := c.check(); err != nil {
+			result.OK = false
+			result.Message = err.Error()
+			report.Ready = false
+		}
+
+		report.Checks = append(report.Checks, result)
+	}
+
+	return report
+}
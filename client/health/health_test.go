@@ -0,0 +1,28 @@
+package health_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/flare-foundation/fdc-client/client/health"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := health.NewRegistry()
+	require.False(t, registry.Ready().Ready, "no checks registered")
+
+	registry.Register("a", func() error { return nil })
+	registry.Register("b", func() error { return errors.New("failing") })
+
+	report := registry.Ready()
+	require.False(t, report.Ready)
+	require.Equal(t, []health.Check{{Name: "a", OK: true}, {Name: "b", OK: false, Message: "failing"}}, report.Checks)
+
+	registry.Register("b", func() error { return nil })
+
+	report = registry.Ready()
+	require.True(t, report.Ready)
+	require.Len(t, report.Checks, 2)
+}
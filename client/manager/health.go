@@ -0,0 +1,43 @@
+package manager
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/flare-foundation/fdc-client/client/timing"
+)
+
+// checkSigningPolicy returns an error if there is no signing policy for the current voting round.
+func (m *Manager) checkSigningPolicy() error {
+	roundID, err := timing.RoundIDForTS(uint64(time.Now().Unix()))
+	if err != nil {
+		return err
+	}
+
+	if policy, _ := m.signingPolicyStorage.ForVotingRound(roundID); policy == nil {
+		return fmt.Errorf("no signing policy for round %d", roundID)
+	}
+
+	return nil
+}
+
+// checkQueues returns an error listing the queues with waiting attestations and no dequeues for longer than queueStallTimeout.
+func (m *Manager) checkQueues() error {
+	queues := m.current().queues
+
+	var stalled []string
+	for _, name := range slices.Sorted(maps.Keys(queues)) {
+		if depth, since, ok := queues[name].depth.stalled(queueStallTimeout); ok {
+			stalled = append(stalled, fmt.Sprintf("%s: %d waiting, no progress for %v", name, depth, since.Round(time.Second)))
+		}
+	}
+
+	if len(stalled) > 0 {
+		return fmt.Errorf("stalled queues: %s", strings.Join(stalled, "; "))
+	}
+
+	return nil
+}
@@ -2,7 +2,11 @@ package manager
 
 import (
 	"context"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/flare-foundation/go-flare-common/pkg/database"
@@ -13,21 +17,33 @@ import (
 
 	"github.com/flare-foundation/fdc-client/client/attestation"
 	"github.com/flare-foundation/fdc-client/client/config"
+	"github.com/flare-foundation/fdc-client/client/events"
+	"github.com/flare-foundation/fdc-client/client/metrics"
 	"github.com/flare-foundation/fdc-client/client/round"
 	"github.com/flare-foundation/fdc-client/client/shared"
+	"github.com/flare-foundation/fdc-client/client/store"
 	"github.com/flare-foundation/fdc-client/client/timing"
 	"github.com/flare-foundation/fdc-client/client/utils"
 )
 
 type Manager struct {
-	Rounds                storage.Cyclic[uint32, *round.Round] // cyclically cached rounds with buffer roundBuffer.
-	lastRoundCreated      uint32
-	requests              <-chan []database.Log
-	bitVotes              <-chan payload.Round
-	signingPolicies       <-chan []shared.VotersData
-	signingPolicyStorage  *policy.Storage
-	attestationTypeConfig config.AttestationTypes
-	queues                attestationQueues
+	Rounds               storage.Cyclic[uint32, *round.Round] // cyclically cached rounds with buffer roundBuffer.
+	lastRoundCreated     uint32
+	requests             <-chan []database.Log
+	reverted             <-chan []database.Log
+	bitVotes             <-chan payload.Round
+	signingPolicies      <-chan []shared.VotersData
+	signingPolicyStorage *policy.Storage
+	configuration        atomic.Pointer[configuration] // attestation types and queues, swapped on reload
+	reloads              chan reloadRequest
+	breakers             *attestation.Breakers // circuit breakers of the verifiers
+	events               *events.Hub           // round lifecycle events
+	store                store.Store           // persisted rounds
+	reloadRounds         uint32                // number of latest rounds restored from store on startup
+	consensusTimeout     time.Duration         // maximal duration of the consensus bitVote computation, 0 for the time until the signing deadline
+
+	metricsMu    sync.Mutex // serializes round metrics set by concurrent consensus computations
+	metricsRound uint32     // latest round whose metrics were set
 }
 
 // New initializes attestation round manager from raw user configurations.
@@ -36,16 +52,33 @@ func New(configs *config.UserRaw, attestationTypeConfig config.AttestationTypes,
 
 	queues := buildQueues(configs.Queues)
 
-	return &Manager{
-			Rounds:                sharedDataPipes.Rounds,
-			signingPolicyStorage:  signingPolicyStorage,
-			attestationTypeConfig: attestationTypeConfig,
-			queues:                queues,
-			signingPolicies:       sharedDataPipes.Voters,
-			bitVotes:              sharedDataPipes.BitVotes,
-			requests:              sharedDataPipes.Requests,
-		},
-		nil
+	roundStore, err := store.New(configs.RoundStore)
+	if err != nil {
+		return nil, fmt.Errorf("round store: %s", err)
+	}
+
+	m := &Manager{
+		Rounds:               sharedDataPipes.Rounds,
+		signingPolicyStorage: signingPolicyStorage,
+		reloads:              make(chan reloadRequest),
+		breakers:             sharedDataPipes.Breakers,
+		events:               sharedDataPipes.Events,
+		signingPolicies:      sharedDataPipes.Voters,
+		bitVotes:             sharedDataPipes.BitVotes,
+		requests:             sharedDataPipes.Requests,
+		reverted:             sharedDataPipes.Reverted,
+		store:                roundStore,
+		reloadRounds:         configs.RoundStore.ReloadRounds,
+	}
+	m.consensusTimeout = configs.Consensus.Timeout
+	m.configuration.Store(&configuration{types: attestationTypeConfig, queues: queues})
+
+	if sharedDataPipes.Health != nil {
+		sharedDataPipes.Health.Register("signing_policy", m.checkSigningPolicy)
+		sharedDataPipes.Health.Register("queues", m.checkQueues)
+	}
+
+	return m, nil
 }
 
 // Run starts processing data received through the manager's channels.
@@ -54,7 +87,17 @@ func (m *Manager) Run(ctx context.Context, cancel context.CancelFunc) {
 	// without a signing policy.
 	var signingPolicies []shared.VotersData
 
-	go runQueues(ctx, m.queues)
+	defer func() {
+		if err := m.store.Close(); err != nil {
+			logger.Warnf("closing round store: %v", err)
+		}
+	}()
+
+	runQueues(ctx, m.current().queues, m.handler, m.discard)
+
+	if m.breakers != nil {
+		go m.breakers.Run(ctx, m.requeue)
+	}
 
 	select {
 	case signingPolicies = <-m.signingPolicies:
@@ -72,6 +115,10 @@ func (m *Manager) Run(ctx context.Context, cancel context.CancelFunc) {
 		}
 	}
 
+	if err := m.restoreRounds(); err != nil {
+		logger.Errorf("restoring rounds: %v", err)
+	}
+
 	for {
 		select {
 		case signingPolicies := <-m.signingPolicies:
@@ -114,22 +161,17 @@ func (m *Manager) Run(ctx context.Context, cancel context.CancelFunc) {
 				break
 			}
 
-			now := time.Now()
-			err := r.ComputeConsensusBitVote()
-			logger.Debugf("BitVote algorithm finished in %s", time.Since(now))
-			if err != nil {
-				logger.Warnf("Failed bitVote in round %d: %s", bvsForRound.ID, err)
-			} else {
-				logger.Debugf("Consensus bitVote %s for round %d computed.", r.ConsensusBitVote.EncodeBitVoteHex(), bvsForRound.ID)
+			count, weight, _ := r.BitVoteParticipation()
+			m.events.Publish(events.Event{Type: events.BitVotesCollected, RoundID: r.ID, BitVotes: count, BitVoteWeight: weight})
 
-				noOfRetried, err := m.retryUnsuccessfulChosen(r)
-				if err != nil {
-					logger.Warnf("retrying round %d: %v", r.ID, err)
-				} else if noOfRetried > 0 {
-					logger.Debugf("retrying %d attestations in round %d", noOfRetried, r.ID)
-				}
+			// bitVotes received after the computation started, e.g., after a reorg, do not change the consensus
+			if !r.StartConsensus() {
+				logger.Debugf("consensus bitVote for round %d already computed or being computed", r.ID)
+				break
 			}
 
+			go m.computeConsensus(ctx, r)
+
 		case requests := <-m.requests:
 			for i := range requests {
 				err := m.OnRequest(ctx, requests[i])
@@ -138,6 +180,17 @@ func (m *Manager) Run(ctx context.Context, cancel context.CancelFunc) {
 				}
 			}
 
+		case reverted := <-m.reverted:
+			for i := range reverted {
+				err := m.OnRevertedRequest(reverted[i])
+				if err != nil {
+					logger.Error(err)
+				}
+			}
+
+		case reload := <-m.reloads:
+			reload.result <- m.reload(ctx, reload.types, reload.queues)
+
 		case <-ctx.Done():
 			logger.Infof("Manager exiting: %v", ctx.Err())
 			return
@@ -158,10 +211,20 @@ func (m *Manager) GetOrCreateRound(roundID uint32) (*round.Round, error) {
 	}
 
 	roundForID = round.New(roundID, policy.Voters)
+	roundForID.Events = m.events
 	m.lastRoundCreated = roundID
 	logger.Infof("Round %d created", roundID)
+	m.events.Publish(events.Event{Type: events.RoundCreated, RoundID: roundID})
 
 	m.Rounds.Store(roundID, roundForID)
+
+	// rounds that no longer fit into the cache are not needed anymore
+	if size := m.Rounds.Size(); roundID > size {
+		if err := m.store.RemoveBefore(roundID - size); err != nil {
+			logger.Warnf("pruning round store: %v", err)
+		}
+	}
+
 	return roundForID, nil
 }
 
@@ -185,9 +248,97 @@ func (m *Manager) OnBitVote(message payload.Message) (error, error) {
 		return fmt.Errorf("processing bitVote from %s for voting round %d: %s", message.From, message.VotingRound, err), nil
 	}
 
+	metrics.BitVotes.Inc()
+
+	if bitVote, ok := round.SubmittedBitVote(message.From); ok {
+		if err := m.store.SaveBitVote(message.VotingRound, message.From, &bitVote); err != nil {
+			logger.Warnf("storing bitVote from %s for voting round %d: %v", message.From, message.VotingRound, err)
+		}
+	}
+
 	return nil, nil
 }
 
+// computeConsensus computes the consensus bitVote of the round within the configured timeout, by default within the time the Merkle root of the round can still be signed.
+// It runs outside of the manager's loop. On success, the consensus is stored and chosen attestations without a successful response are retried.
+func (m *Manager) computeConsensus(ctx context.Context, r *round.Round) {
+	timeout := m.consensusTimeout
+	if timeout <= 0 {
+		timeout = timing.SigningDuration()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	now := time.Now()
+	err := r.ComputeConsensusBitVote(ctx)
+	logger.Debugf("BitVote algorithm finished in %s", time.Since(now))
+	m.observeConsensus(r, time.Since(now), err)
+	if err != nil {
+		logger.Warnf("Failed bitVote in round %d: %s", r.ID, err)
+		return
+	}
+
+	consensus, _, _ := r.GetConsensusBitVote()
+	logger.Debugf("Consensus bitVote %s for round %d computed.", consensus.EncodeBitVoteHex(), r.ID)
+
+	if err := m.store.SaveConsensus(r.ID, consensus); err != nil {
+		logger.Warnf("storing consensus bitVote for round %d: %v", r.ID, err)
+	}
+
+	noOfRetried, err := m.retryUnsuccessfulChosen(r)
+	if err != nil {
+		logger.Warnf("retrying round %d: %v", r.ID, err)
+	}
+	if noOfRetried > 0 {
+		logger.Debugf("retrying %d attestations in round %d", noOfRetried, r.ID)
+	}
+}
+
+// observeConsensus records metrics of the round whose consensus bitVote computation took duration and finished with err.
+// Round gauges are set only if no later round has set them, since computations of different rounds run concurrently.
+func (m *Manager) observeConsensus(r *round.Round, duration time.Duration, err error) {
+	metrics.ConsensusDuration.Observe(duration.Seconds())
+	if err != nil {
+		metrics.ConsensusFailures.Inc()
+	}
+
+	count, weight, totalWeight := r.BitVoteParticipation()
+
+	requests := make(map[[2]string]int) // by type and source
+	r.RLock()
+	optimal := err == nil && r.ConsensusOptimal
+	for i := range r.Attestations {
+		attType, source := r.Attestations[i].Request.TypeAndSource()
+		requests[[2]string{attType, source}]++
+	}
+	r.RUnlock()
+
+	m.metricsMu.Lock()
+	defer m.metricsMu.Unlock()
+
+	if r.ID < m.metricsRound {
+		return
+	}
+	m.metricsRound = r.ID
+
+	metrics.RoundBitVotes.Set(float64(count))
+	if totalWeight > 0 {
+		metrics.RoundBitVoteWeight.Set(float64(weight) / float64(totalWeight))
+	}
+
+	if optimal {
+		metrics.ConsensusOptimal.Set(1)
+	} else {
+		metrics.ConsensusOptimal.Set(0)
+	}
+
+	metrics.RoundRequests.Reset()
+	for typeAndSource, n := range requests {
+		metrics.RoundRequests.WithLabelValues(typeAndSource[0], typeAndSource[1]).Set(float64(n))
+	}
+}
+
 // OnRequest processes the attestation request.
 // The request is parsed into an Attestation that is assigned to an attestation round according to the timestamp.
 // The request is added to verifier queue.
@@ -203,7 +354,25 @@ func (m *Manager) OnRequest(ctx context.Context, request database.Log) error {
 	}
 
 	added := round.AddAttestation(attestation)
+
+	// on a duplicated request, the fee and indexes of the existing attestation are updated
+	if stored, ok := round.AttestationForRequest(attestation.Request); ok {
+		if err := m.store.SaveAttestation(stored); err != nil {
+			logger.Warnf("storing attestation in round %d: %v", stored.RoundID, err)
+		}
+	}
+
 	if added {
+		attType, source := attestation.Request.TypeAndSource()
+		metrics.Requests.WithLabelValues(attType, source).Inc()
+		m.events.Publish(events.Event{
+			Type:            events.RequestAdded,
+			RoundID:         attestation.RoundID,
+			AttestationType: attType,
+			Source:          source,
+			Request:         hex.EncodeToString(attestation.Request),
+		})
+
 		if err := m.AddToQueue(ctx, attestation); err != nil {
 			return err
 		}
@@ -212,6 +381,43 @@ func (m *Manager) OnRequest(ctx context.Context, request database.Log) error {
 	return nil
 }
 
+// OnRevertedRequest removes the request log that is no longer on the chain from the attestation round.
+// Attestations without remaining request logs are removed from the round.
+func (m *Manager) OnRevertedRequest(request database.Log) error {
+	reverted, err := attestation.AttestationFromDatabaseLog(request)
+	if err != nil {
+		return fmt.Errorf("OnRevertedRequest: %s", err)
+	}
+
+	r, ok := m.Rounds.Get(reverted.RoundID)
+	if !ok {
+		return fmt.Errorf("OnRevertedRequest: no round %d", reverted.RoundID)
+	}
+
+	att, removed, err := r.RemoveRequestLog(reverted)
+	if errors.Is(err, round.ErrAttestationsFixed) {
+		logger.Warnf("reverted request in transaction %s kept: %s", request.TransactionHash, err)
+		metrics.RevertsIgnored.Inc()
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("OnRevertedRequest: %s", err)
+	}
+
+	if removed {
+		logger.Infof("reverted request removed from round %d", reverted.RoundID)
+		err = m.store.RemoveAttestation(att)
+	} else {
+		logger.Infof("reverted request log removed from attestation in round %d", reverted.RoundID)
+		err = m.store.SaveAttestation(att)
+	}
+	if err != nil {
+		logger.Warnf("storing attestation in round %d: %v", reverted.RoundID, err)
+	}
+
+	return nil
+}
+
 // OnSigningPolicy parses SigningPolicyInitialized log and submit addresses, and stores it into the signingPolicyStorage.
 func (m *Manager) OnSigningPolicy(data shared.VotersData) error {
 	err := VotersDataCheck(data)
@@ -241,46 +447,49 @@ func VotersDataCheck(data shared.VotersData) error {
 	return nil
 }
 
-// retryUnsuccessfulChosen adds the requests that are without successful response but were chosen by the consensus bitVote to the priority verifier queues.
+// retryUnsuccessfulChosen adds the requests that are without successful response but were chosen by the consensus bitVote to the priority verifier queues
+// of the current configuration. Requests that cannot be added are skipped and the returned error joins the reasons.
 func (m *Manager) retryUnsuccessfulChosen(round *round.Round) (int, error) {
 	count := 0 // only for logging
+	cfg := m.current()
+
+	var errs []error
+	for _, at := range round.UnsuccessfulChosen() {
+		queueName, err := m.queueName(at, cfg)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("retry: preparing %s: %w", at.Request.TypeAndSourceString(), err))
+			continue
+		}
 
-	for i := range round.Attestations {
-		round.Attestations[i].RLock()
-		defer round.Attestations[i].RUnlock()
-
-		if round.Attestations[i].Consensus && round.Attestations[i].Status != attestation.Success {
-			queueName := round.Attestations[i].QueueName
-
-			queue, ok := m.queues[queueName]
-			if !ok {
-				return 0, fmt.Errorf("retry: no queue: %s", queueName)
-			}
+		queue, ok := cfg.queues[queueName]
+		if !ok {
+			errs = append(errs, fmt.Errorf("retry: no queue: %s", queueName))
+			continue
+		}
 
-			weight := attestation.Weight{Index: round.Attestations[i].Index()}
-			queue.AddFast(round.Attestations[i], weight)
+		queue.addFast(at)
 
-			count++
-		}
+		count++
 	}
 
-	return count, nil
+	return count, errors.Join(errs...)
 }
 
 // AddToQueue adds the attestation to the correct verifier queue.
 func (m *Manager) AddToQueue(ctx context.Context, att *attestation.Attestation) error {
-	err := att.PrepareRequest(m.attestationTypeConfig)
+	cfg := m.current()
+
+	err := m.prepare(att, cfg)
 	if err != nil {
 		return fmt.Errorf("preparing request: %s", err)
 	}
 
-	queue, ok := m.queues[att.QueueName]
+	queue, ok := cfg.queues[att.QueueName]
 	if !ok {
 		return fmt.Errorf("queue %s does not exist", att.QueueName)
 	}
 
-	weight := attestation.Weight{Index: att.Index()}
-	att.QueuePointer = queue.Add(att, weight) // for future use cases
+	queue.add(att)
 
 	return nil
 }
@@ -6,18 +6,27 @@ import (
 	"github.com/flare-foundation/go-flare-common/pkg/database"
 	"github.com/flare-foundation/go-flare-common/pkg/payload"
 	"github.com/flare-foundation/go-flare-common/pkg/policy"
+	"github.com/flare-foundation/go-flare-common/pkg/voters"
 
 	"fmt"
+	"math"
+	"math/big"
+	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
 
 	"github.com/flare-foundation/fdc-client/client/attestation"
 	"github.com/flare-foundation/fdc-client/client/config"
+	"github.com/flare-foundation/fdc-client/client/metrics"
+	"github.com/flare-foundation/fdc-client/client/round"
 	"github.com/flare-foundation/fdc-client/client/shared"
 	"github.com/flare-foundation/fdc-client/tests/mocks"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -214,10 +223,269 @@ func TestManager(t *testing.T) {
 	time.Sleep(1 * time.Second)
 
 	require.True(t, ok)
-	require.Equal(t, 5, int(r.ConsensusBitVote.BitVector.Int64()))
+	consensus, computed, _ := r.GetConsensusBitVote()
+	require.True(t, computed)
+	require.Equal(t, 5, int(consensus.BitVector.Int64()))
 
 	cancel()
 	<-ctx.Done()
 
 	time.Sleep(1 * time.Second)
 }
+
+func TestRestoreRounds(t *testing.T) {
+	cfg, err := config.ReadUserRaw(USER_FILE)
+	require.NoError(t, err)
+	attestationTypeConfig, err := config.ParseAttestationTypes(cfg.AttestationTypeConfig)
+	require.NoError(t, err)
+
+	cfg.RoundStore = config.RoundStore{
+		Type:         "sqlite",
+		Path:         filepath.Join(t.TempDir(), "rounds.db"),
+		ReloadRounds: math.MaxUint32,
+	}
+
+	signingPolicyParsed, err := policy.ParseSigningPolicyInitializedEvent(policyLog)
+	require.NoError(t, err)
+
+	submitToSigning := make(map[common.Address]common.Address)
+	for i := range signingPolicyParsed.Voters {
+		submitToSigning[signingPolicyParsed.Voters[i]] = signingPolicyParsed.Voters[i]
+	}
+	votersData := shared.VotersData{Policy: signingPolicyParsed, SubmitToSigningAddress: submitToSigning}
+
+	mngr, err := New(&cfg, attestationTypeConfig, shared.NewDataPipes())
+	require.NoError(t, err)
+	require.NoError(t, mngr.OnSigningPolicy(votersData))
+
+	// request is not added to a queue as no queue is running
+	r, err := mngr.GetOrCreateRound(664111)
+	require.NoError(t, err)
+	att, err := attestation.AttestationFromDatabaseLog(requestLog)
+	require.NoError(t, err)
+	require.True(t, r.AddAttestation(att))
+	require.NoError(t, mngr.store.SaveAttestation(att))
+
+	bitVoteMessageCorrect := bitVoteMessage
+	bitVoteMessageCorrect.Payload = []byte{0, 1, 1}
+	bverr, err := mngr.OnBitVote(bitVoteMessageCorrect)
+	require.NoError(t, bverr)
+	require.NoError(t, err)
+	require.NoError(t, mngr.store.Close())
+
+	// restart
+	sharedDataPipes := shared.NewDataPipes()
+	restarted, err := New(&cfg, attestationTypeConfig, sharedDataPipes)
+	require.NoError(t, err)
+	require.NoError(t, restarted.OnSigningPolicy(votersData))
+	require.NoError(t, restarted.restoreRounds())
+
+	restored, ok := sharedDataPipes.Rounds.Get(664111)
+	require.True(t, ok)
+	require.Len(t, restored.Attestations, 1)
+	require.Equal(t, att.Request, restored.Attestations[0].Request)
+	require.NotNil(t, restored.Attestations[0].ResponseABI)
+
+	bitVote, ok := restored.SubmittedBitVote(bitVoteMessageCorrect.From)
+	require.True(t, ok)
+	require.Equal(t, uint16(1), bitVote.BitVote.Length)
+	require.NoError(t, restarted.store.Close())
+}
+
+func TestQueueDepth(t *testing.T) {
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "depth"})
+	depth := newQueueDepth(2, gauge)
+
+	at := &attestation.Attestation{}
+
+	// the attestation has an entry in each lane, another attestation has an entry in the regular lane
+	regular := &queueEntry{at: at, attemptsLeft: depth.maxAttempts}
+	fast := &queueEntry{at: at, fast: true}
+
+	depth.added()
+	depth.added()
+	depth.added()
+	require.Equal(t, 3.0, testutil.ToFloat64(gauge))
+
+	// failed entry from the fast lane is not retried
+	depth.dequeued(false)
+	depth.handled(fast, errors.New("failed"))
+	require.Equal(t, 2.0, testutil.ToFloat64(gauge))
+
+	// failed attempt of the entry from the regular lane with an attempt left is retried
+	depth.dequeued(false)
+	depth.handled(regular, errors.New("failed"))
+	require.Equal(t, 2.0, testutil.ToFloat64(gauge))
+
+	// last attempt
+	depth.dequeued(false)
+	depth.handled(regular, errors.New("failed"))
+	require.Equal(t, 1.0, testutil.ToFloat64(gauge))
+
+	// entry of the other attestation is discarded
+	depth.dequeued(true)
+	require.Zero(t, testutil.ToFloat64(gauge))
+	require.True(t, depth.idle())
+}
+
+func TestQueueStalled(t *testing.T) {
+	depth := newQueueDepth(1, nil)
+	entry := &queueEntry{at: &attestation.Attestation{}, attemptsLeft: depth.maxAttempts}
+
+	_, _, stalled := depth.stalled(0)
+	require.False(t, stalled, "empty queue")
+
+	depth.added()
+	time.Sleep(10 * time.Millisecond)
+
+	_, _, stalled = depth.stalled(time.Minute)
+	require.False(t, stalled)
+
+	waiting, since, stalled := depth.stalled(time.Millisecond)
+	require.True(t, stalled)
+	require.Equal(t, 1, waiting)
+	require.GreaterOrEqual(t, since, 10*time.Millisecond)
+
+	depth.dequeued(false)
+	depth.handled(entry, nil)
+
+	_, _, stalled = depth.stalled(0)
+	require.False(t, stalled)
+}
+
+func TestReload(t *testing.T) {
+	cfg, err := config.ReadUserRaw(USER_FILE)
+	require.NoError(t, err)
+	attestationTypeConfig, err := config.ParseAttestationTypes(cfg.AttestationTypeConfig)
+	require.NoError(t, err)
+
+	signingPolicyParsed, err := policy.ParseSigningPolicyInitializedEvent(policyLog)
+	require.NoError(t, err)
+	submitToSigning := make(map[common.Address]common.Address)
+	for i := range signingPolicyParsed.Voters {
+		submitToSigning[signingPolicyParsed.Voters[i]] = signingPolicyParsed.Voters[i]
+	}
+
+	mngr, err := New(&cfg, attestationTypeConfig, shared.NewDataPipes())
+	require.NoError(t, err)
+	require.NoError(t, mngr.OnSigningPolicy(shared.VotersData{Policy: signingPolicyParsed, SubmitToSigningAddress: submitToSigning}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the queue accepts attestations but does not dequeue them
+	old := mngr.current().queues["evmETH"]
+	oldCtx, oldCancel := context.WithCancel(ctx)
+	old.cancel = oldCancel
+	old.InitiateAndRun(oldCtx)
+
+	r, err := mngr.GetOrCreateRound(664111)
+	require.NoError(t, err)
+	att, err := attestation.AttestationFromDatabaseLog(requestLog)
+	require.NoError(t, err)
+	require.True(t, r.AddAttestation(att))
+	require.NoError(t, mngr.AddToQueue(ctx, att))
+	require.Zero(t, att.ConfigVersion)
+
+	// queue of the source does not exist
+	err = mngr.reload(ctx, attestationTypeConfig, config.Queues{"other": cfg.Queues["evmETH"]})
+	require.Error(t, err)
+	require.Zero(t, mngr.current().version)
+
+	// unchanged queue is kept
+	require.NoError(t, mngr.reload(ctx, attestationTypeConfig, cfg.Queues))
+	require.Equal(t, uint64(1), mngr.current().version)
+	require.Same(t, old, mngr.current().queues["evmETH"])
+
+	// source moved to a new queue
+	reloaded, err := config.ParseAttestationTypes(cfg.AttestationTypeConfig)
+	require.NoError(t, err)
+	attType, err := config.StringToByte32("EVMTransaction")
+	require.NoError(t, err)
+	source, err := config.StringToByte32("ETH")
+	require.NoError(t, err)
+	sourceConfig := reloaded[attType].SourcesConfig[source]
+	sourceConfig.QueueName = "evmNew"
+	sourceConfig.LUTLimit = 100
+	reloaded[attType].SourcesConfig[source] = sourceConfig
+
+	require.NoError(t, mngr.reload(ctx, reloaded, config.Queues{"evmNew": cfg.Queues["evmETH"]}))
+	require.Equal(t, uint64(2), mngr.current().version)
+	require.NotContains(t, mngr.current().queues, "evmETH")
+
+	// the waiting attestation is prepared again and moved instead of being handled by the retired queue
+	old.Dequeue(ctx, func(context.Context, *queueEntry) error {
+		t.Error("attestation handled by the retired queue")
+		return nil
+	}, mngr.discard(old))
+
+	att.RLock()
+	require.Equal(t, "evmNew", att.QueueName)
+	require.Equal(t, uint64(100), att.LUTLimit)
+	require.Equal(t, uint64(2), att.ConfigVersion)
+	att.RUnlock()
+
+	// the drained retired queue is stopped
+	stopped := make(chan struct{})
+	go func() {
+		run(oldCtx, old, func(context.Context, *attestation.Attestation) error {
+			t.Error("attestation handled by the retired queue")
+			return nil
+		}, mngr.discard(old))
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * queueRetireInterval):
+		t.Fatal("retired queue not stopped")
+	}
+	require.ErrorIs(t, oldCtx.Err(), context.Canceled)
+
+	// a parked attestation is requeued to the queue of its source after a reload
+	// a new attestation is used, since att may still be handled by the queue it was moved to
+	sourceConfig.QueueName = "evmParked"
+	reloaded[attType].SourcesConfig[source] = sourceConfig
+
+	require.NoError(t, mngr.reload(ctx, reloaded, config.Queues{"evmParked": cfg.Queues["evmETH"]}))
+
+	parked, err := attestation.AttestationFromDatabaseLog(requestLog)
+	require.NoError(t, err)
+	mngr.requeue(parked)
+
+	parked.RLock()
+	require.Equal(t, "evmParked", parked.QueueName)
+	require.Equal(t, uint64(100), parked.LUTLimit)
+	require.Equal(t, uint64(3), parked.ConfigVersion)
+	parked.RUnlock()
+}
+
+func TestObserveConsensus(t *testing.T) {
+	m := &Manager{}
+
+	newRound := func(id uint32, requests int, optimal bool) *round.Round {
+		r := round.New(id, voters.NewSet([]common.Address{common.HexToAddress("0x1")}, []uint16{1}, nil))
+		for i := range requests {
+			attType, source := [32]byte{'A'}, [32]byte{'B'}
+			request := append(append(attType[:], source[:]...), common.BigToHash(big.NewInt(int64(i))).Bytes()...)
+			r.AddAttestation(&attestation.Attestation{Indexes: []attestation.IndexLog{{BlockNumber: uint64(i)}}, Request: request, Fee: big.NewInt(1)})
+		}
+		r.ConsensusOptimal = optimal
+
+		return r
+	}
+
+	m.observeConsensus(newRound(2, 3, true), time.Second, nil)
+	require.Equal(t, 3.0, testutil.ToFloat64(metrics.RoundRequests.WithLabelValues("A", "B")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.ConsensusOptimal))
+
+	// an earlier round that finishes later does not overwrite the metrics
+	m.observeConsensus(newRound(1, 5, false), time.Second, nil)
+	require.Equal(t, 3.0, testutil.ToFloat64(metrics.RoundRequests.WithLabelValues("A", "B")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.ConsensusOptimal))
+
+	// a failed computation is not optimal
+	m.observeConsensus(newRound(3, 2, true), time.Second, errors.New("failed"))
+	require.Equal(t, 2.0, testutil.ToFloat64(metrics.RoundRequests.WithLabelValues("A", "B")))
+	require.Zero(t, testutil.ToFloat64(metrics.ConsensusOptimal))
+}
@@ -2,34 +2,236 @@ package manager
 
 import (
 	"context"
+	"encoding/hex"
+	"sync"
+	"time"
 
 	"github.com/flare-foundation/fdc-client/client/attestation"
 	"github.com/flare-foundation/fdc-client/client/config"
+	"github.com/flare-foundation/fdc-client/client/events"
+	"github.com/flare-foundation/fdc-client/client/metrics"
 	"github.com/flare-foundation/go-flare-common/pkg/logger"
 	"github.com/flare-foundation/go-flare-common/pkg/priority"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
-type attestationQueue = priority.PriorityQueue[*attestation.Attestation, attestation.Weight]
+// queueStallTimeout is the duration after which a queue with waiting attestations and no dequeues is reported as stalled.
+const queueStallTimeout = 2 * time.Minute
 
-type attestationQueues map[string]*attestationQueue
+type attestationQueue = priority.PriorityQueue[*queueEntry, attestation.Weight]
+
+// queueEntry is an entry of an attestation in a queue. An attestation can have several entries in a queue, e.g., when it is retried in the fast lane.
+// A failed entry from the regular lane is retried by the queue as the same entry.
+type queueEntry struct {
+	at           *attestation.Attestation
+	fast         bool
+	attemptsLeft int // attempts left of an entry in the regular lane, tracked by queueDepth
+}
+
+// queueRetireInterval is the interval at which a retired queue is checked for whether it was drained.
+const queueRetireInterval = time.Second
+
+// verifierQueue is an attestation queue that tracks its depth.
+type verifierQueue struct {
+	*attestationQueue
+	params priority.Params
+	depth  *queueDepth
+	cancel context.CancelFunc // cancels the context the queue runs with
+}
+
+type attestationQueues map[string]*verifierQueue
 
 // buildQueues builds attestation queues from configurations.
 func buildQueues(queuesConfigs config.Queues) attestationQueues {
 	queues := make(attestationQueues)
 
 	for k := range queuesConfigs {
-		params := queuesConfigs[k]
-		queue := priority.New[*attestation.Attestation, attestation.Weight](params, k)
-		queues[k] = &queue
+		queues[k] = newVerifierQueue(k, queuesConfigs[k])
 	}
 
 	return queues
 }
 
-// handler handles dequeued attestation.
-func handler(ctx context.Context, at *attestation.Attestation) error {
+func newVerifierQueue(name string, params priority.Params) *verifierQueue {
+	queue := priority.New[*queueEntry, attestation.Weight](params, name)
+
+	return &verifierQueue{
+		attestationQueue: &queue,
+		params:           params,
+		depth:            newQueueDepth(params.MaxAttempts, metrics.QueueDepth.WithLabelValues(name)),
+	}
+}
+
+// add adds the attestation to the regular lane of the queue.
+func (q *verifierQueue) add(at *attestation.Attestation) {
+	q.depth.added()
+	q.Add(&queueEntry{at: at, attemptsLeft: q.depth.maxAttempts}, attestation.Weight{Index: at.Index()})
+}
+
+// addFast adds the attestation to the fast lane of the queue.
+func (q *verifierQueue) addFast(at *attestation.Attestation) {
+	q.depth.added()
+	q.AddFast(&queueEntry{at: at, fast: true}, attestation.Weight{Index: at.Index()})
+}
+
+// retire stops the queue once no attestations are waiting in it or being handled by it.
+// Waiting attestations are moved to their new queues when dequeued. The queue is stopped by an empty entry in the fast lane.
+func (q *verifierQueue) retire(ctx context.Context) {
+	q.depth.detach()
+
+	ticker := time.NewTicker(queueRetireInterval)
+	defer ticker.Stop()
+
+	for !q.depth.idle() {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	q.AddFast(nil, attestation.Weight{})
+}
+
+// queueDepth tracks the number of attestations waiting in a queue, including the ones waiting to be retried.
+type queueDepth struct {
+	maxAttempts int
+	gauge       prometheus.Gauge
+
+	mu       sync.Mutex
+	depth    int
+	progress time.Time // time of the last dequeue or of the last add to the empty queue
+	handling int       // number of entries being handled
+}
+
+func newQueueDepth(maxAttempts int, gauge prometheus.Gauge) *queueDepth {
+	return &queueDepth{
+		maxAttempts: maxAttempts,
+		gauge:       gauge,
+	}
+}
+
+// added records an entry added to the queue.
+func (d *queueDepth) added() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.depth == 0 {
+		d.progress = time.Now()
+	}
+
+	d.set(d.depth + 1)
+}
+
+// dequeued records an entry dequeued from the queue. Discarded entries are not handled.
+func (d *queueDepth) dequeued(discarded bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !discarded {
+		d.handling++
+	}
+
+	d.progress = time.Now()
+	d.set(d.depth - 1)
+}
+
+// handled records an entry handled with err.
+// Failed entries from the regular lane with attempts left are retried by the queue and are counted as waiting.
+func (d *queueDepth) handled(entry *queueEntry, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.handling--
+
+	if entry.fast {
+		return
+	}
+
+	entry.attemptsLeft--
+	if err == nil || entry.attemptsLeft <= 0 {
+		return
+	}
+
+	d.set(d.depth + 1)
+}
+
+// stalled returns the number of waiting attestations and the duration since the last dequeue
+// if there are waiting attestations and nothing was dequeued for longer than timeout.
+func (d *queueDepth) stalled(timeout time.Duration) (int, time.Duration, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	since := time.Since(d.progress)
+	if d.depth <= 0 || since <= timeout {
+		return 0, 0, false
+	}
+
+	return d.depth, since, true
+}
+
+// idle returns true if no attestations are waiting in the queue or being handled.
+func (d *queueDepth) idle() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.depth <= 0 && d.handling <= 0
+}
+
+func (d *queueDepth) set(depth int) {
+	d.depth = depth
+	if d.gauge != nil {
+		d.gauge.Set(float64(depth))
+	}
+}
+
+// detach stops reporting the depth, e.g., when the queue is replaced by a queue with the same name.
+func (d *queueDepth) detach() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.gauge = nil
+}
+
+// handler handles dequeued attestation and stores the result.
+// Attestations without an available verifier are parked until one of their verifiers becomes available.
+func (m *Manager) handler(ctx context.Context, at *attestation.Attestation) error {
+	if m.breakers != nil && !at.VerifiersAvailable() {
+		logger.Debugf("attestation request %s for round %d parked, no verifier available", at.Request.TypeAndSourceString(), at.RoundID)
+		m.breakers.Park(at)
+		return nil
+	}
+
+	start := time.Now()
 	err := at.Handle(ctx)
+
+	at.RLock()
+	reverted := at.Reverted
+	status := at.Status
+	queueName := at.QueueName
+	at.RUnlock()
+
+	metrics.VerifierDuration.WithLabelValues(queueName).Observe(time.Since(start).Seconds())
+	metrics.VerifierResults.WithLabelValues(queueName, status.String()).Inc()
+
+	attType, source := at.Request.TypeAndSource()
+	m.events.Publish(events.Event{
+		Type:            events.AttestationVerified,
+		RoundID:         at.RoundID,
+		AttestationType: attType,
+		Source:          source,
+		Request:         hex.EncodeToString(at.Request),
+		Status:          status.String(),
+	})
+
+	// reverted attestations were already removed from the store
+	if !reverted {
+		if storeErr := m.store.SaveAttestation(at); storeErr != nil {
+			logger.Warnf("storing attestation in round %d: %v", at.RoundID, storeErr)
+		}
+	}
+
 	if err != nil {
 		wrapped := errors.Wrapf(err, "attestation request %s for round %d failed", at.Request.TypeAndSourceString(), at.RoundID)
 		logger.Info(wrapped.Error())
@@ -38,25 +240,72 @@ func handler(ctx context.Context, at *attestation.Attestation) error {
 	return nil
 }
 
-// discard discards requests that do not need to be handled.
-func discard(ctx context.Context, at *attestation.Attestation) bool {
-	return at.Discard(ctx)
+// requeue adds the parked attestation back to its queue in the current configuration.
+func (m *Manager) requeue(at *attestation.Attestation) {
+	cfg := m.current()
+
+	queueName, err := m.queueName(at, cfg)
+	if err != nil {
+		logger.Warnf("requeue: preparing attestation request %s for round %d after reload: %s", at.Request.TypeAndSourceString(), at.RoundID, err)
+		return
+	}
+
+	queue, ok := cfg.queues[queueName]
+	if !ok {
+		logger.Warnf("requeue: queue %s does not exist", queueName)
+		return
+	}
+
+	logger.Debugf("attestation request %s for round %d requeued", at.Request.TypeAndSourceString(), at.RoundID)
+	queue.add(at)
 }
 
-// runQueues runs all attestation queues at once.
-func runQueues(ctx context.Context, queues attestationQueues) {
+// discard returns a function that discards entries dequeued from q whose requests do not need to be handled
+// and requests that were moved to another queue after a configuration reload.
+func (m *Manager) discard(q *verifierQueue) func(context.Context, *queueEntry) bool {
+	return func(ctx context.Context, entry *queueEntry) bool {
+		discarded := entry.at.Discard(ctx) || m.moved(q, entry.at, entry.fast)
+		q.depth.dequeued(discarded)
+
+		return discarded
+	}
+}
+
+// runQueues runs all attestation queues at once, each with its own context derived from ctx.
+// The queues accept new items when runQueues returns.
+func runQueues(ctx context.Context, queues attestationQueues, handler func(context.Context, *attestation.Attestation) error, discard func(*verifierQueue) func(context.Context, *queueEntry) bool) {
 	for k := range queues {
-		go func(k string) {
-			run(ctx, queues[k])
-		}(k)
+		queueCtx, cancel := context.WithCancel(ctx)
+		queues[k].cancel = cancel
+		queues[k].InitiateAndRun(queueCtx)
+
+		go func(q *verifierQueue) {
+			run(queueCtx, q, handler, discard(q))
+		}(queues[k])
 	}
 }
 
 // run tracks and handles all dequeued attestations from a queue.
-func run(ctx context.Context, q *attestationQueue) {
-	q.InitiateAndRun(ctx)
+// An empty entry cancels the context of the queue and run returns.
+func run(ctx context.Context, q *verifierQueue, handler func(context.Context, *attestation.Attestation) error, discard func(context.Context, *queueEntry) bool) {
+	tracked := func(ctx context.Context, entry *queueEntry) error {
+		err := handler(ctx, entry.at)
+		q.depth.handled(entry, err)
+
+		return err
+	}
+
+	stopping := func(ctx context.Context, entry *queueEntry) bool {
+		if entry == nil {
+			q.cancel()
+			return true
+		}
+
+		return discard(ctx, entry)
+	}
+
 	for {
-		q.Dequeue(ctx, handler, discard)
+		q.Dequeue(ctx, tracked, stopping)
 
 		if err := ctx.Err(); err != nil {
 			logger.Infof("queue %s exiting: %v ", q.Name(), err)
@@ -0,0 +1,184 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/flare-foundation/go-flare-common/pkg/logger"
+
+	"github.com/flare-foundation/fdc-client/client/attestation"
+	"github.com/flare-foundation/fdc-client/client/config"
+	"github.com/flare-foundation/fdc-client/client/utils"
+)
+
+// configuration is the part of the manager's configuration that can be reloaded without a restart.
+// It is never modified, a reload replaces it.
+type configuration struct {
+	version uint64
+	types   config.AttestationTypes
+	queues  attestationQueues
+}
+
+type reloadRequest struct {
+	types  config.AttestationTypes
+	queues config.Queues
+	result chan<- error
+}
+
+// current returns the configuration in use.
+func (m *Manager) current() *configuration {
+	return m.configuration.Load()
+}
+
+// supports returns true if the attestation type and source of the request are configured.
+func (c *configuration) supports(request attestation.Request) bool {
+	attType, err := request.AttestationType()
+	if err != nil {
+		return false
+	}
+
+	source, err := request.Source()
+	if err != nil {
+		return false
+	}
+
+	_, ok := c.types[attType].SourcesConfig[source]
+
+	return ok
+}
+
+// Reload replaces the attestation types configuration and the queues of the manager.
+// The reload is applied by the running manager and Reload blocks until it is applied or ctx is done.
+//
+// Queues whose parameters did not change are kept. Replaced and removed queues are retired:
+// attestations waiting in them are prepared again with the new configuration and moved to their new queue when dequeued.
+// A retired queue is stopped once it is drained.
+// Attestations that are being verified are not affected. An attestation whose type and source are no longer configured
+// keeps the configuration it was prepared with.
+func (m *Manager) Reload(ctx context.Context, types config.AttestationTypes, queues config.Queues) error {
+	result := make(chan error, 1)
+
+	select {
+	case m.reloads <- reloadRequest{types: types, queues: queues, result: result}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reload validates the new configuration, starts the new queues and swaps the configuration.
+func (m *Manager) reload(ctx context.Context, types config.AttestationTypes, queuesConfigs config.Queues) error {
+	for attType := range types {
+		for source, sourceConfig := range types[attType].SourcesConfig {
+			if _, ok := queuesConfigs[sourceConfig.QueueName]; !ok {
+				return fmt.Errorf("reload: queue %s of %s, %s does not exist", sourceConfig.QueueName, utils.Bytes32ToString(attType), utils.Bytes32ToString(source))
+			}
+		}
+	}
+
+	old := m.current()
+
+	queues := make(attestationQueues)
+	started := make(attestationQueues)
+	for name, params := range queuesConfigs {
+		if queue, ok := old.queues[name]; ok && queue.params == params {
+			queues[name] = queue
+			continue
+		}
+
+		queue := newVerifierQueue(name, params)
+		queues[name] = queue
+		started[name] = queue
+	}
+
+	runQueues(ctx, started, m.handler, m.discard)
+
+	m.configuration.Store(&configuration{version: old.version + 1, types: types, queues: queues})
+
+	if m.breakers != nil {
+		m.breakers.Retain(types)
+	}
+
+	for name, queue := range old.queues {
+		if queues[name] != queue {
+			go queue.retire(ctx)
+			logger.Infof("queue %s retired", name)
+		}
+	}
+
+	logger.Infof("configuration reloaded: %d attestation types, %d queues, %d new or changed queues", len(types), len(queues), len(started))
+
+	return nil
+}
+
+// prepare prepares the attestation request with the configuration cfg.
+func (m *Manager) prepare(at *attestation.Attestation, cfg *configuration) error {
+	err := at.PrepareRequest(cfg.types, m.breakers)
+	if err != nil {
+		return err
+	}
+
+	at.Lock()
+	at.ConfigVersion = cfg.version
+	at.Unlock()
+
+	return nil
+}
+
+// moved prepares the attestation dequeued from q again if the configuration was reloaded since it was prepared.
+// If the attestation belongs to another queue in the current configuration, it is added to that queue and true is returned.
+// Entries from the fast lane are moved to the fast lane.
+func (m *Manager) moved(q *verifierQueue, at *attestation.Attestation, fast bool) bool {
+	cfg := m.current()
+	queueName, err := m.queueName(at, cfg)
+	if err != nil {
+		logger.Warnf("preparing attestation request %s for round %d after reload: %s", at.Request.TypeAndSourceString(), at.RoundID, err)
+		return false
+	}
+
+	target, ok := cfg.queues[queueName]
+	if !ok || target == q {
+		return false
+	}
+
+	logger.Debugf("attestation request %s for round %d moved to queue %s", at.Request.TypeAndSourceString(), at.RoundID, queueName)
+
+	if fast {
+		target.addFast(at)
+	} else {
+		target.add(at)
+	}
+
+	return true
+}
+
+// queueName prepares the attestation again with cfg if the configuration was reloaded since it was prepared
+// and returns the name of its queue.
+func (m *Manager) queueName(at *attestation.Attestation, cfg *configuration) (string, error) {
+	at.Lock()
+	outdated := at.ConfigVersion != cfg.version
+	if outdated && !cfg.supports(at.Request) {
+		// keep the previous configuration, the request is not checked again until the next reload
+		at.ConfigVersion = cfg.version
+		outdated = false
+		logger.Warnf("attestation request %s for round %d not supported after reload, using previous configuration", at.Request.TypeAndSourceString(), at.RoundID)
+	}
+	at.Unlock()
+
+	if outdated {
+		if err := m.prepare(at, cfg); err != nil {
+			return "", err
+		}
+	}
+
+	at.RLock()
+	defer at.RUnlock()
+
+	return at.QueueName, nil
+}
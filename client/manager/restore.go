@@ -0,0 +1,123 @@
+package manager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/flare-foundation/go-flare-common/pkg/logger"
+
+	"github.com/flare-foundation/fdc-client/client/attestation"
+	"github.com/flare-foundation/fdc-client/client/round"
+	"github.com/flare-foundation/fdc-client/client/store"
+	"github.com/flare-foundation/fdc-client/client/timing"
+)
+
+// restoreRounds restores the latest reloadRounds rounds from the round store.
+// Signing policies for the restored rounds must already be in the signingPolicyStorage.
+// Attestations that still need a response are added to the verifier queues.
+func (m *Manager) restoreRounds() error {
+	if m.reloadRounds == 0 {
+		return nil
+	}
+
+	now := uint64(time.Now().Unix())
+	currentRoundID, err := timing.RoundIDForTS(now)
+	if err != nil {
+		return err
+	}
+
+	var fromRoundID uint32
+	if currentRoundID > m.reloadRounds {
+		fromRoundID = currentRoundID - m.reloadRounds
+	}
+
+	storedRounds, err := m.store.LoadRounds(fromRoundID)
+	if err != nil {
+		return err
+	}
+
+	for i := range storedRounds {
+		r, err := m.restoreRound(storedRounds[i], now)
+		if err != nil {
+			logger.Warnf("restoring round %d: %v", storedRounds[i].ID, err)
+			continue
+		}
+
+		m.Rounds.Store(r.ID, r)
+		if r.ID > m.lastRoundCreated {
+			m.lastRoundCreated = r.ID
+		}
+		logger.Infof("Round %d restored with %d attestations and %d bitVotes", r.ID, len(storedRounds[i].Attestations), len(storedRounds[i].BitVotes))
+	}
+
+	return nil
+}
+
+// restoreRound rebuilds a round from its stored state.
+func (m *Manager) restoreRound(storedRound store.Round, now uint64) (*round.Round, error) {
+	policy, _ := m.signingPolicyStorage.ForVotingRound(storedRound.ID)
+	if policy == nil {
+		return nil, fmt.Errorf("no signing policy")
+	}
+
+	r := round.New(storedRound.ID, policy.Voters)
+	r.Events = m.events
+
+	for _, att := range storedRound.Attestations {
+		r.AddAttestation(att)
+	}
+
+	for _, bitVote := range storedRound.BitVotes {
+		r.RestoreBitVote(bitVote.SubmitAddress, bitVote.BitVote)
+	}
+
+	if storedRound.ConsensusComputed {
+		if err := r.RestoreConsensus(storedRound.ConsensusBitVote); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg := m.current()
+
+	for _, att := range r.Attestations {
+		storedStatus := att.Status
+
+		// response ABI, LUT limit and verifier credentials are not stored
+		err := m.prepare(att, cfg)
+		if err != nil {
+			logger.Warnf("preparing restored request in round %d: %v", r.ID, err)
+			continue
+		}
+
+		if storedStatus == attestation.Success || !needsResponse(storedRound, att, now) {
+			att.Lock()
+			att.Status = storedStatus
+			att.Unlock()
+			continue
+		}
+
+		queue, ok := cfg.queues[att.QueueName]
+		if !ok {
+			logger.Warnf("restoring round %d: queue %s does not exist", r.ID, att.QueueName)
+			continue
+		}
+
+		queue.add(att)
+	}
+
+	return r, nil
+}
+
+// needsResponse returns true if the restored attestation can still be used in the protocol.
+// Before consensus, the attestation is needed while bitVotes for the round can be submitted.
+// After consensus, the attestation is needed if it was chosen.
+func needsResponse(storedRound store.Round, att *attestation.Attestation, now uint64) bool {
+	if storedRound.ConsensusComputed {
+		att.RLock()
+		defer att.RUnlock()
+
+		return att.Consensus
+	}
+
+	return now < timing.ChooseEndTS(storedRound.ID)
+}
@@ -0,0 +1,187 @@
+// Package metrics defines Prometheus metrics of the client.
+// The metrics are registered to Registry, which is served by the server on /metrics.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "fdc"
+
+// Registry holds all metrics of the client together with Go runtime and process metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// CollectorLagBlocks is the number of blocks that the indexer head was ahead of the last queried block when the attestation request listener polled.
+	CollectorLagBlocks = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "collector",
+		Name:      "lag_blocks",
+		Help:      "Number of blocks the indexer head was ahead of the last block queried for attestation requests.",
+	})
+
+	// CollectorLagSeconds is the difference between the wall clock and the timestamp of the indexer head.
+	CollectorLagSeconds = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "collector",
+		Name:      "lag_seconds",
+		Help:      "Difference between the wall clock and the timestamp of the latest indexed block.",
+	})
+
+	// CollectorRestarts counts restarts of collector listeners after they failed.
+	CollectorRestarts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "collector",
+		Name:      "restarts_total",
+		Help:      "Number of restarts of collector listeners after they failed.",
+	}, []string{"listener"})
+
+	// Requests counts distinct attestation requests added to rounds.
+	Requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "round",
+		Name:      "requests_total",
+		Help:      "Number of distinct attestation requests added to rounds.",
+	}, []string{"type", "source"})
+
+	// RevertsIgnored counts request logs that were no longer on the chain after the attestations of their round could no longer change.
+	RevertsIgnored = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "round",
+		Name:      "reverts_ignored_total",
+		Help:      "Number of reverted request logs kept in their round because the choose phase of the round started.",
+	})
+
+	// RoundRequests is the number of distinct attestation requests in the latest round with computed consensus.
+	RoundRequests = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "round",
+		Name:      "requests",
+		Help:      "Number of distinct attestation requests in the latest round with computed consensus.",
+	}, []string{"type", "source"})
+
+	// VerifierDuration observes durations of attestation request verifications.
+	VerifierDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "verifier",
+		Name:      "duration_seconds",
+		Help:      "Duration of attestation request verification.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"queue"})
+
+	// VerifierResults counts outcomes of attestation request verifications by the status of the attestation.
+	VerifierResults = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "verifier",
+		Name:      "results_total",
+		Help:      "Number of attestation request verifications by resulting attestation status.",
+	}, []string{"queue", "status"})
+
+	// QueueDepth is the number of attestations waiting in a verifier queue.
+	QueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "queue",
+		Name:      "depth",
+		Help:      "Number of attestations waiting in the verifier queue, including the ones waiting to be retried.",
+	}, []string{"queue"})
+
+	// BitVotes counts valid bitVotes.
+	BitVotes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "round",
+		Name:      "bitvotes_total",
+		Help:      "Number of valid bitVotes received.",
+	})
+
+	// RoundBitVotes is the number of voters that submitted a bitVote in the latest round with computed consensus.
+	RoundBitVotes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "round",
+		Name:      "bitvotes",
+		Help:      "Number of voters that submitted a valid bitVote in the latest round with computed consensus.",
+	})
+
+	// RoundBitVoteWeight is the share of the total weight of the voters that submitted a bitVote in the latest round with computed consensus.
+	RoundBitVoteWeight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "round",
+		Name:      "bitvote_weight_ratio",
+		Help:      "Share of the total weight of the voters that submitted a valid bitVote in the latest round with computed consensus.",
+	})
+
+	// ConsensusDuration observes durations of consensus bitVote computations.
+	ConsensusDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "consensus",
+		Name:      "duration_seconds",
+		Help:      "Duration of the consensus bitVote computation.",
+		Buckets:   []float64{0.001, 0.01, 0.1, 0.5, 1, 2.5, 5, 10, 30},
+	})
+
+	// ConsensusOptimal is 1 if the latest consensus bitVote is known to be optimal and 0 otherwise.
+	ConsensusOptimal = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "consensus",
+		Name:      "optimal",
+		Help:      "1 if the latest computed consensus bitVote is known to be optimal, 0 otherwise.",
+	})
+
+	// ConsensusFailures counts rounds for which consensus bitVote could not be computed.
+	ConsensusFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "consensus",
+		Name:      "failures_total",
+		Help:      "Number of rounds for which the consensus bitVote could not be computed.",
+	})
+
+	// MerkleRootLatency observes durations from the end of the choose phase of a round until its Merkle root is available.
+	MerkleRootLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "round",
+		Name:      "merkle_root_latency_seconds",
+		Help:      "Duration from the end of the choose phase until the Merkle root of the round is available.",
+		Buckets:   []float64{1, 5, 10, 20, 30, 45, 60, 90, 120, 180},
+	})
+
+	// FSPResponses counts responses of FSP endpoints by http status code.
+	FSPResponses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "fsp",
+		Name:      "responses_total",
+		Help:      "Number of responses of FSP endpoints by http status code.",
+	}, []string{"endpoint", "code"})
+
+	// FSPStatuses counts responses of FSP endpoints by payload status.
+	FSPStatuses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "fsp",
+		Name:      "statuses_total",
+		Help:      "Number of responses of FSP endpoints by payload status (OK, EMPTY, RETRY).",
+	}, []string{"endpoint", "status"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		CollectorLagBlocks,
+		CollectorLagSeconds,
+		CollectorRestarts,
+		Requests,
+		RevertsIgnored,
+		RoundRequests,
+		VerifierDuration,
+		VerifierResults,
+		QueueDepth,
+		BitVotes,
+		RoundBitVotes,
+		RoundBitVoteWeight,
+		ConsensusDuration,
+		ConsensusOptimal,
+		ConsensusFailures,
+		MerkleRootLatency,
+		FSPResponses,
+		FSPStatuses,
+	)
+}
@@ -0,0 +1,320 @@
+// Package replay recomputes the consensus bitVote and the Merkle root of a past voting round from the chain data,
+// without starting the collector, the manager or the server.
+package replay
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/flare-foundation/go-flare-common/pkg/database"
+	"github.com/flare-foundation/go-flare-common/pkg/logger"
+	"github.com/flare-foundation/go-flare-common/pkg/policy"
+
+	"github.com/flare-foundation/fdc-client/client/attestation"
+	bitvotes "github.com/flare-foundation/fdc-client/client/attestation/bitVotes"
+	"github.com/flare-foundation/fdc-client/client/collector"
+	"github.com/flare-foundation/fdc-client/client/config"
+	"github.com/flare-foundation/fdc-client/client/round"
+	"github.com/flare-foundation/fdc-client/client/timing"
+)
+
+const verifyWorkers = 8 // maximal number of concurrent verifications
+
+// Options of a replay.
+type Options struct {
+	RoundID   uint32
+	Verify    bool                            // if true, verifiers are queried for attestations without a response in Responses
+	Responses map[string]attestation.Response // responses by hex encoded requests without 0x prefix
+}
+
+// Replayer fetches the data of a round from Source.
+type Replayer struct {
+	Source          collector.IndexerSource
+	System          *config.System
+	ProtocolID      uint8
+	AttestationType config.AttestationTypes // used to validate responses and to query verifiers
+}
+
+// Result is the outcome of a replay.
+type Result struct {
+	RoundID          uint32                `json:"roundId"`
+	RewardEpochID    int64                 `json:"rewardEpochId"`
+	BitVotes         int                   `json:"bitVotes"`
+	BitVoteWeight    uint16                `json:"bitVoteWeight"`
+	TotalWeight      uint16                `json:"totalWeight"`
+	ConsensusBitVote string                `json:"consensusBitVote,omitempty"`
+	ConsensusOptimal bool                  `json:"consensusOptimal"`
+	ConsensusError   string                `json:"consensusError,omitempty"`
+	MerkleRoot       string                `json:"merkleRoot,omitempty"`
+	MerkleRootError  string                `json:"merkleRootError,omitempty"`
+	Attestations     []AttestationResult   `json:"attestations"`
+	Explanation      *bitvotes.Explanation `json:"explanation,omitempty"`
+}
+
+// AttestationResult describes an attestation of the replayed round. Attestations are in the order of the round.
+type AttestationResult struct {
+	Index     int    `json:"index"`
+	Type      string `json:"type"`
+	Source    string `json:"source"`
+	Fee       string `json:"fee"`
+	Consensus bool   `json:"consensus"`
+	Status    string `json:"status"`
+	Hash      string `json:"hash,omitempty"`
+	Request   string `json:"request"`
+}
+
+type responseEntry struct {
+	Request  string `json:"request"`
+	Response string `json:"response"`
+}
+
+// ReadResponses reads responses from a JSON file with a list of {"request": "0x...", "response": "0x..."} objects.
+func ReadResponses(path string) (map[string]attestation.Response, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading responses: %s", err)
+	}
+
+	var entries []responseEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parsing responses: %s", err)
+	}
+
+	responses := make(map[string]attestation.Response, len(entries))
+	for i := range entries {
+		response, err := hex.DecodeString(strings.TrimPrefix(entries[i].Response, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("response %d: %s", i, err)
+		}
+
+		responses[normalizeHex(entries[i].Request)] = response
+	}
+
+	return responses, nil
+}
+
+func normalizeHex(s string) string {
+	return strings.ToLower(strings.TrimPrefix(s, "0x"))
+}
+
+// Replay rebuilds the round with the requests, the signing policy and the bitVotes from the chain data and computes the consensus bitVote.
+// Responses are taken from opts.Responses or, if opts.Verify is set, from the verifiers. The Merkle root is computed if all chosen attestations have a valid response.
+func (r *Replayer) Replay(ctx context.Context, opts Options) (*Result, error) {
+	votersData, err := collector.SigningPolicyForRound(ctx, r.Source, r.System.Addresses.RelayContract, r.System.VoterRegistries, opts.RoundID)
+	if err != nil {
+		return nil, err
+	}
+
+	signingPolicy := policy.NewSigningPolicy(votersData.Policy, votersData.SubmitToSigningAddress)
+	rnd := round.New(opts.RoundID, signingPolicy.Voters)
+
+	if err := r.addRequests(ctx, rnd); err != nil {
+		return nil, err
+	}
+
+	if err := r.addBitVotes(ctx, rnd); err != nil {
+		return nil, err
+	}
+
+	result := &Result{RoundID: opts.RoundID, RewardEpochID: signingPolicy.RewardEpochID}
+	result.BitVotes, result.BitVoteWeight, result.TotalWeight = rnd.BitVoteParticipation()
+
+	// unlike the live node, the replay has no signing deadline, so the computation is bounded only by the operations budget
+	err = rnd.ComputeConsensusBitVote(ctx)
+	result.Explanation, _ = rnd.ConsensusExplanation()
+	if err != nil {
+		result.ConsensusError = err.Error()
+	} else {
+		result.ConsensusBitVote = "0x" + rnd.ConsensusBitVote.EncodeBitVoteHex()
+		result.ConsensusOptimal = rnd.ConsensusOptimal
+	}
+
+	r.resolve(ctx, rnd, opts)
+
+	if err == nil {
+		root, err := rnd.MerkleRoot()
+		if err != nil {
+			result.MerkleRootError = err.Error()
+		} else {
+			result.MerkleRoot = root.Hex()
+		}
+	}
+
+	result.Attestations = make([]AttestationResult, len(rnd.Attestations))
+	for i, att := range rnd.Attestations {
+		result.Attestations[i] = attestationResult(i, att)
+	}
+
+	return result, nil
+}
+
+// addRequests adds the attestation requests emitted in the collect phase of the round.
+func (r *Replayer) addRequests(ctx context.Context, rnd *round.Round) error {
+	params := database.LogsParams{
+		Address: r.System.Addresses.FdcContract,
+		Topic0:  collector.AttestationRequestEventSel,
+		From:    int64(timing.RoundStartTS(rnd.ID)) - 1,
+		To:      int64(timing.ChooseStartTS(rnd.ID)) - 1,
+	}
+
+	logs, err := r.Source.FetchLogsByTimestamp(ctx, params)
+	if err != nil {
+		return fmt.Errorf("fetching requests: %s", err)
+	}
+
+	for i := range logs {
+		att, err := attestation.AttestationFromDatabaseLog(logs[i])
+		if err != nil {
+			logger.Warnf("request in transaction %s: %s", logs[i].TransactionHash, err)
+			continue
+		}
+
+		if att.RoundID != rnd.ID {
+			continue
+		}
+
+		rnd.AddAttestation(att)
+	}
+
+	logger.Infof("replaying round %d with %d attestations from %d requests", rnd.ID, len(rnd.Attestations), len(logs))
+
+	return nil
+}
+
+// addBitVotes adds the bitVotes submitted in the choose phase of the round.
+func (r *Replayer) addBitVotes(ctx context.Context, rnd *round.Round) error {
+	messages, err := collector.FetchBitVotes(ctx, r.Source, r.System.Addresses.SubmitContract, collector.Submit2FuncSel, r.ProtocolID, rnd.ID)
+	if err != nil {
+		return fmt.Errorf("fetching bitVotes: %s", err)
+	}
+
+	for _, message := range messages {
+		if message.VotingRound != rnd.ID {
+			logger.Warnf("bitVote from %s for voting round %d submitted in round %d", message.From, message.VotingRound, rnd.ID)
+			continue
+		}
+
+		if err := rnd.ProcessBitVote(message); err != nil {
+			logger.Warnf("processing bitVote from %s: %s", message.From, err)
+		}
+	}
+
+	return nil
+}
+
+// resolve prepares the attestations and sets their responses from opts.Responses or the verifiers.
+func (r *Replayer) resolve(ctx context.Context, rnd *round.Round, opts Options) {
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, verifyWorkers)
+
+	for _, att := range rnd.Attestations {
+		if err := att.PrepareRequest(r.AttestationType, nil); err != nil {
+			logger.Debugf("preparing request %s: %s", att.Request.TypeAndSourceString(), err)
+			continue
+		}
+
+		if response, ok := opts.Responses[hex.EncodeToString(att.Request)]; ok {
+			if err := att.SetResponse(response); err != nil {
+				logger.Warnf("response for %s: %s", att.Request.TypeAndSourceString(), err)
+			}
+			continue
+		}
+
+		if !opts.Verify {
+			continue
+		}
+
+		wg.Add(1)
+		workers <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-workers }()
+
+			if err := att.Handle(ctx); err != nil {
+				logger.Warnf("verifying %s: %s", att.Request.TypeAndSourceString(), err)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func attestationResult(index int, att *attestation.Attestation) AttestationResult {
+	att.RLock()
+	defer att.RUnlock()
+
+	attType, source := att.Request.TypeAndSource()
+
+	result := AttestationResult{
+		Index:     index,
+		Type:      attType,
+		Source:    source,
+		Fee:       att.Fee.String(),
+		Consensus: att.Consensus,
+		Status:    att.Status.String(),
+		Request:   hex.EncodeToString(att.Request),
+	}
+
+	if att.Status == attestation.Success {
+		result.Hash = att.Hash.Hex()
+	}
+
+	return result
+}
+
+// WriteText writes a summary of the result followed by a table of the attestations.
+func (r *Result) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	consensus := r.ConsensusBitVote
+	if r.ConsensusError != "" {
+		consensus = "error: " + r.ConsensusError
+	} else if r.ConsensusOptimal {
+		consensus += " (optimal)"
+	}
+
+	root := r.MerkleRoot
+	if r.MerkleRootError != "" {
+		root = "error: " + r.MerkleRootError
+	}
+
+	lines := [][2]string{
+		{"round", fmt.Sprintf("%d (reward epoch %d)", r.RoundID, r.RewardEpochID)},
+		{"bitVotes", fmt.Sprintf("%d, weight %d of %d", r.BitVotes, r.BitVoteWeight, r.TotalWeight)},
+		{"consensus bitVote", consensus},
+		{"merkle root", root},
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\n", line[0], line[1]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprintf(tw, "\nINDEX\tTYPE\tSOURCE\tFEE\tCONSENSUS\tSTATUS\tHASH\n"); err != nil {
+		return err
+	}
+
+	for _, att := range r.Attestations {
+		_, err := fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%t\t%s\t%s\n", att.Index, att.Type, att.Source, att.Fee, att.Consensus, att.Status, att.Hash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+// WriteJSON writes the result as JSON.
+func (r *Result) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(r)
+}
@@ -1,17 +1,25 @@
 package round
 
 import (
+	"context"
 	"fmt"
 	"math/big"
+	"slices"
 	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/flare-foundation/go-flare-common/pkg/logger"
 	"github.com/flare-foundation/go-flare-common/pkg/merkle"
 	"github.com/flare-foundation/go-flare-common/pkg/payload"
 	"github.com/flare-foundation/go-flare-common/pkg/voters"
 
 	"github.com/flare-foundation/fdc-client/client/attestation"
 	bitvotes "github.com/flare-foundation/fdc-client/client/attestation/bitVotes"
+	"github.com/flare-foundation/fdc-client/client/config"
+	"github.com/flare-foundation/fdc-client/client/events"
+	"github.com/flare-foundation/fdc-client/client/timing"
 	"github.com/flare-foundation/fdc-client/client/utils"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -19,7 +27,37 @@ import (
 	"github.com/pkg/errors"
 )
 
-const BitVoteMaxNoOfOperations = 20_000_000 // maximal number of operations in the BitVote algorithm
+const consensusAttempts = 3 // maximal number of consensus computations if the attestations of the round change during the computation
+
+var (
+	// ErrAttestationsFixed is returned when attestations of a round can no longer change.
+	ErrAttestationsFixed = errors.New("attestations of the round can no longer change")
+	// ErrConsensusFixed is returned when the consensus bitVote of a round can no longer change, since its Merkle root was already computed.
+	ErrConsensusFixed = errors.New("consensus bitVote of the round can no longer change")
+)
+
+var (
+	maxOperations       atomic.Int64  // maximal number of operations of each strategy of the consensus bitVote computation
+	exactVotesFromRound atomic.Uint32 // first voting round computed with the exact search over votes, 0 if it is not activated
+)
+
+func init() {
+	maxOperations.Store(config.DefaultConsensusMaxOperations)
+}
+
+// SetConsensusProtocol sets the operations budget of the consensus bitVote computation and the voting rounds from which its changes apply.
+// It should be set from the system configuration of the chain before any consensus bitVote is computed.
+func SetConsensusProtocol(protocol config.ConsensusProtocol) {
+	maxOperations.Store(int64(protocol.Operations()))
+	exactVotesFromRound.Store(protocol.ExactVotesFromRound)
+}
+
+// exactVotes returns true if the consensus bitVote of round roundID is computed with the exact search over votes.
+func exactVotes(roundID uint32) bool {
+	from := exactVotesFromRound.Load()
+
+	return from > 0 && roundID >= from
+}
 
 type Round struct {
 	ID                           uint32
@@ -30,8 +68,16 @@ type Round struct {
 	bitVoteCheckList             map[common.Address]*bitvotes.WeightedBitVote
 	ConsensusCalculationFinished bool
 	ConsensusBitVote             bitvotes.BitVote
+	ConsensusOptimal             bool // true if ConsensusBitVote is known to be optimal
+	consensusExplanation         *bitvotes.Explanation
 	voterSet                     *voters.Set
 	merkleTree                   merkle.Tree
+	merkleRootComputed           bool        // set when the Merkle tree is computed for the first time, the consensus bitVote is fixed afterwards
+	snapshot                     *Snapshot   // set when the Merkle tree is computed for the first time
+	Events                       *events.Hub // receives lifecycle events of the round, optional
+
+	consensusMu      sync.Mutex  // serializes consensus computations of the round
+	consensusStarted atomic.Bool // set when the consensus computation of the round is started or the consensus is restored
 
 	sync.RWMutex
 }
@@ -54,6 +100,7 @@ func New(id uint32, voterSet *voters.Set) *Round {
 // AddAttestation checks whether an attestation with such request is already in the round.
 // If not, it is added to the round. If yes, the fee is added to the existent attestation
 // and Index is set to the earlier one.
+// A request from a log that was already added (e.g. fetched twice) is ignored.
 func (r *Round) AddAttestation(attToAdd *attestation.Attestation) bool {
 	r.Lock()
 	defer r.Unlock()
@@ -61,6 +108,10 @@ func (r *Round) AddAttestation(attToAdd *attestation.Attestation) bool {
 	identifier := crypto.Keccak256Hash(attToAdd.Request)
 	att, exists := r.attestationMap[identifier]
 	if exists {
+		if slices.Contains(att.Indexes, attToAdd.Index()) {
+			return false
+		}
+
 		att.Fee.Add(att.Fee, attToAdd.Fee)
 		if attestation.EarlierLog(attToAdd.Index(), att.Index()) {
 			att.Indexes = utils.Prepend(att.Indexes, attToAdd.Index())
@@ -78,6 +129,67 @@ func (r *Round) AddAttestation(attToAdd *attestation.Attestation) bool {
 	return true
 }
 
+// RemoveRequestLog removes the request log of attToRemove, which is no longer on the chain, from the attestation with the same request.
+// The fee of the log is subtracted from the attestation. If the attestation has no logs left, it is marked as reverted and removed from the round.
+// It returns the attestation and whether it was removed.
+//
+// Logs can only be removed before the choose phase of the round starts and before any bitVote is received.
+// Later, bitVotes are checked against the attestations of the round, and a removal would shift the bits of the following attestations,
+// so ErrAttestationsFixed is returned.
+func (r *Round) RemoveRequestLog(attToRemove *attestation.Attestation) (*attestation.Attestation, bool, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.Status.RLock()
+	status := r.Status.Value
+	r.Status.RUnlock()
+
+	switch {
+	case status != attestation.PreConsensus:
+		return nil, false, fmt.Errorf("%w: round %d already reached consensus", ErrAttestationsFixed, r.ID)
+	case len(r.bitVotes) > 0:
+		return nil, false, fmt.Errorf("%w: round %d already has bitVotes", ErrAttestationsFixed, r.ID)
+	case uint64(time.Now().Unix()) >= timing.ChooseStartTS(r.ID):
+		return nil, false, fmt.Errorf("%w: choose phase of round %d started", ErrAttestationsFixed, r.ID)
+	}
+
+	identifier := crypto.Keccak256Hash(attToRemove.Request)
+	att, exists := r.attestationMap[identifier]
+	if !exists {
+		return nil, false, fmt.Errorf("no attestation with the request in round %d", r.ID)
+	}
+
+	att.Lock()
+	defer att.Unlock()
+
+	i := slices.Index(att.Indexes, attToRemove.Index())
+	if i < 0 {
+		return nil, false, fmt.Errorf("no log %v for the request in round %d", attToRemove.Index(), r.ID)
+	}
+
+	att.Fee.Sub(att.Fee, attToRemove.Fee)
+
+	if len(att.Indexes) > 1 {
+		att.Indexes = slices.Delete(att.Indexes, i, i+1)
+		return att, false, nil
+	}
+
+	att.Reverted = true
+	delete(r.attestationMap, identifier)
+	r.Attestations = slices.DeleteFunc(r.Attestations, func(a *attestation.Attestation) bool { return a == att })
+
+	return att, true, nil
+}
+
+// AttestationForRequest returns the attestation in the round with the request.
+func (r *Round) AttestationForRequest(request attestation.Request) (*attestation.Attestation, bool) {
+	r.RLock()
+	defer r.RUnlock()
+
+	att, exists := r.attestationMap[crypto.Keccak256Hash(request)]
+	return att, exists
+}
+
 // sortAttestations sorts round's attestations according to their IndexLog.
 // We assume that attestations have at least one index.
 func (r *Round) sortAttestations() {
@@ -106,29 +218,103 @@ func (r *Round) BitVoteBytes() ([]byte, error) {
 }
 
 // ComputeConsensusBitVote computes the consensus BitVote according to the collected bitVotes and sets consensus status to the attestations.
-func (r *Round) ComputeConsensusBitVote() error {
+// The computation is bounded by the operations budget of the chain and runs the exact search over votes if it is activated for the round. If the deadline of ctx passes first, the computation fails and no consensus bitVote is set.
+//
+// The round is not locked during the computation, so requests and bitVotes can be processed meanwhile.
+// If the attestations of the round change during the computation, the result is discarded and the consensus is computed again.
+func (r *Round) ComputeConsensusBitVote(ctx context.Context) error {
+	r.consensusMu.Lock()
+	defer r.consensusMu.Unlock()
+
+	r.RLock()
+	fixed := r.merkleRootComputed
+	r.RUnlock()
+	if fixed {
+		return fmt.Errorf("round %d: %w", r.ID, ErrConsensusFixed)
+	}
+
+	for range consensusAttempts {
+		attestations, bitVotes, fees, totalWeight := r.consensusInput()
+
+		consensus, explanation, err := bitvotes.EnsembleConsensusBitVoteExplained(ctx, bitVotes, fees, totalWeight, int(maxOperations.Load()), exactVotes(r.ID))
+
+		published, err := r.publishConsensus(attestations, consensus, explanation, err)
+		if published {
+			return err
+		}
+
+		logger.Infof("attestations of round %d changed during the consensus computation, computing again", r.ID)
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	r.ConsensusCalculationFinished = true
+
+	return fmt.Errorf("attestations of round %d changed during %d consensus computations", r.ID, consensusAttempts)
+}
+
+// StartConsensus marks the consensus computation of the round as started.
+// It returns false if the computation was already started or the consensus was restored, so the consensus of a round is computed once.
+func (r *Round) StartConsensus() bool {
+	return r.consensusStarted.CompareAndSwap(false, true)
+}
+
+// consensusInput returns the sorted attestations of the round together with copies of the bitVotes and the fees of the attestations.
+// The bitVotes keep the order of the first submissions of the voters on the chain, which breaks ties between bitVotes with the same value.
+func (r *Round) consensusInput() ([]*attestation.Attestation, []*bitvotes.WeightedBitVote, []*big.Int, uint16) {
 	r.Lock()
 	defer r.Unlock()
 
-	defer func() { r.ConsensusCalculationFinished = true }()
 	r.sortAttestations()
+	attestations := slices.Clone(r.Attestations)
+
+	fees := make([]*big.Int, len(attestations))
+	for i, a := range attestations {
+		fees[i] = new(big.Int).Set(a.Fee)
+	}
+
+	bitVotes := make([]*bitvotes.WeightedBitVote, len(r.bitVotes))
+	for i := range r.bitVotes {
+		bitVote := *r.bitVotes[i]
+		bitVotes[i] = &bitVote
+	}
+
+	return attestations, bitVotes, fees, r.voterSet.TotalWeight
+}
+
+// publishConsensus sets the result of the consensus computation on attestations to the round.
+// If the attestations of the round are no longer the same, nothing is set and false is returned.
+// If the Merkle root of the round was computed in the meantime, nothing is set and ErrConsensusFixed is returned.
+func (r *Round) publishConsensus(attestations []*attestation.Attestation, consensus bitvotes.BitVote, explanation *bitvotes.Explanation, err error) (bool, error) {
+	r.Lock()
+	defer r.Unlock()
 
-	fees := make([]*big.Int, len(r.Attestations))
-	for i, a := range r.Attestations {
-		fees[i] = a.Fee
+	if r.merkleRootComputed {
+		return true, fmt.Errorf("round %d: %w", r.ID, ErrConsensusFixed)
 	}
 
-	consensus, err := bitvotes.EnsembleConsensusBitVote(r.bitVotes, fees, r.voterSet.TotalWeight, BitVoteMaxNoOfOperations)
+	r.sortAttestations()
+	if !slices.Equal(attestations, r.Attestations) {
+		return false, nil
+	}
+
+	defer func() { r.ConsensusCalculationFinished = true }()
+
+	r.consensusExplanation = explanation
 	if err != nil {
-		return err
+		return true, err
 	}
 
 	r.ConsensusBitVote = consensus
+	r.ConsensusOptimal = explanation.Optimal
+
+	r.Events.Publish(events.Event{Type: events.ConsensusComputed, RoundID: r.ID, BitVote: "0x" + consensus.EncodeBitVoteHex()})
 	r.Status.Lock()
 	r.Status.Value = attestation.Consensus
 	r.Status.Unlock()
 
-	return r.setConsensusStatus(consensus)
+	return true, r.setConsensusStatus(consensus)
 }
 
 // GetConsensusBitVote returns triplet:
@@ -136,12 +322,42 @@ func (r *Round) ComputeConsensusBitVote() error {
 //   - bool indicating whether the consensus BitVote is successfully computed
 //   - bool indicating whether the consensus BitVote computation took place
 func (r *Round) GetConsensusBitVote() (bitvotes.BitVote, bool, bool) {
+	r.RLock()
+	defer r.RUnlock()
+
 	if r.ConsensusBitVote.BitVector == nil {
 		return bitvotes.BitVote{}, false, r.ConsensusCalculationFinished
 	}
 	return r.ConsensusBitVote, true, r.ConsensusCalculationFinished
 }
 
+// UnsuccessfulChosen returns the attestations chosen by the consensus bitVote that do not have a successful response.
+func (r *Round) UnsuccessfulChosen() []*attestation.Attestation {
+	r.RLock()
+	defer r.RUnlock()
+
+	var unsuccessful []*attestation.Attestation
+	for _, at := range r.Attestations {
+		at.RLock()
+		if at.Consensus && at.Status != attestation.Success {
+			unsuccessful = append(unsuccessful, at)
+		}
+		at.RUnlock()
+	}
+
+	return unsuccessful
+}
+
+// ConsensusExplanation returns the explanation of the consensus bitVote and true if the consensus bitVote computation took place.
+// The explanation of a failed computation describes only the filtering of bits and votes.
+// Rounds with consensus restored from the round store have no explanation.
+func (r *Round) ConsensusExplanation() (*bitvotes.Explanation, bool) {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.consensusExplanation, r.consensusExplanation != nil
+}
+
 // setConsensusStatus sets consensus status of the attestations.
 //
 // The scenario where a chosen attestation is missing is not possible as in such case, it is not possible to compute the consensus bitVote.
@@ -158,64 +374,130 @@ func (r *Round) setConsensusStatus(consensusBitVote bitvotes.BitVote) error {
 		r.Attestations[i].Unlock()
 	}
 
+	// the cached tree was built from the previously chosen attestations
+	r.merkleTree = nil
+
 	return nil
 }
 
-// MerkleTree computes Merkle tree from sorted hashes of attestations chosen by the consensus bitVote.
-// The computed tree is stored in the round.
-// If any of the hash of the chosen attestations is not successfully verified, the tree is not computed.
-func (r *Round) MerkleTree() (merkle.Tree, error) {
+// RestoreConsensus sets a previously computed consensus BitVote and consensus status to the attestations.
+func (r *Round) RestoreConsensus(consensus bitvotes.BitVote) error {
 	r.Lock()
 	defer r.Unlock()
 
+	if r.merkleRootComputed {
+		return fmt.Errorf("round %d: %w", r.ID, ErrConsensusFixed)
+	}
+
+	r.consensusStarted.Store(true)
+	r.ConsensusCalculationFinished = true
+	r.sortAttestations()
+
+	r.ConsensusBitVote = consensus
+	r.Status.Lock()
+	r.Status.Value = attestation.Consensus
+	r.Status.Unlock()
+
+	return r.setConsensusStatus(consensus)
+}
+
+// computeMerkleTree computes Merkle tree from sorted hashes of attestations chosen by the consensus bitVote and stores it in the round.
+// When the tree is computed for the first time, the snapshot of the round is built and the consensus bitVote of the round can no longer change,
+// so the root served by DA endpoints is the signed one. The returned bool is true if the tree of the round was computed for the first time.
+// If any of the hash of the chosen attestations is not successfully verified, the tree is not computed. The round must be locked.
+func (r *Round) computeMerkleTree() (merkle.Tree, bool, error) {
 	var hashes []common.Hash
+	var chosen []*attestation.Attestation
 	for i := range r.Attestations {
 		r.Attestations[i].RLock()
 		defer r.Attestations[i].RUnlock()
 
 		if r.Attestations[i].Consensus {
 			if r.Attestations[i].Status != attestation.Success {
-				return merkle.Tree{}, errors.Errorf("attestation %s, at index %d in consensus but not confirmed", r.Attestations[i].Request.TypeAndSourceString(), i)
+				return merkle.Tree{}, false, errors.Errorf("attestation %s, at index %d in consensus but not confirmed", r.Attestations[i].Request.TypeAndSourceString(), i)
 			}
 
 			hashes = append(hashes, r.Attestations[i].Hash)
+			chosen = append(chosen, r.Attestations[i])
 		}
 	}
 
 	merkleTree := merkle.Build(hashes, false)
 	r.merkleTree = merkleTree
+
+	if r.snapshot == nil {
+		snapshot, err := newSnapshot(r.ID, merkleTree, chosen)
+		if err != nil {
+			logger.Errorf("snapshot of round %d: %s", r.ID, err)
+		}
+		r.snapshot = snapshot
+
+		if snapshot != nil {
+			r.Events.Publish(events.Event{Type: events.MerkleRootFinalised, RoundID: r.ID, MerkleRoot: snapshot.Root.Hex()})
+		}
+	}
+
 	r.Status.Lock()
 	r.Status.Value = attestation.Done
 	r.Status.Unlock()
 
-	return merkleTree, nil
+	first := !r.merkleRootComputed
+	r.merkleRootComputed = true
+
+	return merkleTree, first, nil
 }
 
-// MerkleTreeCached gets Merkle tree from cache if it is already computed or computes it.
-func (r *Round) MerkleTreeCached() (merkle.Tree, error) {
+// Snapshot returns the immutable snapshot of the round and true if the Merkle root of the round was already finalised.
+// Unlike MerkleRoot, it never computes the tree or changes the state of the round.
+func (r *Round) Snapshot() (*Snapshot, bool) {
 	r.RLock()
-	if len(r.merkleTree) != 0 {
-		return r.merkleTree, nil
-	}
-	r.RUnlock()
+	defer r.RUnlock()
+
+	return r.snapshot, r.snapshot != nil
+}
+
+// HasMerkleTree returns true if the Merkle tree of the round is already computed.
+func (r *Round) HasMerkleTree() bool {
+	r.RLock()
+	defer r.RUnlock()
 
-	return r.MerkleTree()
+	return len(r.merkleTree) != 0
 }
 
 // MerkleRoot returns Merkle root for a round if it is possible to compute it.
 func (r *Round) MerkleRoot() (common.Hash, error) {
-	tree, err := r.MerkleTreeCached()
-	if err != nil {
-		return common.Hash{}, err
+	root, _, err := r.MerkleRootFirst()
+
+	return root, err
+}
+
+// MerkleRootFirst returns Merkle root for a round if it is possible to compute it.
+// The returned bool is true only for the call that computed the Merkle root of the round for the first time.
+func (r *Round) MerkleRootFirst() (common.Hash, bool, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	tree, first := r.merkleTree, false
+	if len(tree) == 0 {
+		var err error
+		tree, first, err = r.computeMerkleTree()
+		if err != nil {
+			return common.Hash{}, false, err
+		}
 	}
 
-	return tree.Root()
+	root, err := tree.Root()
+
+	return root, first, err
 }
 
 // ProcessBitVote decodes bitVote message, checks roundCheck, adds voter weight and index, and stores bitVote to the round.
 // If the voter is invalid, or has zero weight, the bitVote is ignored.
 // If a voter already submitted a valid bitVote for the round, the bitVote is overwritten.
 func (r *Round) ProcessBitVote(message payload.Message) error {
+	r.Lock()
+	defer r.Unlock()
+
 	bitVote, err := bitvotes.DecodeBitVoteBytes(message.Payload)
 	if err != nil {
 		return err
@@ -272,3 +554,59 @@ func (r *Round) ProcessBitVote(message payload.Message) error {
 
 	return nil
 }
+
+// BitVoteParticipation returns the number and the total weight of voters that submitted a valid bitVote,
+// and the total weight of all voters of the round.
+func (r *Round) BitVoteParticipation() (int, uint16, uint16) {
+	r.RLock()
+	defer r.RUnlock()
+
+	var weight uint16
+	for i := range r.bitVotes {
+		weight += r.bitVotes[i].Weight
+	}
+
+	return len(r.bitVotes), weight, r.voterSet.TotalWeight
+}
+
+// SubmittedBitVotes returns copies of valid bitVotes by submit addresses of their senders.
+func (r *Round) SubmittedBitVotes() map[common.Address]bitvotes.WeightedBitVote {
+	r.RLock()
+	defer r.RUnlock()
+
+	submitted := make(map[common.Address]bitvotes.WeightedBitVote, len(r.bitVoteCheckList))
+	for address, weightedBitVote := range r.bitVoteCheckList {
+		submitted[address] = *weightedBitVote
+	}
+
+	return submitted
+}
+
+// SubmittedBitVote returns a copy of the valid bitVote submitted by submitAddress.
+func (r *Round) SubmittedBitVote(submitAddress common.Address) (bitvotes.WeightedBitVote, bool) {
+	r.RLock()
+	defer r.RUnlock()
+
+	weightedBitVote, exists := r.bitVoteCheckList[submitAddress]
+	if !exists {
+		return bitvotes.WeightedBitVote{}, false
+	}
+	return *weightedBitVote, true
+}
+
+// RestoreBitVote adds a previously processed bitVote submitted by submitAddress to the round.
+// An existing bitVote of the sender is overwritten.
+func (r *Round) RestoreBitVote(submitAddress common.Address, bitVote bitvotes.WeightedBitVote) {
+	r.Lock()
+	defer r.Unlock()
+
+	weightedBitVote, exists := r.bitVoteCheckList[submitAddress]
+	if exists {
+		*weightedBitVote = bitVote
+		return
+	}
+
+	weightedBitVote = &bitVote
+	r.bitVotes = append(r.bitVotes, weightedBitVote)
+	r.bitVoteCheckList[submitAddress] = weightedBitVote
+}
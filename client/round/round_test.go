@@ -1,18 +1,26 @@
 package round_test
 
 import (
+	"context"
 	"fmt"
 	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/flare-foundation/go-flare-common/pkg/database"
+	"github.com/flare-foundation/go-flare-common/pkg/merkle"
 	"github.com/flare-foundation/go-flare-common/pkg/voters"
 
 	"github.com/flare-foundation/fdc-client/client/attestation"
+	bitvotes "github.com/flare-foundation/fdc-client/client/attestation/bitVotes"
 	"github.com/flare-foundation/fdc-client/client/round"
+	"github.com/flare-foundation/fdc-client/client/timing"
 	"github.com/flare-foundation/fdc-client/client/utils"
 
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/require"
 )
 
@@ -128,6 +136,38 @@ func TestAddAttestation(t *testing.T) {
 			added:            []bool{true, true, false},
 			nuOfAttestations: 2,
 		},
+		{
+			// the same log fetched twice
+			requests: []database.Log{
+				{
+					Address:         "Cf6798810Bc8C0B803121405Fee2A5a9cc0CA5E5",
+					Data:            "0000000000000000000000000000000000000000000000000000000000000040000000000000000000000000000000000000000000000000000000000000000a000000000000000000000000000000000000000000000000000000000000014045564d5472616e73616374696f6e00000000000000000000000000000000000045544800000000000000000000000000000000000000000000000000000000005453e040c1d33d8852f82714b28959380834b66988fa0348efe38625b3320b4500000000000000000000000000000000000000000000000000000000000000204ff8da95da542ca5e013daf405d08871fdb4375ee6dec77f001e918c8cd8d1b800000000000000000000000000000000000000000000000000000000000000050000000000000000000000000000000000000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000a00000000000000000000000000000000000000000000000000000000000000000",
+					Topic0:          "251377668af6553101c9bb094ba89c0c536783e005e203625e6cd57345918cc9",
+					Topic1:          "NULL",
+					Topic2:          "NULL",
+					Topic3:          "NULL",
+					TransactionHash: "e995790cdbb02e851cd767ee4f36bdf4d172b6fc210a497a505ec9c73330f5d1",
+					LogIndex:        0,
+					Timestamp:       1718113234,
+					BlockNumber:     16497501,
+				},
+				{
+					Address:         "Cf6798810Bc8C0B803121405Fee2A5a9cc0CA5E5",
+					Data:            "0000000000000000000000000000000000000000000000000000000000000040000000000000000000000000000000000000000000000000000000000000000a000000000000000000000000000000000000000000000000000000000000014045564d5472616e73616374696f6e00000000000000000000000000000000000045544800000000000000000000000000000000000000000000000000000000005453e040c1d33d8852f82714b28959380834b66988fa0348efe38625b3320b4500000000000000000000000000000000000000000000000000000000000000204ff8da95da542ca5e013daf405d08871fdb4375ee6dec77f001e918c8cd8d1b800000000000000000000000000000000000000000000000000000000000000050000000000000000000000000000000000000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000a00000000000000000000000000000000000000000000000000000000000000000",
+					Topic0:          "251377668af6553101c9bb094ba89c0c536783e005e203625e6cd57345918cc9",
+					Topic1:          "NULL",
+					Topic2:          "NULL",
+					Topic3:          "NULL",
+					TransactionHash: "e995790cdbb02e851cd767ee4f36bdf4d172b6fc210a497a505ec9c73330f5d1",
+					LogIndex:        0,
+					Timestamp:       1718113234,
+					BlockNumber:     16497501,
+				},
+			},
+			fees:             []*big.Int{big.NewInt(10)},
+			added:            []bool{true, false},
+			nuOfAttestations: 1,
+		},
 	}
 
 	for i, test := range tests {
@@ -148,6 +188,99 @@ func TestAddAttestation(t *testing.T) {
 	}
 }
 
+func TestRemoveRequestLog(t *testing.T) {
+	request := database.Log{
+		Address:         "Cf6798810Bc8C0B803121405Fee2A5a9cc0CA5E5",
+		Data:            "0000000000000000000000000000000000000000000000000000000000000040000000000000000000000000000000000000000000000000000000000000000a000000000000000000000000000000000000000000000000000000000000014045564d5472616e73616374696f6e00000000000000000000000000000000000045544800000000000000000000000000000000000000000000000000000000005453e040c1d33d8852f82714b28959380834b66988fa0348efe38625b3320b4500000000000000000000000000000000000000000000000000000000000000204ff8da95da542ca5e013daf405d08871fdb4375ee6dec77f001e918c8cd8d1b800000000000000000000000000000000000000000000000000000000000000050000000000000000000000000000000000000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000a00000000000000000000000000000000000000000000000000000000000000000",
+		Topic0:          "251377668af6553101c9bb094ba89c0c536783e005e203625e6cd57345918cc9",
+		Topic1:          "NULL",
+		Topic2:          "NULL",
+		Topic3:          "NULL",
+		TransactionHash: "e995790cdbb02e851cd767ee4f36bdf4d172b6fc210a497a505ec9c73330f5d1",
+		LogIndex:        0,
+		Timestamp:       1718113234,
+		BlockNumber:     16497501,
+	}
+	duplicate := request
+	duplicate.LogIndex = 1
+
+	roundID, err := timing.RoundIDForTS(uint64(time.Now().Unix()))
+	require.NoError(t, err)
+
+	voter := common.HexToAddress("0x1")
+	newRound := func(id uint32) *round.Round {
+		return round.New(id, voters.NewSet([]common.Address{voter}, []uint16{1}, nil))
+	}
+
+	r := newRound(roundID)
+
+	for _, log := range []database.Log{request, duplicate} {
+		att, err := attestation.AttestationFromDatabaseLog(log)
+		require.NoError(t, err)
+		r.AddAttestation(att)
+	}
+	require.Len(t, r.Attestations, 1)
+
+	reverted, err := attestation.AttestationFromDatabaseLog(request)
+	require.NoError(t, err)
+
+	att, removed, err := r.RemoveRequestLog(reverted)
+	require.NoError(t, err)
+	require.False(t, removed)
+	require.Equal(t, []attestation.IndexLog{{BlockNumber: 16497501, LogIndex: 1}}, att.Indexes)
+	require.Equal(t, big.NewInt(10), att.Fee)
+	require.Len(t, r.Attestations, 1)
+
+	// the same log cannot be removed twice
+	_, _, err = r.RemoveRequestLog(reverted)
+	require.Error(t, err)
+
+	reverted, err = attestation.AttestationFromDatabaseLog(duplicate)
+	require.NoError(t, err)
+
+	att, removed, err = r.RemoveRequestLog(reverted)
+	require.NoError(t, err)
+	require.True(t, removed)
+	require.True(t, att.Reverted)
+	require.Empty(t, r.Attestations)
+
+	_, exists := r.AttestationForRequest(att.Request)
+	require.False(t, exists)
+
+	// logs cannot be removed once bitVotes are checked against the attestations of the round
+	tests := []struct {
+		name  string
+		round func() *round.Round
+	}{
+		{"consensus", func() *round.Round {
+			r := newRound(roundID)
+			r.Status.Value = attestation.Consensus
+			return r
+		}},
+		{"bitVote received", func() *round.Round {
+			r := newRound(roundID)
+			r.RestoreBitVote(voter, bitvotes.WeightedBitVote{Weight: 1, BitVote: bitvotes.BitVote{Length: 1, BitVector: big.NewInt(1)}})
+			return r
+		}},
+		{"choose phase started", func() *round.Round { return newRound(roundID - 1) }},
+	}
+
+	for _, test := range tests {
+		r := test.round()
+
+		readded, err := attestation.AttestationFromDatabaseLog(request)
+		require.NoError(t, err)
+		require.True(t, r.AddAttestation(readded))
+
+		reverted, err := attestation.AttestationFromDatabaseLog(request)
+		require.NoError(t, err)
+
+		_, _, err = r.RemoveRequestLog(reverted)
+		require.ErrorIs(t, err, round.ErrAttestationsFixed, test.name)
+		require.Len(t, r.Attestations, 1, test.name)
+	}
+}
+
 func TestPrepend(t *testing.T) {
 	tests := []struct {
 		added    []int
@@ -171,3 +304,247 @@ func TestPrepend(t *testing.T) {
 		require.Equal(t, test.expected, array, fmt.Sprintf("error in test %d", i))
 	}
 }
+
+func TestSnapshot(t *testing.T) {
+	r := round.New(1, voters.NewSet(nil, nil, nil))
+
+	_, ok := r.Snapshot()
+	require.False(t, ok)
+
+	for i := range 3 {
+		att := &attestation.Attestation{
+			Indexes:   []attestation.IndexLog{{BlockNumber: uint64(i)}},
+			Request:   []byte{byte(i)},
+			Response:  []byte{byte(i), 1},
+			Fee:       big.NewInt(0),
+			Consensus: i != 1,
+			Status:    attestation.Success,
+			Hash:      common.BigToHash(big.NewInt(int64(i + 1))),
+		}
+		require.True(t, r.AddAttestation(att))
+	}
+
+	root, err := r.MerkleRoot()
+	require.NoError(t, err)
+
+	snapshot, ok := r.Snapshot()
+	require.True(t, ok)
+	require.Equal(t, root, snapshot.Root)
+	require.Len(t, snapshot.Leaves, 2)
+
+	leaf, ok := snapshot.LeafForRequest([]byte{2})
+	require.True(t, ok)
+	require.Equal(t, common.BigToHash(big.NewInt(3)), leaf.Hash)
+	require.True(t, merkle.VerifyProof(leaf.Hash, leaf.Proof, root))
+
+	_, ok = snapshot.LeafForRequest([]byte{1}) // not chosen
+	require.False(t, ok)
+
+	_, ok = snapshot.LeafForHash(common.BigToHash(big.NewInt(2)))
+	require.False(t, ok)
+
+	// the snapshot is not affected by later changes of the round
+	r.Attestations[0].Response[0] = 9
+	_, err = r.MerkleRoot()
+	require.NoError(t, err)
+
+	again, _ := r.Snapshot()
+	require.Same(t, snapshot, again)
+	require.Equal(t, byte(0), snapshot.Leaves[0].Response[0])
+}
+
+func TestMerkleRootFirst(t *testing.T) {
+	r := round.New(1, voters.NewSet(nil, nil, nil))
+	require.True(t, r.AddAttestation(&attestation.Attestation{
+		Request:   []byte{1},
+		Response:  []byte{1, 1},
+		Fee:       big.NewInt(0),
+		Consensus: true,
+		Status:    attestation.Success,
+		Hash:      common.BigToHash(big.NewInt(1)),
+	}))
+
+	// only one of the concurrent calls computes the root first
+	var firsts atomic.Int32
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, first, err := r.MerkleRootFirst()
+			require.NoError(t, err)
+			if first {
+				firsts.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, int32(1), firsts.Load())
+
+	// a later call does not compute the root again
+	_, first, err := r.MerkleRootFirst()
+	require.NoError(t, err)
+	require.False(t, first)
+}
+
+func TestConsensusFixedAfterMerkleRoot(t *testing.T) {
+	voter := common.HexToAddress("0x1")
+	r := round.New(1, voters.NewSet([]common.Address{voter}, []uint16{1}, nil))
+
+	for i := range 2 {
+		r.AddAttestation(&attestation.Attestation{
+			Indexes:  []attestation.IndexLog{{BlockNumber: uint64(i)}},
+			Request:  []byte{byte(i)},
+			Response: []byte{byte(i), 1},
+			Fee:      big.NewInt(1),
+			Status:   attestation.Success,
+			Hash:     common.BigToHash(big.NewInt(int64(i + 1))),
+		})
+	}
+
+	vote := func(bitVector int64) {
+		r.RestoreBitVote(voter, bitvotes.WeightedBitVote{Index: 0, Weight: 1, BitVote: bitvotes.BitVote{Length: 2, BitVector: big.NewInt(bitVector)}})
+	}
+
+	// the consensus can be computed again before the Merkle root is computed
+	vote(0b11)
+	require.NoError(t, r.ComputeConsensusBitVote(context.Background()))
+	vote(0b01)
+	require.NoError(t, r.ComputeConsensusBitVote(context.Background()))
+
+	root, err := r.MerkleRoot()
+	require.NoError(t, err)
+	snapshot, ok := r.Snapshot()
+	require.True(t, ok)
+	require.Equal(t, root, snapshot.Root)
+	require.Len(t, snapshot.Leaves, 1)
+
+	// afterwards, the consensus bitVote and the signed root can no longer change
+	vote(0b11)
+	require.ErrorIs(t, r.ComputeConsensusBitVote(context.Background()), round.ErrConsensusFixed)
+	require.ErrorIs(t, r.RestoreConsensus(bitvotes.BitVote{Length: 2, BitVector: big.NewInt(0b11)}), round.ErrConsensusFixed)
+
+	consensus, _, _ := r.GetConsensusBitVote()
+	require.Equal(t, big.NewInt(0b01), consensus.BitVector)
+
+	again, err := r.MerkleRoot()
+	require.NoError(t, err)
+	require.Equal(t, root, again)
+
+	served, _ := r.Snapshot()
+	require.Same(t, snapshot, served)
+}
+
+func TestStartConsensus(t *testing.T) {
+	r := round.New(1, voters.NewSet(nil, nil, nil))
+	require.True(t, r.StartConsensus())
+	require.False(t, r.StartConsensus())
+
+	// the consensus of a restored round is not computed again
+	restored := round.New(2, voters.NewSet(nil, nil, nil))
+	require.NoError(t, restored.RestoreConsensus(bitvotes.BitVote{BitVector: big.NewInt(0)}))
+	require.False(t, restored.StartConsensus())
+}
+
+func TestComputeConsensusBitVote(t *testing.T) {
+	voter0, voter1 := common.HexToAddress("0x1"), common.HexToAddress("0x2")
+
+	newRound := func() *round.Round {
+		r := round.New(1, voters.NewSet([]common.Address{voter0, voter1}, []uint16{1, 1}, nil))
+		for i := range 2 {
+			r.AddAttestation(&attestation.Attestation{
+				Indexes: []attestation.IndexLog{{BlockNumber: uint64(i)}},
+				Request: []byte{byte(i)},
+				Fee:     big.NewInt(1),
+			})
+		}
+
+		bitVote := bitvotes.BitVote{Length: 2, BitVector: big.NewInt(0b01)}
+		r.RestoreBitVote(voter0, bitvotes.WeightedBitVote{Index: 0, Weight: 1, BitVote: bitVote})
+		r.RestoreBitVote(voter1, bitvotes.WeightedBitVote{Index: 1, Weight: 1, BitVote: bitVote})
+
+		return r
+	}
+
+	t.Run("concurrent reads", func(t *testing.T) {
+		r := newRound()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for range 100 {
+				r.BitVoteParticipation()
+				r.GetConsensusBitVote()
+				r.AddAttestation(&attestation.Attestation{Indexes: []attestation.IndexLog{{BlockNumber: 0}}, Request: []byte{0}, Fee: big.NewInt(1)})
+			}
+		}()
+
+		require.NoError(t, r.ComputeConsensusBitVote(context.Background()))
+		<-done
+
+		consensus, exists, computed := r.GetConsensusBitVote()
+		require.True(t, exists)
+		require.True(t, computed)
+		require.Equal(t, int64(0b01), consensus.BitVector.Int64())
+		require.True(t, r.Attestations[0].Consensus)
+		require.False(t, r.Attestations[1].Consensus)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		r := newRound()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		require.ErrorIs(t, r.ComputeConsensusBitVote(ctx), context.Canceled)
+
+		_, exists, computed := r.GetConsensusBitVote()
+		require.False(t, exists)
+		require.True(t, computed)
+	})
+
+	t.Run("unsuccessful chosen", func(t *testing.T) {
+		r := newRound()
+		require.NoError(t, r.ComputeConsensusBitVote(context.Background()))
+
+		require.Equal(t, []*attestation.Attestation{r.Attestations[0]}, r.UnsuccessfulChosen())
+
+		r.Attestations[0].Status = attestation.Success
+		require.Empty(t, r.UnsuccessfulChosen())
+	})
+
+	t.Run("order of bitVotes", func(t *testing.T) {
+		// majorities with the same value, so the consensus depends on the tie-break
+		addresses := []common.Address{voter0, voter1, common.HexToAddress("0x3"), common.HexToAddress("0x4")}
+		weights := []uint16{3, 4, 4, 4}
+		vectors := []int64{0b01100, 0b11000, 0b00110, 0b11111}
+		fees := []int64{2, 1, 1, 1, 1}
+
+		consensus := func(order []int) bitvotes.BitVote {
+			r := round.New(1, voters.NewSet(addresses, weights, nil))
+			for i := range fees {
+				r.AddAttestation(&attestation.Attestation{
+					Indexes: []attestation.IndexLog{{BlockNumber: uint64(i)}},
+					Request: []byte{byte(i)},
+					Fee:     big.NewInt(fees[i]),
+				})
+			}
+
+			for _, i := range order {
+				bitVote := bitvotes.BitVote{Length: uint16(len(fees)), BitVector: big.NewInt(vectors[i])}
+				r.RestoreBitVote(addresses[i], bitvotes.WeightedBitVote{Index: i, Weight: weights[i], BitVote: bitVote})
+			}
+
+			require.NoError(t, r.ComputeConsensusBitVote(context.Background()))
+			consensus, _, _ := r.GetConsensusBitVote()
+
+			return consensus
+		}
+
+		// ties are broken by the order of the first submissions, as in the test vector "tie broken by the order of bitVotes"
+		require.Equal(t, int64(0b11000), consensus([]int{0, 1, 2, 3}).BitVector.Int64())
+		require.Equal(t, int64(0b00110), consensus([]int{3, 2, 1, 0}).BitVector.Int64())
+	})
+}
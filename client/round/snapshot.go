@@ -0,0 +1,96 @@
+package round
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/flare-foundation/go-flare-common/pkg/merkle"
+
+	"github.com/flare-foundation/fdc-client/client/attestation"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Leaf is a confirmed attestation chosen by the consensus bitVote together with its Merkle proof.
+type Leaf struct {
+	Request     attestation.Request
+	Response    attestation.Response
+	ResponseABI string
+	Hash        common.Hash
+	Proof       []common.Hash
+}
+
+// Snapshot is an immutable view of a round with a finalised Merkle root.
+// It is built when the Merkle tree of the round is computed with a new root and is safe for concurrent reads.
+type Snapshot struct {
+	RoundID uint32
+	Root    common.Hash
+	Tree    merkle.Tree
+	Leaves  []Leaf // in the order of the attestations in the round
+
+	byHash    map[common.Hash]int // leaf index by response hash
+	byRequest map[common.Hash]int // leaf index by keccak hash of the request
+}
+
+// newSnapshot builds a snapshot from the Merkle tree and the attestations chosen by the consensus bitVote.
+// The attestations must be confirmed and read locked.
+func newSnapshot(roundID uint32, tree merkle.Tree, chosen []*attestation.Attestation) (*Snapshot, error) {
+	var root common.Hash // zero for a round without chosen attestations
+	if len(tree) > 0 {
+		root = tree[0]
+	}
+
+	snapshot := &Snapshot{
+		RoundID:   roundID,
+		Root:      root,
+		Tree:      slices.Clone(tree),
+		Leaves:    make([]Leaf, len(chosen)),
+		byHash:    make(map[common.Hash]int, len(chosen)),
+		byRequest: make(map[common.Hash]int, len(chosen)),
+	}
+
+	for i, att := range chosen {
+		proof, err := tree.GetProofFromHash(att.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("proof of attestation %d: %s", i, err)
+		}
+
+		var responseABI string
+		if att.ResponseABIString != nil {
+			responseABI = *att.ResponseABIString
+		}
+
+		snapshot.Leaves[i] = Leaf{
+			Request:     slices.Clone(att.Request),
+			Response:    slices.Clone(att.Response),
+			ResponseABI: responseABI,
+			Hash:        att.Hash,
+			Proof:       proof,
+		}
+		snapshot.byHash[att.Hash] = i
+		snapshot.byRequest[crypto.Keccak256Hash(att.Request)] = i
+	}
+
+	return snapshot, nil
+}
+
+// LeafForHash returns the leaf with the response hash.
+func (s *Snapshot) LeafForHash(hash common.Hash) (Leaf, bool) {
+	i, ok := s.byHash[hash]
+	if !ok {
+		return Leaf{}, false
+	}
+
+	return s.Leaves[i], true
+}
+
+// LeafForRequest returns the leaf with the request.
+func (s *Snapshot) LeafForRequest(request attestation.Request) (Leaf, bool) {
+	i, ok := s.byRequest[crypto.Keccak256Hash(request)]
+	if !ok {
+		return Leaf{}, false
+	}
+
+	return s.Leaves[i], true
+}
@@ -1,7 +1,11 @@
 package shared
 
 import (
+	"github.com/flare-foundation/fdc-client/client/attestation"
+	"github.com/flare-foundation/fdc-client/client/events"
+	"github.com/flare-foundation/fdc-client/client/health"
 	"github.com/flare-foundation/fdc-client/client/round"
+	"github.com/flare-foundation/fdc-client/client/shutdown"
 	"github.com/flare-foundation/go-flare-common/pkg/contracts/relay"
 	"github.com/flare-foundation/go-flare-common/pkg/database"
 	"github.com/flare-foundation/go-flare-common/pkg/payload"
@@ -24,13 +28,20 @@ type VotersData struct {
 
 // DataPipes are connection between components of the client.
 //
-//   - Rounds are shared between manager and server
+//   - Rounds, Breakers and Events are shared between manager and server
+//   - Shutdown is shared between server and the shutdown procedure
+//   - Health is shared between collector and manager (register checks) and server (reports readiness)
 //   - Channels are shared between collector (send to) and manager (receive from)
 type DataPipes struct {
 	Rounds   storage.Cyclic[uint32, *round.Round] // cyclically cached rounds with buffer roundBuffer.
 	Requests chan []database.Log
+	Reverted chan []database.Log // requests that are no longer on the chain
 	BitVotes chan payload.Round
 	Voters   chan []VotersData
+	Breakers *attestation.Breakers // circuit breakers of the verifiers
+	Events   *events.Hub           // round lifecycle events
+	Shutdown *shutdown.State       // fetched Merkle roots and draining state
+	Health   *health.Registry      // readiness checks
 }
 
 // NewDataPipes created new DataPipes.
@@ -40,5 +51,10 @@ func NewDataPipes() *DataPipes {
 		Voters:   make(chan []VotersData, signingPolicyBufferSize),
 		BitVotes: make(chan payload.Round, bitVoteBufferSize),
 		Requests: make(chan []database.Log, requestsBufferSize),
+		Reverted: make(chan []database.Log, requestsBufferSize),
+		Breakers: attestation.NewBreakers(),
+		Events:   events.NewHub(),
+		Shutdown: shutdown.New(),
+		Health:   health.NewRegistry(),
 	}
 }
@@ -0,0 +1,151 @@
+// Package shutdown coordinates the graceful shutdown of the client.
+//
+// On shutdown, the client stops being ready, keeps collecting attestation requests until the requests of the collect phase of the current round are collected,
+// and then keeps verifying attestations until the FSP client fetches the Merkle root of the round or the deadline passes.
+//
+// The verifier queues are not stopped before the Merkle root is fetched. They hold the attestations of the round,
+// and chosen attestations without a response are retried through them after the consensus, so the root cannot be computed without them.
+// They are stopped with the rest of the client once Drain returns.
+package shutdown
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/flare-foundation/go-flare-common/pkg/logger"
+
+	"github.com/flare-foundation/fdc-client/client/timing"
+)
+
+const (
+	// DefaultMargin is waited after the end of the choose phase of the current round if no timeout is set.
+	// The FSP client fetches the Merkle root when the choose phase ends, so the wait is at most a collect phase, a choose phase and the margin.
+	DefaultMargin = 15 * time.Second
+	logInterval   = 10 * time.Second
+)
+
+// State records the Merkle roots fetched by the FSP client and whether the client is shutting down.
+// It is shared between the server and the shutdown procedure.
+type State struct {
+	draining atomic.Bool
+
+	mu      sync.Mutex
+	fetched bool
+	latest  uint32        // latest round whose Merkle root was fetched
+	notify  chan struct{} // closed and replaced when a Merkle root of a later round is fetched
+}
+
+func New() *State {
+	return &State{notify: make(chan struct{})}
+}
+
+// Draining returns true if the shutdown has started.
+func (s *State) Draining() bool {
+	return s.draining.Load()
+}
+
+// RootFetched records that the FSP client received the final response of submitSignatures for roundID.
+func (s *State) RootFetched(roundID uint32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.fetched && roundID <= s.latest {
+		return
+	}
+
+	s.fetched = true
+	s.latest = roundID
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+// rootFetched returns true if the Merkle root of roundID or a later round was fetched.
+// Otherwise, it returns a channel that is closed on the next fetch.
+func (s *State) rootFetched(roundID uint32) (bool, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.fetched && s.latest >= roundID {
+		return true, nil
+	}
+
+	return false, s.notify
+}
+
+// Requests is the collection of attestation requests, which is stopped first on shutdown.
+type Requests interface {
+	// StopRequestsAfter stops the collection once blocks with timestamp at least ts are queried. The returned channel is closed when the collection stops.
+	StopRequestsAfter(ts uint64) <-chan struct{}
+	// StopRequests stops the collection immediately.
+	StopRequests()
+}
+
+// Drain marks the client as draining and blocks until the Merkle root of the current round is fetched,
+// timeout passes or ctx is done. If timeout is 0, Drain waits until DefaultMargin after the end of the choose phase of the current round.
+// The remaining time is logged while waiting.
+//
+// The collection of requests is stopped once the requests emitted in the collect phase of the current round are collected,
+// which can be after the end of the phase if the indexer lags behind. It is stopped immediately when Drain returns.
+// Until Drain returns, attestations already in queues are verified, so that the Merkle root can be computed.
+func (s *State) Drain(ctx context.Context, timeout time.Duration, requests Requests) {
+	s.draining.Store(true)
+	defer requests.StopRequests()
+
+	roundID, err := timing.RoundIDForTS(uint64(time.Now().Unix()))
+	if err != nil {
+		logger.Warnf("shutdown: %v, not waiting for the current round", err)
+		return
+	}
+
+	deadline := time.Now().Add(timeout)
+	if timeout == 0 {
+		deadline = time.Unix(int64(timing.ChooseEndTS(roundID)), 0).Add(DefaultMargin)
+	}
+
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	logger.Infof("Shutting down after the Merkle root of round %d is fetched, at the latest in %v", roundID, time.Until(deadline).Round(time.Second))
+
+	collected := requests.StopRequestsAfter(timing.ChooseStartTS(roundID))
+
+	if !wait(ctx, collected, deadline, "the requests of the collect phase of round", roundID) {
+		logger.Warnf("Stopped waiting for the requests of round %d: %v", roundID, ctx.Err())
+		return
+	}
+
+	logger.Infof("Requests of round %d collected, attestation requests are no longer collected", roundID)
+
+	for {
+		fetched, next := s.rootFetched(roundID)
+		if fetched {
+			logger.Infof("Merkle root of round %d fetched", roundID)
+			return
+		}
+
+		if !wait(ctx, next, deadline, "the Merkle root of round", roundID) {
+			logger.Warnf("Stopped waiting for the Merkle root of round %d: %v", roundID, ctx.Err())
+			return
+		}
+	}
+}
+
+// wait blocks until done receives or is closed and returns true. If ctx is done first, false is returned.
+// The time remaining until the deadline is logged every logInterval.
+func wait[T any](ctx context.Context, done <-chan T, deadline time.Time, what string, roundID uint32) bool {
+	ticker := time.NewTicker(logInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return true
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			logger.Infof("Shutdown: waiting for %s %d, %v until the deadline", what, roundID, time.Until(deadline).Round(time.Second))
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package shutdown_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flare-foundation/fdc-client/client/config"
+	"github.com/flare-foundation/fdc-client/client/shutdown"
+	"github.com/flare-foundation/fdc-client/client/timing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// requests records how the collection of requests is stopped. The collection stops after StopRequestsAfter when collect is called.
+type requests struct {
+	mu        sync.Mutex
+	stopAt    uint64
+	stopped   int // number of immediate stops
+	collected chan struct{}
+	once      sync.Once
+}
+
+func newRequests() *requests {
+	return &requests{collected: make(chan struct{})}
+}
+
+func (r *requests) StopRequestsAfter(ts uint64) <-chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stopAt = ts
+
+	return r.collected
+}
+
+func (r *requests) StopRequests() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stopped++
+}
+
+func (r *requests) collect() {
+	r.once.Do(func() { close(r.collected) })
+}
+
+func (r *requests) state() (uint64, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.stopAt, r.stopped
+}
+
+func TestDrain(t *testing.T) {
+	now := uint64(time.Now().Unix())
+	require.NoError(t, timing.Set(config.Timing{T0: now - 10, CollectDurationSec: 2, ChooseDurationSec: 1}))
+
+	roundID, err := timing.RoundIDForTS(now)
+	require.NoError(t, err)
+
+	state := shutdown.New()
+	require.False(t, state.Draining())
+
+	collection := newRequests()
+	done := make(chan struct{})
+	go func() {
+		state.Drain(context.Background(), time.Minute, collection)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		stopAt, _ := collection.state()
+		return stopAt != 0
+	}, 5*time.Second, 10*time.Millisecond)
+	require.True(t, state.Draining())
+
+	// requests are collected until blocks after the collect phase of the round are queried
+	stopAt, stopped := collection.state()
+	require.Equal(t, timing.ChooseStartTS(roundID), stopAt)
+	require.Zero(t, stopped)
+
+	// the root does not end the shutdown before the requests of the round are collected
+	state.RootFetched(roundID)
+	select {
+	case <-done:
+		require.FailNow(t, "drained before the requests of the round were collected")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	collection.collect()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.FailNow(t, "not drained after the root of the round was fetched")
+	}
+
+	_, stopped = collection.state()
+	require.Equal(t, 1, stopped)
+}
+
+func TestDrainRoot(t *testing.T) {
+	now := uint64(time.Now().Unix())
+	require.NoError(t, timing.Set(config.Timing{T0: now - 1000, CollectDurationSec: 90, ChooseDurationSec: 45}))
+
+	roundID, err := timing.RoundIDForTS(now)
+	require.NoError(t, err)
+
+	state := shutdown.New()
+	collection := newRequests()
+	collection.collect()
+
+	done := make(chan struct{})
+	go func() {
+		state.Drain(context.Background(), time.Minute, collection)
+		close(done)
+	}()
+
+	// roots of earlier rounds do not end the shutdown
+	state.RootFetched(roundID - 1)
+	select {
+	case <-done:
+		require.FailNow(t, "drained before the root of the round was fetched")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	state.RootFetched(roundID)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.FailNow(t, "not drained after the root of the round was fetched")
+	}
+}
+
+func TestDrainTimeout(t *testing.T) {
+	require.NoError(t, timing.Set(config.Timing{T0: uint64(time.Now().Unix()), CollectDurationSec: 90}))
+
+	state := shutdown.New()
+	collection := newRequests()
+
+	start := time.Now()
+	state.Drain(context.Background(), 100*time.Millisecond, collection)
+	require.Less(t, time.Since(start), time.Second)
+
+	_, stopped := collection.state()
+	require.Equal(t, 1, stopped)
+}
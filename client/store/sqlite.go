@@ -0,0 +1,291 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/flare-foundation/fdc-client/client/attestation"
+	bitvotes "github.com/flare-foundation/fdc-client/client/attestation/bitVotes"
+)
+
+type roundModel struct {
+	ID                uint32 `gorm:"primaryKey;autoIncrement:false"`
+	ConsensusComputed bool
+	ConsensusBitVote  []byte
+}
+
+func (roundModel) TableName() string { return "rounds" }
+
+type attestationModel struct {
+	RoundID     uint32 `gorm:"primaryKey;autoIncrement:false"`
+	RequestHash []byte `gorm:"primaryKey"`
+	Request     []byte
+	Response    []byte
+	Fee         string
+	Indexes     string // json encoded []attestation.IndexLog
+	Status      attestation.Status
+	Consensus   bool
+	Hash        []byte
+}
+
+func (attestationModel) TableName() string { return "attestations" }
+
+type bitVoteModel struct {
+	RoundID          uint32 `gorm:"primaryKey;autoIncrement:false"`
+	SubmitAddress    string `gorm:"primaryKey"`
+	VoterIndex       int
+	Weight           uint16
+	BlockNumber      uint64
+	TransactionIndex uint64
+	BitVote          []byte
+}
+
+func (bitVoteModel) TableName() string { return "bit_votes" }
+
+// SQLite is a Store backed by a SQLite database.
+type SQLite struct {
+	db *gorm.DB
+}
+
+// NewSQLite opens (or creates) a SQLite round store at path.
+func NewSQLite(path string) (*SQLite, error) {
+	if path == "" {
+		return nil, fmt.Errorf("sqlite round store: empty path")
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0o750)
+	if err != nil {
+		return nil, fmt.Errorf("creating directory for sqlite round store %s: %s", path, err)
+	}
+
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("opening sqlite round store %s: %s", path, err)
+	}
+
+	// Writes come from several goroutines, SQLite allows only one writer at a time.
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("opening sqlite round store %s: %s", path, err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	err = db.AutoMigrate(&roundModel{}, &attestationModel{}, &bitVoteModel{})
+	if err != nil {
+		return nil, fmt.Errorf("migrating sqlite round store %s: %s", path, err)
+	}
+
+	return &SQLite{db: db}, nil
+}
+
+// ensureRound inserts an empty round row if it does not exist yet.
+func ensureRound(tx *gorm.DB, roundID uint32) error {
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&roundModel{ID: roundID}).Error
+}
+
+// SaveAttestation stores or updates the attestation in its round.
+func (s *SQLite) SaveAttestation(att *attestation.Attestation) error {
+	att.RLock()
+	indexes, err := json.Marshal(att.Indexes)
+	if err != nil {
+		att.RUnlock()
+		return fmt.Errorf("encoding indexes: %s", err)
+	}
+	model := attestationModel{
+		RoundID:     att.RoundID,
+		RequestHash: crypto.Keccak256(att.Request),
+		Request:     att.Request,
+		Response:    att.Response,
+		Fee:         feeString(att.Fee),
+		Indexes:     string(indexes),
+		Status:      att.Status,
+		Consensus:   att.Consensus,
+		Hash:        att.Hash.Bytes(),
+	}
+	att.RUnlock()
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensureRound(tx, model.RoundID); err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&model).Error
+	})
+}
+
+// RemoveAttestation removes the attestation from its round.
+func (s *SQLite) RemoveAttestation(att *attestation.Attestation) error {
+	att.RLock()
+	roundID, requestHash := att.RoundID, crypto.Keccak256(att.Request)
+	att.RUnlock()
+
+	return s.db.Where("round_id = ? AND request_hash = ?", roundID, requestHash).Delete(&attestationModel{}).Error
+}
+
+// SaveBitVote stores or updates the bitVote submitted by submitAddress in a round.
+func (s *SQLite) SaveBitVote(roundID uint32, submitAddress common.Address, bitVote *bitvotes.WeightedBitVote) error {
+	model := bitVoteModel{
+		RoundID:          roundID,
+		SubmitAddress:    submitAddress.Hex(),
+		VoterIndex:       bitVote.Index,
+		Weight:           bitVote.Weight,
+		BlockNumber:      bitVote.IndexTx.BlockNumber,
+		TransactionIndex: bitVote.IndexTx.TransactionIndex,
+		BitVote:          bitVote.BitVote.EncodeBitVote(),
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensureRound(tx, roundID); err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&model).Error
+	})
+}
+
+// SaveConsensus stores the consensus bitVote of a round.
+func (s *SQLite) SaveConsensus(roundID uint32, consensus bitvotes.BitVote) error {
+	model := roundModel{
+		ID:                roundID,
+		ConsensusComputed: true,
+		ConsensusBitVote:  consensus.EncodeBitVote(),
+	}
+
+	return s.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&model).Error
+}
+
+// LoadRounds returns all stored rounds with ID at least fromRoundID sorted by ID.
+func (s *SQLite) LoadRounds(fromRoundID uint32) ([]Round, error) {
+	var roundModels []roundModel
+	err := s.db.Where("id >= ?", fromRoundID).Order("id").Find(&roundModels).Error
+	if err != nil {
+		return nil, fmt.Errorf("loading rounds: %s", err)
+	}
+
+	rounds := make([]Round, 0, len(roundModels))
+	for i := range roundModels {
+		r, err := s.loadRound(roundModels[i])
+		if err != nil {
+			return nil, fmt.Errorf("loading round %d: %s", roundModels[i].ID, err)
+		}
+		rounds = append(rounds, r)
+	}
+
+	return rounds, nil
+}
+
+func (s *SQLite) loadRound(model roundModel) (Round, error) {
+	r := Round{ID: model.ID, ConsensusComputed: model.ConsensusComputed}
+
+	if model.ConsensusComputed {
+		consensus, err := bitvotes.DecodeBitVoteBytes(model.ConsensusBitVote)
+		if err != nil {
+			return Round{}, fmt.Errorf("decoding consensus bitVote: %s", err)
+		}
+		r.ConsensusBitVote = consensus
+	}
+
+	var attestationModels []attestationModel
+	err := s.db.Where("round_id = ?", model.ID).Find(&attestationModels).Error
+	if err != nil {
+		return Round{}, err
+	}
+
+	for i := range attestationModels {
+		att, err := attestationFromModel(attestationModels[i])
+		if err != nil {
+			return Round{}, err
+		}
+		r.Attestations = append(r.Attestations, att)
+	}
+
+	var bitVoteModels []bitVoteModel
+	// rows keep the rowid of the first submission when updated, so bitVotes are loaded in the order of the first submissions
+	err = s.db.Where("round_id = ?", model.ID).Order("rowid").Find(&bitVoteModels).Error
+	if err != nil {
+		return Round{}, err
+	}
+
+	for i := range bitVoteModels {
+		bitVote, err := bitvotes.DecodeBitVoteBytes(bitVoteModels[i].BitVote)
+		if err != nil {
+			return Round{}, fmt.Errorf("decoding bitVote of %s: %s", bitVoteModels[i].SubmitAddress, err)
+		}
+
+		r.BitVotes = append(r.BitVotes, BitVote{
+			SubmitAddress: common.HexToAddress(bitVoteModels[i].SubmitAddress),
+			BitVote: bitvotes.WeightedBitVote{
+				Index:  bitVoteModels[i].VoterIndex,
+				Weight: bitVoteModels[i].Weight,
+				IndexTx: bitvotes.IndexTx{
+					BlockNumber:      bitVoteModels[i].BlockNumber,
+					TransactionIndex: bitVoteModels[i].TransactionIndex,
+				},
+				BitVote: bitVote,
+			},
+		})
+	}
+
+	return r, nil
+}
+
+// attestationFromModel rebuilds the stored part of an attestation.
+// Configuration dependent fields have to be set by PrepareRequest.
+func attestationFromModel(model attestationModel) (*attestation.Attestation, error) {
+	var indexes []attestation.IndexLog
+	err := json.Unmarshal([]byte(model.Indexes), &indexes)
+	if err != nil {
+		return nil, fmt.Errorf("decoding indexes: %s", err)
+	}
+	if len(indexes) == 0 {
+		return nil, fmt.Errorf("attestation %x without indexes", model.RequestHash)
+	}
+
+	fee, ok := new(big.Int).SetString(model.Fee, 10)
+	if !ok {
+		return nil, fmt.Errorf("decoding fee %s", model.Fee)
+	}
+
+	return &attestation.Attestation{
+		Indexes:   indexes,
+		RoundID:   model.RoundID,
+		Request:   model.Request,
+		Response:  model.Response,
+		Fee:       fee,
+		Status:    model.Status,
+		Consensus: model.Consensus,
+		Hash:      common.BytesToHash(model.Hash),
+	}, nil
+}
+
+// RemoveBefore removes all rounds with ID lower than roundID.
+func (s *SQLite) RemoveBefore(roundID uint32) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("round_id < ?", roundID).Delete(&attestationModel{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("round_id < ?", roundID).Delete(&bitVoteModel{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id < ?", roundID).Delete(&roundModel{}).Error
+	})
+}
+
+// Close closes the underlying database.
+func (s *SQLite) Close() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
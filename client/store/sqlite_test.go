@@ -0,0 +1,156 @@
+package store_test
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/flare-foundation/fdc-client/client/attestation"
+	bitvotes "github.com/flare-foundation/fdc-client/client/attestation/bitVotes"
+	"github.com/flare-foundation/fdc-client/client/config"
+	"github.com/flare-foundation/fdc-client/client/store"
+)
+
+func newAttestation(roundID uint32, request string, blockNumber uint64) *attestation.Attestation {
+	return &attestation.Attestation{
+		Indexes: []attestation.IndexLog{{BlockNumber: blockNumber, LogIndex: 1}},
+		RoundID: roundID,
+		Request: []byte(request),
+		Fee:     big.NewInt(10),
+		Status:  attestation.Processing,
+	}
+}
+
+func TestSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rounds.db")
+
+	s, err := store.NewSQLite(path)
+	require.NoError(t, err)
+
+	att := newAttestation(100, "request1", 5)
+	require.NoError(t, s.SaveAttestation(att))
+
+	// update of the same attestation
+	att.Indexes = append(att.Indexes, attestation.IndexLog{BlockNumber: 6, LogIndex: 0})
+	att.Fee = big.NewInt(20)
+	att.Status = attestation.Success
+	att.Response = []byte("response1")
+	att.Hash = common.HexToHash("0x01")
+	require.NoError(t, s.SaveAttestation(att))
+
+	require.NoError(t, s.SaveAttestation(newAttestation(100, "request2", 7)))
+	require.NoError(t, s.SaveAttestation(newAttestation(99, "request3", 2)))
+
+	submitAddress := common.HexToAddress("0x8fe15e1048f90bc028a60007c7d5b55d9d20de66")
+	bitVote := bitvotes.WeightedBitVote{
+		Index:   3,
+		IndexTx: bitvotes.IndexTx{BlockNumber: 10, TransactionIndex: 2},
+		Weight:  100,
+		BitVote: bitvotes.BitVote{Length: 2, BitVector: big.NewInt(3)},
+	}
+	require.NoError(t, s.SaveBitVote(100, submitAddress, &bitVote))
+	require.NoError(t, s.SaveConsensus(100, bitvotes.BitVote{Length: 2, BitVector: big.NewInt(1)}))
+
+	require.NoError(t, s.Close())
+
+	// reopen
+	s, err = store.NewSQLite(path)
+	require.NoError(t, err)
+	defer s.Close() //nolint:errcheck
+
+	rounds, err := s.LoadRounds(0)
+	require.NoError(t, err)
+	require.Len(t, rounds, 2)
+	require.Equal(t, uint32(99), rounds[0].ID)
+	require.False(t, rounds[0].ConsensusComputed)
+
+	r := rounds[1]
+	require.Equal(t, uint32(100), r.ID)
+	require.Len(t, r.Attestations, 2)
+	require.True(t, r.ConsensusComputed)
+	require.Equal(t, uint16(2), r.ConsensusBitVote.Length)
+	require.Equal(t, int64(1), r.ConsensusBitVote.BitVector.Int64())
+
+	var restored *attestation.Attestation
+	for _, a := range r.Attestations {
+		if string(a.Request) == "request1" {
+			restored = a
+		}
+	}
+	require.NotNil(t, restored)
+	require.Equal(t, attestation.Success, restored.Status)
+	require.Equal(t, int64(20), restored.Fee.Int64())
+	require.Len(t, restored.Indexes, 2)
+	require.Equal(t, []byte("response1"), []byte(restored.Response))
+	require.Equal(t, common.HexToHash("0x01"), restored.Hash)
+
+	require.Len(t, r.BitVotes, 1)
+	require.Equal(t, submitAddress, r.BitVotes[0].SubmitAddress)
+	require.Equal(t, bitVote.Index, r.BitVotes[0].BitVote.Index)
+	require.Equal(t, bitVote.IndexTx, r.BitVotes[0].BitVote.IndexTx)
+	require.Equal(t, int64(3), r.BitVotes[0].BitVote.BitVote.BitVector.Int64())
+
+	rounds, err = s.LoadRounds(100)
+	require.NoError(t, err)
+	require.Len(t, rounds, 1)
+
+	require.NoError(t, s.RemoveBefore(100))
+	rounds, err = s.LoadRounds(0)
+	require.NoError(t, err)
+	require.Len(t, rounds, 1)
+	require.Equal(t, uint32(100), rounds[0].ID)
+
+	require.NoError(t, s.RemoveAttestation(restored))
+	rounds, err = s.LoadRounds(0)
+	require.NoError(t, err)
+	require.Len(t, rounds[0].Attestations, 1)
+	require.Equal(t, []byte("request2"), []byte(rounds[0].Attestations[0].Request))
+}
+
+func TestSQLiteBitVoteOrder(t *testing.T) {
+	s, err := store.NewSQLite(filepath.Join(t.TempDir(), "rounds.db"))
+	require.NoError(t, err)
+	defer s.Close() //nolint:errcheck
+
+	first := common.HexToAddress("0xf000000000000000000000000000000000000001")
+	second := common.HexToAddress("0x0000000000000000000000000000000000000002")
+
+	bitVote := func(blockNumber uint64) *bitvotes.WeightedBitVote {
+		return &bitvotes.WeightedBitVote{
+			IndexTx: bitvotes.IndexTx{BlockNumber: blockNumber},
+			Weight:  10,
+			BitVote: bitvotes.BitVote{Length: 1, BitVector: big.NewInt(1)},
+		}
+	}
+
+	require.NoError(t, s.SaveBitVote(100, first, bitVote(10)))
+	require.NoError(t, s.SaveBitVote(100, second, bitVote(11)))
+	// a later submission keeps the position of the first one
+	require.NoError(t, s.SaveBitVote(100, first, bitVote(12)))
+
+	rounds, err := s.LoadRounds(0)
+	require.NoError(t, err)
+	require.Len(t, rounds, 1)
+	require.Len(t, rounds[0].BitVotes, 2)
+	require.Equal(t, first, rounds[0].BitVotes[0].SubmitAddress)
+	require.Equal(t, uint64(12), rounds[0].BitVotes[0].BitVote.IndexTx.BlockNumber)
+	require.Equal(t, second, rounds[0].BitVotes[1].SubmitAddress)
+}
+
+func TestNew(t *testing.T) {
+	s, err := store.New(config.RoundStore{})
+	require.NoError(t, err)
+
+	rounds, err := s.LoadRounds(0)
+	require.NoError(t, err)
+	require.Empty(t, rounds)
+
+	_, err = store.New(config.RoundStore{Type: "unknown"})
+	require.Error(t, err)
+
+	_, err = store.New(config.RoundStore{Type: store.TypeSQLite})
+	require.Error(t, err)
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/flare-foundation/fdc-client/client/attestation"
+	bitvotes "github.com/flare-foundation/fdc-client/client/attestation/bitVotes"
+	"github.com/flare-foundation/fdc-client/client/config"
+)
+
+const (
+	TypeNone   = ""       // round store disabled
+	TypeSQLite = "sqlite" // rounds stored in a local SQLite database
+)
+
+// Store persists the state of the voting rounds so that it can be restored after a restart.
+type Store interface {
+	// SaveAttestation stores or updates the attestation in its round.
+	SaveAttestation(att *attestation.Attestation) error
+	// RemoveAttestation removes the attestation from its round.
+	RemoveAttestation(att *attestation.Attestation) error
+	// SaveBitVote stores or updates the bitVote submitted by submitAddress in a round.
+	SaveBitVote(roundID uint32, submitAddress common.Address, bitVote *bitvotes.WeightedBitVote) error
+	// SaveConsensus stores the consensus bitVote of a round.
+	SaveConsensus(roundID uint32, consensus bitvotes.BitVote) error
+	// LoadRounds returns all stored rounds with ID at least fromRoundID sorted by ID.
+	LoadRounds(fromRoundID uint32) ([]Round, error)
+	// RemoveBefore removes all rounds with ID lower than roundID.
+	RemoveBefore(roundID uint32) error
+	// Close releases the resources held by the store.
+	Close() error
+}
+
+// Round is a persisted voting round.
+type Round struct {
+	ID                uint32
+	Attestations      []*attestation.Attestation
+	BitVotes          []BitVote // in the order of the first submissions of the voters
+	ConsensusComputed bool
+	ConsensusBitVote  bitvotes.BitVote
+}
+
+// BitVote is a persisted bitVote together with its sender.
+type BitVote struct {
+	SubmitAddress common.Address
+	BitVote       bitvotes.WeightedBitVote
+}
+
+// New returns a Store of the type set in cfg.
+func New(cfg config.RoundStore) (Store, error) {
+	switch cfg.Type {
+	case TypeNone:
+		return nop{}, nil
+	case TypeSQLite:
+		return NewSQLite(cfg.Path)
+	default:
+		return nil, fmt.Errorf("unsupported round store type %q", cfg.Type)
+	}
+}
+
+// nop is a Store that stores nothing.
+type nop struct{}
+
+func (nop) SaveAttestation(*attestation.Attestation) error { return nil }
+
+func (nop) RemoveAttestation(*attestation.Attestation) error { return nil }
+
+func (nop) SaveBitVote(uint32, common.Address, *bitvotes.WeightedBitVote) error { return nil }
+
+func (nop) SaveConsensus(uint32, bitvotes.BitVote) error { return nil }
+
+func (nop) LoadRounds(uint32) ([]Round, error) { return nil, nil }
+
+func (nop) RemoveBefore(uint32) error { return nil }
+
+func (nop) Close() error { return nil }
+
+// feeString returns the decimal representation of the fee.
+func feeString(fee *big.Int) string {
+	if fee == nil {
+		return "0"
+	}
+	return fee.String()
+}
@@ -2,6 +2,7 @@ package timing
 
 import (
 	"fmt"
+	"time"
 )
 
 // RoundIDForTS calculates roundID that is active at timestamp.
@@ -32,6 +33,12 @@ func ChooseEndTS(n uint32) uint64 {
 	return ChooseStartTS(n) + Chain.ChooseDurationSec
 }
 
+// SigningDuration returns the duration from the end of the choose phase of a round until its Merkle root must be signed,
+// which is at the end of the collect phase of the following round.
+func SigningDuration() time.Duration {
+	return time.Duration(Chain.CollectDurationSec-Chain.ChooseDurationSec) * time.Second
+}
+
 // NextChoosePhaseEnd returns the roundID of the round whose choose phase is next in line to end and the timestamp of the end.
 // If t is right at the end of choose phase, the returned round is current and the timestamp is t.
 func NextChooseEnd(t uint64) (uint32, uint64) {
@@ -46,6 +53,17 @@ func NextChooseEnd(t uint64) (uint32, uint64) {
 	return uint32(roundID), endTimestamp
 }
 
+// OldestUnfinishedRound returns the roundID of the oldest round whose choose phase has not ended at t and the timestamp of its start.
+func OldestUnfinishedRound(t uint64) (uint32, uint64) {
+	if t < Chain.T0+Chain.CollectDurationSec+Chain.ChooseDurationSec {
+		return 0, RoundStartTS(0)
+	}
+
+	roundID := uint32((t-Chain.T0-Chain.ChooseDurationSec-Chain.CollectDurationSec)/Chain.CollectDurationSec) + 1
+
+	return roundID, RoundStartTS(roundID)
+}
+
 // LastCollectPhaseStart returns roundID and start timestamp of the latest round.
 func LastCollectPhaseStart(t uint64) (uint32, uint64, error) {
 	roundID, err := RoundIDForTS(t)
@@ -110,3 +110,45 @@ func TestTimesForTimestamps(t *testing.T) {
 		require.Equal(t, test.collectStart, collectStart, fmt.Sprintf("wrong roundIDCollect in test %d", i))
 	}
 }
+
+func TestOldestUnfinishedRound(t *testing.T) {
+	tests := []struct {
+		timestamp uint64
+		roundID   uint32
+	}{
+		{
+			timestamp: 0,
+			roundID:   0,
+		},
+		{
+			timestamp: timing.Chain.T0 + timing.Chain.CollectDurationSec + timing.Chain.ChooseDurationSec/2,
+			roundID:   0,
+		},
+		{
+			timestamp: timing.ChooseEndTS(0),
+			roundID:   1,
+		},
+		{
+			timestamp: timing.ChooseEndTS(10000) - 1,
+			roundID:   10000,
+		},
+		{
+			timestamp: timing.ChooseEndTS(10000),
+			roundID:   10001,
+		},
+	}
+
+	for i, test := range tests {
+		roundID, start := timing.OldestUnfinishedRound(test.timestamp)
+		require.Equal(t, test.roundID, roundID, fmt.Sprintf("wrong roundID in test %d", i))
+		require.Equal(t, timing.RoundStartTS(test.roundID), start, fmt.Sprintf("wrong start in test %d", i))
+	}
+}
+
+func TestSigningDuration(t *testing.T) {
+	roundID := uint32(100)
+
+	// the Merkle root of a round is signed until the end of the collect phase of the next round
+	signingEnd := timing.ChooseEndTS(roundID) + uint64(timing.SigningDuration().Seconds())
+	require.Equal(t, timing.ChooseStartTS(roundID+1), signingEnd)
+}
@@ -0,0 +1,12 @@
+// Package configs embeds the system configurations of the supported chains into the binary.
+package configs
+
+import "embed"
+
+// SystemDirectory is the directory of the system configurations in System.
+const SystemDirectory = "systemConfigs"
+
+// System holds the system configurations stored as <SystemDirectory>/<protocolID>/<chain>.toml.
+//
+//go:embed systemConfigs
+var System embed.FS
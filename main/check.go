@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/flare-foundation/fdc-client/client/check"
+)
+
+const checkConfigCommand = "check-config"
+
+// checkConfig validates the configuration, prints the report, and returns the exit code of the command.
+func checkConfig(args []string) int {
+	flags := flag.NewFlagSet(checkConfigCommand, flag.ExitOnError)
+	cfg := flags.String("config", "configs/userConfig.toml", "Configuration file (toml format)")
+	systemDir := flags.String("system", systemDirectory, "Directory with overrides of the embedded system configurations")
+	ping := flags.Bool("ping", false, "Contact verifiers and the DB or RPC node")
+	jsonOutput := flags.Bool("json", false, "Print the report as JSON")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	report := check.Run(context.Background(), check.Options{UserFile: *cfg, SystemDirectory: *systemDir, Ping: *ping})
+
+	if *jsonOutput {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "writing report: %s\n", err)
+		return 2
+	}
+
+	if !report.OK() {
+		return 1
+	}
+
+	return 0
+}
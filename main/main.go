@@ -2,29 +2,38 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"github.com/flare-foundation/go-flare-common/pkg/logger"
 
 	"github.com/flare-foundation/fdc-client/client/collector"
 	"github.com/flare-foundation/fdc-client/client/config"
 	"github.com/flare-foundation/fdc-client/client/manager"
+	"github.com/flare-foundation/fdc-client/client/round"
 	"github.com/flare-foundation/fdc-client/client/shared"
 	"github.com/flare-foundation/fdc-client/client/timing"
 	"github.com/flare-foundation/fdc-client/server"
 )
 
 const (
-	systemDirectory string = "configs/systemConfigs" // relative to project root
+	systemDirectory string = "configs/systemConfigs" // overrides of the embedded system configs, relative to the working directory
 )
 
 var CfgFlag = flag.String("config", "configs/userConfig.toml", "Configuration file (toml format)")
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == checkConfigCommand {
+		os.Exit(checkConfig(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == replayCommand {
+		os.Exit(replayRound(os.Args[2:]))
+	}
+
 	flag.Parse()
 	userConfigRaw, systemConfig, err := config.Read(*CfgFlag, systemDirectory)
 	if err != nil {
@@ -36,6 +45,7 @@ func main() {
 	if err != nil {
 		logger.Panicf("cannot set timing: %s", err)
 	}
+	round.SetConsensusProtocol(systemConfig.Consensus)
 
 	attestationTypeConfig, err := config.ParseAttestationTypes(userConfigRaw.AttestationTypeConfig)
 	if err != nil {
@@ -50,7 +60,9 @@ func main() {
 
 	// Start attestation client collector
 	col := collector.New(userConfigRaw, systemConfig, sharedDataPipes)
-	col.WaitForDBToSync(ctx)
+	if err := col.WaitForDBToSync(ctx); err != nil {
+		logger.Panicf("waiting for the database to sync: %s", err)
+	}
 	go col.Run(ctx)
 
 	// Start attestation client manager
@@ -60,18 +72,51 @@ func main() {
 	}
 	go mngr.Run(ctx, cancel)
 
+	sharedDataPipes.Health.Register("shutdown", func() error {
+		if sharedDataPipes.Shutdown.Draining() {
+			return errors.New("shutting down")
+		}
+		return nil
+	})
+
+	reload := func(ctx context.Context) error { return reloadConfig(ctx, mngr) }
+
 	// Run attestation client server
-	srv := server.New(&sharedDataPipes.Rounds, userConfigRaw.ProtocolID, userConfigRaw.RestServer)
+	srv := server.New(&sharedDataPipes.Rounds, sharedDataPipes.Breakers, sharedDataPipes.Events, sharedDataPipes.Shutdown, sharedDataPipes.Health, reload, userConfigRaw.ProtocolID, userConfigRaw.RestServer)
 	go srv.Run(ctx)
 	logger.Info("Running server")
 
+	// Reload attestation types, sources and queues on SIGHUP
+	reloadChan := make(chan os.Signal, 1)
+	signal.Notify(reloadChan, syscall.SIGHUP)
+	go func() {
+		for range reloadChan {
+			logger.Info("Received a hangup signal, reloading configuration")
+			if err := reload(ctx); err != nil {
+				logger.Errorf("reloading configuration: %s", err)
+			}
+		}
+	}()
+
 	cancelChan := make(chan os.Signal, 1)
 	signal.Notify(cancelChan, os.Interrupt, syscall.SIGTERM)
 	// Block until a termination signal is received.
 	select {
 	case <-cancelChan:
-		logger.Info("Received an interrupt signal, shutting down after 2 minutes")
-		time.Sleep(2 * time.Minute)
+		logger.Info("Received an interrupt signal, shutting down gracefully, send it again to shut down immediately")
+
+		drainCtx, stopDrain := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-cancelChan:
+				logger.Info("Received a second interrupt signal, shutting down immediately")
+				stopDrain()
+			case <-drainCtx.Done():
+			}
+		}()
+
+		sharedDataPipes.Shutdown.Drain(drainCtx, userConfigRaw.Shutdown.Timeout, col)
+		stopDrain()
 	case <-ctx.Done():
 		logger.Info("Context cancelled, shutting down...")
 	}
@@ -79,3 +124,19 @@ func main() {
 	cancel()
 	srv.Shutdown()
 }
+
+// reloadConfig reads the user configuration again and reloads the attestation types, sources and queues of the manager.
+// Other settings are only applied on restart.
+func reloadConfig(ctx context.Context, mngr *manager.Manager) error {
+	userConfigRaw, err := config.ReadUserRaw(*CfgFlag)
+	if err != nil {
+		return err
+	}
+
+	attestationTypeConfig, err := config.ParseAttestationTypes(userConfigRaw.AttestationTypeConfig)
+	if err != nil {
+		return fmt.Errorf("att types config: %s", err)
+	}
+
+	return mngr.Reload(ctx, attestationTypeConfig, userConfigRaw.Queues)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/flare-foundation/go-flare-common/pkg/logger"
+
+	"github.com/flare-foundation/fdc-client/client/attestation"
+	"github.com/flare-foundation/fdc-client/client/collector"
+	"github.com/flare-foundation/fdc-client/client/config"
+	"github.com/flare-foundation/fdc-client/client/replay"
+	"github.com/flare-foundation/fdc-client/client/round"
+	"github.com/flare-foundation/fdc-client/client/timing"
+)
+
+const replayCommand = "replay"
+
+// replayRound recomputes the consensus bitVote and the Merkle root of a past round, prints the result, and returns the exit code of the command.
+func replayRound(args []string) int {
+	flags := flag.NewFlagSet(replayCommand, flag.ExitOnError)
+	roundID := flags.Uint("round", 0, "Voting round to replay")
+	cfg := flags.String("config", "configs/userConfig.toml", "Configuration file (toml format)")
+	systemDir := flags.String("system", systemDirectory, "Directory with overrides of the embedded system configurations")
+	verify := flags.Bool("verify", false, "Query verifiers for responses that are not in the responses file")
+	responsesFile := flags.String("responses", "", "JSON file with a list of {\"request\", \"response\"} objects")
+	jsonOutput := flags.Bool("json", false, "Print the result as JSON")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	if *roundID == 0 || *roundID > 1<<32-1 {
+		fmt.Fprintln(os.Stderr, "a valid --round is required")
+		return 2
+	}
+
+	userConfigRaw, systemConfig, err := config.Read(*cfg, *systemDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot read configs: %s\n", err)
+		return 2
+	}
+	logger.Set(userConfigRaw.Logging)
+
+	err = timing.Set(systemConfig.Timing)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot set timing: %s\n", err)
+		return 2
+	}
+	round.SetConsensusProtocol(systemConfig.Consensus)
+
+	attestationTypeConfig, err := config.ParseAttestationTypes(userConfigRaw.AttestationTypeConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "att types config: %s\n", err)
+		return 2
+	}
+
+	var responses map[string]attestation.Response
+	if *responsesFile != "" {
+		responses, err = replay.ReadResponses(*responsesFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			return 2
+		}
+	}
+
+	source, err := collector.NewSource(userConfigRaw)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "collector source: %s\n", err)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	replayer := replay.Replayer{
+		Source:          source,
+		System:          systemConfig,
+		ProtocolID:      userConfigRaw.ProtocolID,
+		AttestationType: attestationTypeConfig,
+	}
+
+	result, err := replayer.Replay(ctx, replay.Options{RoundID: uint32(*roundID), Verify: *verify, Responses: responses})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replaying round %d: %s\n", *roundID, err)
+		return 1
+	}
+
+	if *jsonOutput {
+		err = result.WriteJSON(os.Stdout)
+	} else {
+		err = result.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "writing result: %s\n", err)
+		return 2
+	}
+
+	if result.ConsensusError != "" || result.MerkleRootError != "" {
+		return 1
+	}
+
+	return 0
+}
@@ -3,14 +3,18 @@ package server
 // wip
 
 import (
+	"encoding/hex"
 	"errors"
 	"strconv"
+	"strings"
 
 	"github.com/flare-foundation/go-flare-common/pkg/logger"
 	"github.com/flare-foundation/go-flare-common/pkg/restserver"
 	"github.com/flare-foundation/go-flare-common/pkg/storage"
 
 	"github.com/flare-foundation/fdc-client/client/round"
+
+	"github.com/ethereum/go-ethereum/common"
 )
 
 type DAController struct {
@@ -27,6 +31,16 @@ type AttestationResponse struct {
 	Attestations []DAAttestation
 }
 
+type ProofRequest struct {
+	Request string  `json:"request" validate:"required"` // hex encoded ABI encoded request
+	RoundID *uint32 `json:"roundId,omitempty"`           // if not set, all stored rounds are searched
+}
+
+type ProofResponse struct {
+	Status      DAResponseStatus
+	Attestation *DAAttestation `json:",omitempty"`
+}
+
 func validateRoundIDParam(params map[string]string) (uint32, error) {
 	votingRoundIDStr, exists := params["votingRoundID"]
 	if !exists {
@@ -78,3 +92,61 @@ func (c *DAController) getAttestationController(
 
 	return AttestationResponse{Status: Ok, Attestations: attestations}, nil
 }
+
+func (c *DAController) getProofController(
+	_ map[string]string,
+	_ any,
+	body ProofRequest,
+) (ProofResponse, *restserver.ErrorHandler) {
+	request, err := hex.DecodeString(strings.TrimPrefix(body.Request, "0x"))
+	if err != nil {
+		logger.Error(err)
+		return ProofResponse{}, restserver.BadParamsErrorHandler(errors.New("request is not a hex string"))
+	}
+
+	att, exists := c.GetProof(request, body.RoundID)
+	if !exists {
+		return ProofResponse{Status: NotAvailable}, nil
+	}
+
+	return ProofResponse{Status: Ok, Attestation: &att}, nil
+}
+
+func (c *DAController) getProofByHashController(
+	params map[string]string,
+	_ any,
+	_ any,
+) (ProofResponse, *restserver.ErrorHandler) {
+	votingRoundID, err := validateRoundIDParam(params)
+	if err != nil {
+		logger.Error(err)
+		return ProofResponse{}, restserver.BadParamsErrorHandler(err)
+	}
+
+	hash, err := validateHashParam(params)
+	if err != nil {
+		logger.Error(err)
+		return ProofResponse{}, restserver.BadParamsErrorHandler(err)
+	}
+
+	att, exists := c.GetProofByHash(votingRoundID, hash)
+	if !exists {
+		return ProofResponse{Status: NotAvailable}, nil
+	}
+
+	return ProofResponse{Status: Ok, Attestation: &att}, nil
+}
+
+func validateHashParam(params map[string]string) (common.Hash, error) {
+	hashStr, exists := params["hash"]
+	if !exists {
+		return common.Hash{}, errors.New("missing hash param")
+	}
+
+	hash, err := hex.DecodeString(strings.TrimPrefix(hashStr, "0x"))
+	if err != nil || len(hash) != common.HashLength {
+		return common.Hash{}, errors.New("hash param is not a 32 bytes hex string")
+	}
+
+	return common.BytesToHash(hash), nil
+}
@@ -2,11 +2,15 @@ package server
 
 import (
 	"encoding/hex"
-	"fmt"
+	"time"
 
-	"github.com/flare-foundation/go-flare-common/pkg/merkle"
+	"github.com/flare-foundation/go-flare-common/pkg/logger"
 
 	"github.com/flare-foundation/fdc-client/client/attestation"
+	"github.com/flare-foundation/fdc-client/client/round"
+	"github.com/flare-foundation/fdc-client/client/timing"
+
+	"github.com/ethereum/go-ethereum/common"
 )
 
 func (c *DAController) GetRequests(roundId uint32) ([]DARequest, bool) {
@@ -15,6 +19,10 @@ func (c *DAController) GetRequests(roundId uint32) ([]DARequest, bool) {
 		return nil, false
 	}
 
+	// reverted requests are removed from the attestations of the round concurrently
+	round.RLock()
+	defer round.RUnlock()
+
 	requests := make([]DARequest, len(round.Attestations))
 
 	for i := range round.Attestations {
@@ -37,6 +45,8 @@ func AttestationToDARequest(att *attestation.Attestation) DARequest {
 		status = WrongMIC
 	case attestation.InvalidLUT:
 		status = FailedLUT
+	case attestation.Disagreement:
+		status = Disagreement
 	default:
 		status = Failed
 	}
@@ -52,75 +62,95 @@ func AttestationToDARequest(att *attestation.Attestation) DARequest {
 	return dARequest
 }
 
+// GetAttestations returns the confirmed attestations chosen by the consensus bitVote of the round with their Merkle proofs,
+// and a boolean indicating their availability. The attestations are available once the Merkle root of the round is finalised.
 func (c *DAController) GetAttestations(roundId uint32) ([]DAAttestation, bool) {
-	round, exists := c.Rounds.Get(roundId)
+	snapshot, exists := c.snapshot(roundId)
 	if !exists {
 		return nil, false
 	}
 
-	merkleTree, err := round.MerkleTree()
-	if err != nil {
-		return nil, false
+	attestations := make([]DAAttestation, len(snapshot.Leaves))
+	for i := range snapshot.Leaves {
+		attestations[i] = leafToDAAttestation(snapshot.RoundID, snapshot.Leaves[i])
 	}
 
-	attestations := make([]DAAttestation, 0)
+	return attestations, true
+}
 
-	for i := range round.Attestations {
-		att, ok, err := attestationToDAAttestation(round.Attestations[i])
-		if err != nil {
-			return nil, false
-		}
-		if ok {
-			err := att.addProof(merkleTree)
-			if err != nil {
-				return nil, false
-			}
+// GetProof returns the confirmed attestation with the request and its Merkle proof, and a boolean indicating its availability.
+// If roundID is nil, the stored rounds are searched from the current round backwards.
+func (c *DAController) GetProof(request attestation.Request, roundID *uint32) (DAAttestation, bool) {
+	if roundID != nil {
+		return c.proofForRequest(*roundID, request)
+	}
+
+	latest, err := timing.RoundIDForTS(uint64(time.Now().Unix()))
+	if err != nil {
+		logger.Errorf("proof: %s", err)
+		return DAAttestation{}, false
+	}
 
-			attestations = append(attestations, att)
+	for i := uint32(0); i < c.Rounds.Size() && i <= latest; i++ {
+		if att, ok := c.proofForRequest(latest-i, request); ok {
+			return att, true
 		}
 	}
-	return attestations, true
-}
 
-func attestationToDAAttestation(att *attestation.Attestation) (DAAttestation, bool, error) {
-	att.RLock()
-	defer att.RUnlock()
+	return DAAttestation{}, false
+}
 
-	isConfirmed := att.Status == attestation.Success
-	isSelected := att.Consensus
+// GetProofByHash returns the confirmed attestation with the response hash in round roundID and its Merkle proof,
+// and a boolean indicating its availability.
+func (c *DAController) GetProofByHash(roundID uint32, hash common.Hash) (DAAttestation, bool) {
+	snapshot, exists := c.snapshot(roundID)
+	if !exists {
+		return DAAttestation{}, false
+	}
 
-	if !isConfirmed && isSelected {
-		return DAAttestation{}, false, fmt.Errorf("request %s in round %d is in consensus but not confirmed", hex.EncodeToString(att.Request), att.RoundID)
+	leaf, exists := snapshot.LeafForHash(hash)
+	if !exists {
+		return DAAttestation{}, false
 	}
 
-	if !isConfirmed || !isSelected {
-		return DAAttestation{}, false, nil
+	return leafToDAAttestation(roundID, leaf), true
+}
+
+func (c *DAController) proofForRequest(roundID uint32, request attestation.Request) (DAAttestation, bool) {
+	snapshot, exists := c.snapshot(roundID)
+	if !exists {
+		return DAAttestation{}, false
 	}
 
-	dAAttestation := DAAttestation{
-		RoundID:     att.RoundID,
-		Request:     hex.EncodeToString(att.Request),
-		Response:    hex.EncodeToString(att.Response),
-		ResponseABI: *att.ResponseABIString,
-		hash:        att.Hash,
+	leaf, exists := snapshot.LeafForRequest(request)
+	if !exists {
+		return DAAttestation{}, false
 	}
 
-	return dAAttestation, true, nil
+	return leafToDAAttestation(roundID, leaf), true
 }
 
-func (DAAtt *DAAttestation) addProof(tree merkle.Tree) error {
-	proofCommon, err := tree.GetProofFromHash(DAAtt.hash)
-	if err != nil {
-		return fmt.Errorf("no proof for request %s in round %d", DAAtt.Request, DAAtt.RoundID)
+// snapshot returns the snapshot of the round if the round is stored and its Merkle root is finalised.
+func (c *DAController) snapshot(roundID uint32) (*round.Snapshot, bool) {
+	r, exists := c.Rounds.Get(roundID)
+	if !exists {
+		return nil, false
 	}
 
-	proof := make([]string, len(proofCommon))
+	return r.Snapshot()
+}
 
-	for i := range proofCommon {
-		proof[i] = proofCommon[i].Hex()
+func leafToDAAttestation(roundID uint32, leaf round.Leaf) DAAttestation {
+	proof := make([]string, len(leaf.Proof))
+	for i := range leaf.Proof {
+		proof[i] = leaf.Proof[i].Hex()
 	}
 
-	DAAtt.Proof = proof
-
-	return nil
+	return DAAttestation{
+		RoundID:     roundID,
+		Request:     hex.EncodeToString(leaf.Request),
+		Response:    hex.EncodeToString(leaf.Response),
+		ResponseABI: leaf.ResponseABI,
+		Proof:       proof,
+	}
 }
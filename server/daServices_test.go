@@ -70,7 +70,42 @@ func TestGetRequests(t *testing.T) {
 func TestGetAttestations(t *testing.T) {
 	controller := makeController(t)
 
+	// not available before the Merkle root is finalised and the round is not changed
+	_, ok := controller.GetAttestations(1)
+	require.False(t, ok)
+
+	r, _ := controller.Rounds.Get(1)
+	require.Equal(t, attestation.PreConsensus, r.Status.Value)
+	require.False(t, r.HasMerkleTree())
+
+	root, err := r.MerkleRoot()
+	require.NoError(t, err)
+
 	attestations, ok := controller.GetAttestations(1)
 	require.True(t, ok)
 	require.Len(t, attestations, 1)
+	require.Equal(t, requestEVM, attestations[0].Request)
+	require.Empty(t, attestations[0].Proof)
+
+	snapshot, ok := r.Snapshot()
+	require.True(t, ok)
+	require.Equal(t, root, snapshot.Root)
+}
+
+func TestAttestationToDARequestStatus(t *testing.T) {
+	tests := []struct {
+		status   attestation.Status
+		expected server.AttestationStatus
+	}{
+		{attestation.Success, server.Valid},
+		{attestation.WrongMIC, server.WrongMIC},
+		{attestation.InvalidLUT, server.FailedLUT},
+		{attestation.Disagreement, server.Disagreement},
+		{attestation.ProcessError, server.Failed},
+	}
+
+	for _, test := range tests {
+		request := server.AttestationToDARequest(&attestation.Attestation{Status: test.status})
+		require.Equal(t, test.expected, request.Status)
+	}
 }
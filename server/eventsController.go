@@ -0,0 +1,120 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	swagger "github.com/davidebianchi/gswagger"
+	"github.com/flare-foundation/go-flare-common/pkg/logger"
+	"github.com/flare-foundation/go-flare-common/pkg/restserver"
+
+	"github.com/flare-foundation/fdc-client/client/events"
+)
+
+const eventsKeepAlive = 10 * time.Second // period of keep alive comments on idle event streams
+
+// eventsRouteHandler returns a handler of the server-sent events stream of round lifecycle events.
+// The stream ends when the client disconnects or the server shuts down.
+func eventsRouteHandler(hub *events.Hub, shutdown <-chan struct{}, securities []string) restserver.RouteHandler {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		filter := events.Filter{
+			AttestationTypes: queryList(r, "type"),
+			Sources:          queryList(r, "source"),
+		}
+
+		controller := http.NewResponseController(w)
+		// the stream outlives the write timeout of the server
+		if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+			logger.Debugf("events: %s", err)
+		}
+
+		stream, cancel := hub.Subscribe(filter)
+		defer cancel()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+
+		if err := controller.Flush(); err != nil {
+			logger.Errorf("events: %s", err)
+			return
+		}
+
+		keepAlive := time.NewTicker(eventsKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			var err error
+
+			select {
+			case e := <-stream:
+				err = writeEvent(w, e)
+			case <-keepAlive.C:
+				_, err = fmt.Fprint(w, ": keep-alive\n\n")
+			case <-r.Context().Done():
+				return
+			case <-shutdown:
+				return
+			}
+
+			if err == nil {
+				err = controller.Flush()
+			}
+			if err != nil {
+				logger.Debugf("events: %s", err)
+				return
+			}
+		}
+	}
+
+	security := make(swagger.SecurityRequirement)
+	for _, name := range securities {
+		security[name] = []string{}
+	}
+
+	return restserver.RouteHandler{
+		Handler: handler,
+		Method:  http.MethodGet,
+		SwaggerDefinitions: swagger.Definitions{
+			Querystring: swagger.ParameterValue{
+				"type":   {Schema: &swagger.Schema{Value: ""}, Description: "Comma separated attestation types"},
+				"source": {Schema: &swagger.Schema{Value: ""}, Description: "Comma separated sources"},
+			},
+			Responses: map[int]swagger.ContentValue{
+				http.StatusOK: {Content: swagger.Content{"text/event-stream": {Value: events.Event{}}}},
+			},
+			Security: swagger.SecurityRequirements{security},
+		},
+	}
+}
+
+// writeEvent writes the event in the server-sent events format.
+func writeEvent(w http.ResponseWriter, e events.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+
+	return err
+}
+
+// queryList returns comma separated values of the query parameter key. The parameter can be repeated.
+func queryList(r *http.Request, key string) []string {
+	var list []string
+
+	for _, value := range r.URL.Query()[key] {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+
+	return list
+}
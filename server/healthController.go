@@ -0,0 +1,43 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/flare-foundation/go-flare-common/pkg/logger"
+
+	"github.com/flare-foundation/fdc-client/client/health"
+)
+
+// LiveResponse is the response of /health/live.
+type LiveResponse struct {
+	Live bool `json:"live"`
+}
+
+// liveHandler responds with 200 as long as the server is running.
+func liveHandler(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, LiveResponse{Live: true})
+}
+
+// readyHandler returns a handler that responds with the readiness report of the client, with 200 if it is ready and 503 otherwise.
+func readyHandler(registry *health.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		report := registry.Ready()
+
+		code := http.StatusOK
+		if !report.Ready {
+			code = http.StatusServiceUnavailable
+		}
+
+		writeJSON(w, code, report)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Debugf("health: %s", err)
+	}
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/flare-foundation/go-flare-common/pkg/payload"
+	"github.com/gorilla/mux"
+
+	"github.com/flare-foundation/fdc-client/client/metrics"
+)
+
+// statusRecorder records the status code written to the response.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// fspMetricsMiddleware counts responses of the FSP endpoints by http status code.
+// The endpoint is the first path component after prefix, e.g. "submit2".
+func fspMetricsMiddleware(prefix string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			recorder := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			metrics.FSPResponses.WithLabelValues(fspEndpoint(r, prefix), strconv.Itoa(recorder.code)).Inc()
+		})
+	}
+}
+
+func fspEndpoint(r *http.Request, prefix string) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return "unknown"
+	}
+
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return "unknown"
+	}
+
+	endpoint, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(template, prefix), "/"), "/")
+
+	return endpoint
+}
+
+// observeFSPStatus counts the response of the FSP endpoint by its payload status.
+func observeFSPStatus(endpoint string, response payload.SubprotocolResponse) {
+	metrics.FSPStatuses.WithLabelValues(endpoint, string(response.Status)).Inc()
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/flare-foundation/go-flare-common/pkg/storage"
 
 	"github.com/flare-foundation/fdc-client/client/round"
+	"github.com/flare-foundation/fdc-client/client/shutdown"
 	"github.com/flare-foundation/fdc-client/client/timing"
 )
 
@@ -21,6 +22,7 @@ const hexPrefix = "0x"
 type FDCProtocolProviderController struct {
 	rounds     *storage.Cyclic[uint32, *round.Round]
 	protocolID uint8
+	shutdown   *shutdown.State // records the Merkle roots fetched by the FSP client, can be nil
 }
 
 type submitXParams struct {
@@ -28,10 +30,11 @@ type submitXParams struct {
 	submitAddress string
 }
 
-func newFDCProtocolProviderController(rounds *storage.Cyclic[uint32, *round.Round], protocolID uint8) *FDCProtocolProviderController {
+func newFDCProtocolProviderController(rounds *storage.Cyclic[uint32, *round.Round], protocolID uint8, shutdownState *shutdown.State) *FDCProtocolProviderController {
 	return &FDCProtocolProviderController{
 		rounds:     rounds,
 		protocolID: protocolID,
+		shutdown:   shutdownState,
 	}
 }
 
@@ -103,7 +106,12 @@ func (c *FDCProtocolProviderController) submit1Controller(
 	_ any,
 	_ any,
 ) (payload.SubprotocolResponse, *restserver.ErrorHandler) {
-	return submitXController(params, c.submit1Service, timing.RoundStartTS)
+	response, errHandler := submitXController(params, c.submit1Service, timing.RoundStartTS)
+	if errHandler == nil {
+		observeFSPStatus("submit1", response)
+	}
+
+	return response, errHandler
 }
 
 func (c *FDCProtocolProviderController) submit2Controller(
@@ -111,7 +119,12 @@ func (c *FDCProtocolProviderController) submit2Controller(
 	_ any,
 	_ any,
 ) (payload.SubprotocolResponse, *restserver.ErrorHandler) {
-	return submitXController(params, c.submit2Service, timing.ChooseStartTS)
+	response, errHandler := submitXController(params, c.submit2Service, timing.ChooseStartTS)
+	if errHandler == nil {
+		observeFSPStatus("submit2", response)
+	}
+
+	return response, errHandler
 }
 
 func (c *FDCProtocolProviderController) submitSignaturesController(
@@ -126,5 +139,12 @@ func (c *FDCProtocolProviderController) submitSignaturesController(
 	}
 
 	response := c.submitSignaturesService(pathParams.votingRoundID, pathParams.submitAddress)
+	observeFSPStatus("submitSignatures", response)
+
+	// the response is final once the choose phase has ended and the status is not RETRY
+	if c.shutdown != nil && response.Status != payload.Retry && uint64(time.Now().Unix()) >= timing.ChooseEndTS(pathParams.votingRoundID) {
+		c.shutdown.RootFetched(pathParams.votingRoundID)
+	}
+
 	return response, nil
 }
@@ -1,8 +1,13 @@
 package server
 
 import (
+	"time"
+
 	"github.com/flare-foundation/go-flare-common/pkg/logger"
 	"github.com/flare-foundation/go-flare-common/pkg/payload"
+
+	"github.com/flare-foundation/fdc-client/client/metrics"
+	"github.com/flare-foundation/fdc-client/client/timing"
 )
 
 // submit1Service returns an empty response with boolean (always false) that indicate its nonexistence.
@@ -53,13 +58,17 @@ func (c *FDCProtocolProviderController) submitSignaturesService(roundID uint32,
 
 	encodedBV := "0x" + consensusBV.EncodeBitVoteHex()
 
-	root, err := vRound.MerkleRoot()
+	root, first, err := vRound.MerkleRootFirst()
 	if err != nil {
 		logger.Infof("submitSignatures: Merkle root for round %d not available: %s", roundID, err)
 
 		return payload.SubprotocolResponse{Status: payload.Retry}
 	}
 
+	if first {
+		metrics.MerkleRootLatency.Observe(time.Since(time.Unix(int64(timing.ChooseEndTS(roundID)), 0)).Seconds())
+	}
+
 	msg := payload.BuildMessageForSigning(c.protocolID, roundID, false, root)
 	logger.Infof("submitSignatures: round: %v, root: %v, consensus: %s", roundID, root, encodedBV)
 
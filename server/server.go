@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/flare-foundation/go-flare-common/pkg/logger"
@@ -10,14 +11,24 @@ import (
 	"github.com/flare-foundation/go-flare-common/pkg/restserver"
 	"github.com/flare-foundation/go-flare-common/pkg/storage"
 
+	"github.com/flare-foundation/fdc-client/client/attestation"
 	"github.com/flare-foundation/fdc-client/client/config"
+	"github.com/flare-foundation/fdc-client/client/events"
+	"github.com/flare-foundation/fdc-client/client/health"
+	"github.com/flare-foundation/fdc-client/client/metrics"
 	"github.com/flare-foundation/fdc-client/client/round"
+	"github.com/flare-foundation/fdc-client/client/shutdown"
 
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/cors"
 )
 
-const shutdownTimeout = 5 * time.Second
+const (
+	shutdownTimeout      = 5 * time.Second
+	defaultStatusSubpath = "/status"
+	defaultStatusTitle   = "Client status"
+)
 
 type Server struct {
 	srv *http.Server
@@ -25,17 +36,38 @@ type Server struct {
 
 func New(
 	rounds *storage.Cyclic[uint32, *round.Round],
+	breakers *attestation.Breakers,
+	hub *events.Hub,
+	shutdownState *shutdown.State,
+	healthRegistry *health.Registry,
+	reload func(context.Context) error,
 	protocolID uint8,
 	serverConfig config.RestServer,
 ) Server {
 	// Create Mux router
 	muxRouter := mux.NewRouter()
 
-	// Register a health check endpoint at the top level.
+	// closed on shutdown to end event streams
+	shutdown := make(chan struct{})
+
+	// Register a health check endpoint at the top level. It fails once the client is shutting down.
 	muxRouter.HandleFunc("/health", func(w http.ResponseWriter, req *http.Request) {
+		if shutdownState != nil && shutdownState.Draining() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 	}).Methods("GET")
 
+	// Register liveness and readiness endpoints for orchestrators. They are not protected by API keys.
+	muxRouter.HandleFunc("/health/live", liveHandler).Methods("GET")
+	if healthRegistry != nil {
+		muxRouter.HandleFunc("/health/ready", readyHandler(healthRegistry)).Methods("GET")
+	}
+
+	// Register Prometheus metrics endpoint at the top level.
+	muxRouter.Handle("/metrics", promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})).Methods("GET")
+
 	// create api auth middleware
 	keyMiddleware := &restserver.APIKeyAuthMiddleware{
 		KeyName: serverConfig.APIKeyName,
@@ -53,15 +85,32 @@ func New(
 	// create FSP sub router
 	fspSubRouter := router.WithPrefix(serverConfig.FSPSubpath, serverConfig.FSPTitle)
 	// Register routes for FSP
-	registerFDCProviderRoutes(fspSubRouter, protocolID, rounds, []string{serverConfig.APIKeyName})
+	registerFDCProviderRoutes(fspSubRouter, protocolID, rounds, shutdownState, []string{serverConfig.APIKeyName})
+	fspSubRouter.AddMiddleware(fspMetricsMiddleware(serverConfig.FSPSubpath))
 	fspSubRouter.AddMiddleware(keyMiddleware.Middleware)
 
 	// create DA sub router
 	daSubRouter := router.WithPrefix(serverConfig.DAPSubpath, serverConfig.DATitle)
 	// Register routes for DA
 	registerDARoutes(daSubRouter, rounds, []string{serverConfig.APIKeyName})
+	daSubRouter.AddRoute("/events", eventsRouteHandler(hub, shutdown, []string{serverConfig.APIKeyName}), "Events", "Server-sent events stream of round lifecycle events")
 	daSubRouter.AddMiddleware(keyMiddleware.Middleware)
 
+	statusSubpath := serverConfig.StatusSubpath
+	if statusSubpath == "" {
+		statusSubpath = defaultStatusSubpath
+	}
+	statusTitle := serverConfig.StatusTitle
+	if statusTitle == "" {
+		statusTitle = defaultStatusTitle
+	}
+
+	// create status sub router
+	statusSubRouter := router.WithPrefix(statusSubpath, statusTitle)
+	// Register routes for status
+	registerStatusRoutes(statusSubRouter, rounds, breakers, reload, []string{serverConfig.APIKeyName})
+	statusSubRouter.AddMiddleware(keyMiddleware.Middleware)
+
 	// Register routes
 	router.Finalize()
 
@@ -78,6 +127,8 @@ func New(
 		WriteTimeout: 15 * time.Second,
 		ReadTimeout:  15 * time.Second,
 	}
+	var once sync.Once
+	srv.RegisterOnShutdown(func() { once.Do(func() { close(shutdown) }) })
 
 	return Server{srv: srv}
 }
@@ -103,9 +154,9 @@ func (s *Server) Shutdown() {
 }
 
 // registerFDCProviderRoutes registers routes for the FDC protocol provider.
-func registerFDCProviderRoutes(router restserver.Router, protocolID uint8, rounds *storage.Cyclic[uint32, *round.Round], securities []string) {
+func registerFDCProviderRoutes(router restserver.Router, protocolID uint8, rounds *storage.Cyclic[uint32, *round.Round], shutdownState *shutdown.State, securities []string) {
 	// Prepare service controller
-	controller := newFDCProtocolProviderController(rounds, protocolID)
+	controller := newFDCProtocolProviderController(rounds, protocolID, shutdownState)
 	paramMap := map[string]string{"votingRoundID": "Voting round ID", "submitAddress": "Submit address"}
 
 	submit1Handler := restserver.GeneralRouteHandler(controller.submit1Controller, http.MethodGet, http.StatusOK, paramMap, nil, nil, payload.SubprotocolResponse{}, securities)
@@ -129,4 +180,34 @@ func registerDARoutes(router restserver.Router, rounds *storage.Cyclic[uint32, *
 
 	getAttestations := restserver.GeneralRouteHandler(controller.getAttestationController, http.MethodGet, http.StatusOK, paramMap, nil, nil, AttestationResponse{}, securities)
 	router.AddRoute("/getAttestations/{votingRoundID}", getAttestations, "GetAttestations")
+
+	getProof := restserver.GeneralRouteHandler(controller.getProofController, http.MethodPost, http.StatusOK, nil, nil, ProofRequest{}, ProofResponse{}, securities)
+	router.AddRoute("/proof", getProof, "GetProof")
+
+	getProofByHash := restserver.GeneralRouteHandler(controller.getProofByHashController, http.MethodGet, http.StatusOK, map[string]string{"votingRoundID": "Voting round ID", "hash": "Response hash"}, nil, nil, ProofResponse{}, securities)
+	router.AddRoute("/proofByHash/{votingRoundID}/{hash}", getProofByHash, "GetProofByHash")
+}
+
+// registerStatusRoutes registers routes with the status of the client.
+// The reload route is registered only if reload is not nil.
+func registerStatusRoutes(router restserver.Router, rounds *storage.Cyclic[uint32, *round.Round], breakers *attestation.Breakers, reload func(context.Context) error, securities []string) {
+	controller := StatusController{Rounds: rounds, Breakers: breakers, Reload: reload}
+	paramMap := map[string]string{"votingRoundID": "Voting round ID"}
+
+	getRound := restserver.GeneralRouteHandler(controller.getRoundController, http.MethodGet, http.StatusOK, paramMap, nil, nil, RoundStatusResponse{}, securities)
+	router.AddRoute("/round/{votingRoundID}", getRound, "GetRound")
+
+	getConsensus := restserver.GeneralRouteHandler(controller.getConsensusController, http.MethodGet, http.StatusOK, paramMap, nil, nil, ConsensusExplanationResponse{}, securities)
+	router.AddRoute("/round/{votingRoundID}/consensus", getConsensus, "GetConsensus", "Explanation of the consensus bitVote of the round")
+
+	getRounds := restserver.GeneralRouteHandler(controller.getRoundsController, http.MethodGet, http.StatusOK, nil, RoundsQuery{}, nil, RoundsStatusResponse{}, securities)
+	router.AddRoute("/rounds", getRounds, "GetRounds")
+
+	getVerifiers := restserver.GeneralRouteHandler(controller.getVerifiersController, http.MethodGet, http.StatusOK, nil, nil, nil, VerifiersResponse{}, securities)
+	router.AddRoute("/verifiers", getVerifiers, "GetVerifiers")
+
+	if reload != nil {
+		reloadHandler := restserver.GeneralRouteHandler(controller.reloadController, http.MethodPost, http.StatusOK, nil, nil, nil, ReloadResponse{}, securities)
+		router.AddRoute("/reload", reloadHandler, "Reload", "Reloads attestation types, sources and queues from the user configuration")
+	}
 }
@@ -1,12 +1,19 @@
 package server_test
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
 	"math/big"
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -16,8 +23,12 @@ import (
 
 	"github.com/flare-foundation/fdc-client/client/attestation"
 	bitvotes "github.com/flare-foundation/fdc-client/client/attestation/bitVotes"
+	"github.com/flare-foundation/fdc-client/client/collector"
 	"github.com/flare-foundation/fdc-client/client/config"
+	"github.com/flare-foundation/fdc-client/client/events"
+	"github.com/flare-foundation/fdc-client/client/health"
 	"github.com/flare-foundation/fdc-client/client/round"
+	"github.com/flare-foundation/fdc-client/client/shutdown"
 	"github.com/flare-foundation/fdc-client/server"
 	"github.com/flare-foundation/fdc-client/tests/mocks"
 
@@ -42,6 +53,8 @@ func TestServer(t *testing.T) {
 		Title:       "FDC protocol data provider API",
 		FSPTitle:    "FDC protocol data provider for FSP client",
 		FSPSubpath:  "/fsp",
+		DATitle:     "DA endpoints",
+		DAPSubpath:  "/da",
 		Version:     "0.0.0",
 		SwaggerPath: "/api-doc",
 		Addr:        "localhost:8080",
@@ -49,7 +62,27 @@ func TestServer(t *testing.T) {
 		APIKeys:     []string{"12345", "123456"},
 	}
 
-	s := server.New(&rounds, 200, serverConfig)
+	hub := events.NewHub()
+
+	var reloadErr error
+	reloads := 0
+	reload := func(context.Context) error {
+		reloads++
+		return reloadErr
+	}
+
+	shutdownState := shutdown.New()
+
+	var notReady atomic.Bool
+	healthRegistry := health.NewRegistry()
+	healthRegistry.Register("test", func() error {
+		if notReady.Load() {
+			return errors.New("not ready")
+		}
+		return nil
+	})
+
+	s := server.New(&rounds, attestation.NewBreakers(), hub, shutdownState, healthRegistry, reload, 200, serverConfig)
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
 	defer cancel()
@@ -72,20 +105,36 @@ func TestServer(t *testing.T) {
 	abi, err := config.ArgumentsFromABI(abiFile)
 	require.NoError(t, err)
 
+	bitVote := bitvotes.BitVote{Length: 1, BitVector: big.NewInt(1)}
+
+	// round with the consensus computed by the client
+	voter0, voter1 := common.HexToAddress("0x1"), common.HexToAddress("0x2")
+	computed := round.New(10, voters.NewSet([]common.Address{voter0, voter1}, []uint16{1, 1}, nil))
+	computed.AddAttestation(&attestation.Attestation{
+		Indexes: []attestation.IndexLog{{BlockNumber: 1}},
+		Request: request,
+		Fee:     big.NewInt(1),
+	})
+	computed.RestoreBitVote(voter0, bitvotes.WeightedBitVote{Index: 0, Weight: 1, BitVote: bitVote})
+	computed.RestoreBitVote(voter1, bitvotes.WeightedBitVote{Index: 1, Weight: 1, BitVote: bitVote})
+	require.NoError(t, computed.ComputeConsensusBitVote(context.Background()))
+	rounds.Store(10, computed)
+
 	round := round.New(votingRoundID, voters.NewSet(nil, nil, nil))
-	round.Attestations = append(round.Attestations, &attestation.Attestation{
-		Request:     request,
-		Response:    response,
-		RoundID:     votingRoundID,
-		Consensus:   true,
-		Status:      attestation.Success,
-		Hash:        hash,
-		ResponseABI: &abi,
+	abiString := string(abiFile)
+	round.AddAttestation(&attestation.Attestation{
+		Indexes:           []attestation.IndexLog{{BlockNumber: 1}},
+		Request:           request,
+		Response:          response,
+		RoundID:           votingRoundID,
+		Consensus:         true,
+		Status:            attestation.Success,
+		Hash:              hash,
+		ResponseABI:       &abi,
+		ResponseABIString: &abiString,
 	})
 	rounds.Store(votingRoundID, round)
 
-	bitVote := bitvotes.BitVote{Length: 1, BitVector: big.NewInt(1)}
-
 	round.ConsensusBitVote = bitVote
 	round.ConsensusCalculationFinished = true
 	// Wait for the server to be ready.
@@ -130,4 +179,235 @@ func TestServer(t *testing.T) {
 
 		require.Equal(t, rspData.AdditionalData, "0x"+round.ConsensusBitVote.EncodeBitVoteHex())
 	})
+
+	t.Run("verifiers", func(t *testing.T) {
+		var verifiers server.VerifiersResponse
+		require.Equal(t, http.StatusOK, getStatus(t, &serverConfig, "/status/verifiers", &verifiers))
+		require.Empty(t, verifiers.Verifiers)
+		require.Zero(t, verifiers.Parked)
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		var rsp server.ReloadResponse
+		require.Equal(t, http.StatusOK, postDA(t, &serverConfig, "/status/reload", nil, &rsp))
+		require.True(t, rsp.Reloaded)
+		require.Equal(t, 1, reloads)
+
+		reloadErr = errors.New("queue btc does not exist")
+		var failed server.ReloadResponse
+		require.Equal(t, http.StatusOK, postDA(t, &serverConfig, "/status/reload", nil, &failed))
+		require.False(t, failed.Reloaded)
+		require.Equal(t, "queue btc does not exist", failed.Error)
+	})
+
+	t.Run("round status", func(t *testing.T) {
+		var rsp server.RoundStatusResponse
+		require.Equal(t, http.StatusOK, getStatus(t, &serverConfig, "/status/round/1", &rsp))
+		require.Equal(t, server.Ok, rsp.Status)
+		require.NotNil(t, rsp.Round)
+
+		require.Equal(t, "done", rsp.Round.Status)
+		require.True(t, rsp.Round.ConsensusComputed)
+		require.Equal(t, "0x"+bitVote.EncodeBitVoteHex(), rsp.Round.ConsensusBitVote)
+		require.Empty(t, rsp.Round.Blocking)
+		require.Len(t, rsp.Round.Attestations, 1)
+		require.Equal(t, "EVMTransaction", rsp.Round.Attestations[0].Type)
+		require.Equal(t, "success", rsp.Round.Attestations[0].Status)
+		require.True(t, rsp.Round.Attestations[0].Consensus)
+
+		var missing server.RoundStatusResponse
+		require.Equal(t, http.StatusOK, getStatus(t, &serverConfig, "/status/round/2", &missing))
+		require.Equal(t, server.NotAvailable, missing.Status)
+		require.Nil(t, missing.Round)
+	})
+
+	t.Run("consensus explanation", func(t *testing.T) {
+		var rsp server.ConsensusExplanationResponse
+		require.Equal(t, http.StatusOK, getStatus(t, &serverConfig, "/status/round/10/consensus", &rsp))
+		require.Equal(t, server.Ok, rsp.Status)
+		require.NotNil(t, rsp.Explanation)
+		require.Equal(t, []int{0}, rsp.Explanation.Chosen)
+		require.Equal(t, []int{0, 1}, rsp.Explanation.Supporters)
+		require.True(t, rsp.Explanation.Optimal)
+
+		// consensus of round 1 was not computed by the client
+		var missing server.ConsensusExplanationResponse
+		require.Equal(t, http.StatusOK, getStatus(t, &serverConfig, "/status/round/1/consensus", &missing))
+		require.Equal(t, server.NotAvailable, missing.Status)
+		require.Nil(t, missing.Explanation)
+	})
+
+	t.Run("rounds status", func(t *testing.T) {
+		var rsp server.RoundsStatusResponse
+		require.Equal(t, http.StatusOK, getStatus(t, &serverConfig, "/status/rounds?from=0&to=5", &rsp))
+		require.Len(t, rsp.Rounds, 1)
+		require.Equal(t, uint32(votingRoundID), rsp.Rounds[0].RoundID)
+
+		require.Equal(t, http.StatusBadRequest, getStatus(t, &serverConfig, "/status/rounds?from=5&to=0", nil))
+		require.Equal(t, http.StatusBadRequest, getStatus(t, &serverConfig, "/status/rounds?from=0&to=1000", nil))
+	})
+
+	t.Run("proof", func(t *testing.T) {
+		roundID := uint32(votingRoundID)
+
+		var rsp server.ProofResponse
+		require.Equal(t, http.StatusOK, postDA(t, &serverConfig, "/da/proof", server.ProofRequest{Request: "0x" + requestEVM, RoundID: &roundID}, &rsp))
+		require.Equal(t, server.Ok, rsp.Status)
+		require.NotNil(t, rsp.Attestation)
+		require.Equal(t, requestEVM, rsp.Attestation.Request)
+		require.Equal(t, responseEVM, rsp.Attestation.Response)
+		require.Empty(t, rsp.Attestation.Proof) // the only leaf of the tree
+
+		var missing server.ProofResponse
+		require.Equal(t, http.StatusOK, postDA(t, &serverConfig, "/da/proof", server.ProofRequest{Request: "0x1234", RoundID: &roundID}, &missing))
+		require.Equal(t, server.NotAvailable, missing.Status)
+
+		require.Equal(t, http.StatusBadRequest, postDA(t, &serverConfig, "/da/proof", server.ProofRequest{Request: "0xzz"}, nil))
+	})
+
+	t.Run("proof by hash", func(t *testing.T) {
+		var rsp server.ProofResponse
+		require.Equal(t, http.StatusOK, getStatus(t, &serverConfig, "/da/proofByHash/1/"+hash.Hex(), &rsp))
+		require.Equal(t, server.Ok, rsp.Status)
+		require.Equal(t, requestEVM, rsp.Attestation.Request)
+
+		var missing server.ProofResponse
+		require.Equal(t, http.StatusOK, getStatus(t, &serverConfig, "/da/proofByHash/1/"+common.HexToHash("0x1").Hex(), &missing))
+		require.Equal(t, server.NotAvailable, missing.Status)
+
+		require.Equal(t, http.StatusBadRequest, getStatus(t, &serverConfig, "/da/proofByHash/1/0x12", nil))
+	})
+
+	t.Run("events", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost:8080/da/events?type=EVMTransaction", nil)
+		require.NoError(t, err)
+		request.Header.Set(serverConfig.APIKeyName, serverConfig.APIKeys[0])
+
+		rsp, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		defer rsp.Body.Close() //nolint:errcheck
+
+		require.Equal(t, http.StatusOK, rsp.StatusCode)
+		require.Equal(t, "text/event-stream", rsp.Header.Get("Content-Type"))
+
+		hub.Publish(events.Event{Type: events.RequestAdded, RoundID: 2, AttestationType: "Payment", Source: "BTC"})
+		hub.Publish(events.Event{Type: events.RoundCreated, RoundID: 2})
+		hub.Publish(events.Event{Type: events.AttestationVerified, RoundID: 2, AttestationType: "EVMTransaction", Source: "ETH", Status: "success"})
+
+		scanner := bufio.NewScanner(rsp.Body)
+		var received []events.Event
+		for len(received) < 2 && scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+
+			var e events.Event
+			require.NoError(t, json.Unmarshal([]byte(data), &e))
+			received = append(received, e)
+		}
+		require.NoError(t, scanner.Err())
+
+		require.Len(t, received, 2)
+		require.Equal(t, events.RoundCreated, received[0].Type)
+		require.Equal(t, events.AttestationVerified, received[1].Type)
+		require.Equal(t, "success", received[1].Status)
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		rsp, err := http.Get("http://localhost:8080/metrics")
+		require.NoError(t, err)
+		defer rsp.Body.Close() //nolint:errcheck
+
+		require.Equal(t, http.StatusOK, rsp.StatusCode)
+
+		body, err := io.ReadAll(rsp.Body)
+		require.NoError(t, err)
+
+		require.Contains(t, string(body), `fdc_fsp_responses_total{code="200",endpoint="submit2"} 1`)
+		require.Contains(t, string(body), `fdc_fsp_statuses_total{endpoint="submitSignatures",status="OK"} 1`)
+		require.Contains(t, string(body), "fdc_round_merkle_root_latency_seconds_count 1")
+	})
+
+	t.Run("health", func(t *testing.T) {
+		get := func(path string, v any) int {
+			rsp, err := http.Get("http://localhost:8080" + path)
+			require.NoError(t, err)
+			defer rsp.Body.Close() //nolint:errcheck
+
+			require.NoError(t, json.NewDecoder(rsp.Body).Decode(v))
+			return rsp.StatusCode
+		}
+
+		var live server.LiveResponse
+		require.Equal(t, http.StatusOK, get("/health/live", &live))
+		require.True(t, live.Live)
+
+		var report health.Report
+		require.Equal(t, http.StatusOK, get("/health/ready", &report))
+		require.Equal(t, health.Report{Ready: true, Checks: []health.Check{{Name: "test", OK: true}}}, report)
+
+		notReady.Store(true)
+		defer notReady.Store(false)
+
+		require.Equal(t, http.StatusServiceUnavailable, get("/health/ready", &report))
+		require.Equal(t, health.Report{Ready: false, Checks: []health.Check{{Name: "test", OK: false, Message: "not ready"}}}, report)
+	})
+
+	t.Run("health while shutting down", func(t *testing.T) {
+		drainCtx, stopDrain := context.WithCancel(context.Background())
+		stopDrain()
+		shutdownState.Drain(drainCtx, time.Second, &collector.Collector{})
+
+		rsp, err := http.Get(healthURL)
+		require.NoError(t, err)
+		defer rsp.Body.Close() //nolint:errcheck
+
+		require.Equal(t, http.StatusServiceUnavailable, rsp.StatusCode)
+	})
+}
+
+// getStatus makes an authorized GET request to path and decodes the response to v if the request succeeds.
+func getStatus(t *testing.T, cfg *config.RestServer, path string, v any) int {
+	t.Helper()
+
+	request, err := http.NewRequest(http.MethodGet, "http://localhost:8080"+path, nil)
+	require.NoError(t, err)
+	request.Header.Set(cfg.APIKeyName, cfg.APIKeys[0])
+
+	rsp, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer rsp.Body.Close() //nolint:errcheck
+
+	if rsp.StatusCode == http.StatusOK && v != nil {
+		require.NoError(t, json.NewDecoder(rsp.Body).Decode(v))
+	}
+
+	return rsp.StatusCode
+}
+
+// postDA makes an authorized POST request with body to path and decodes the response to v if the request succeeds.
+func postDA(t *testing.T, cfg *config.RestServer, path string, body, v any) int {
+	t.Helper()
+
+	encoded, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "http://localhost:8080"+path, bytes.NewReader(encoded))
+	require.NoError(t, err)
+	request.Header.Set(cfg.APIKeyName, cfg.APIKeys[0])
+	request.Header.Set("Content-Type", "application/json")
+
+	rsp, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer rsp.Body.Close() //nolint:errcheck
+
+	if rsp.StatusCode == http.StatusOK && v != nil {
+		require.NoError(t, json.NewDecoder(rsp.Body).Decode(v))
+	}
+
+	return rsp.StatusCode
 }
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/flare-foundation/go-flare-common/pkg/logger"
+	"github.com/flare-foundation/go-flare-common/pkg/restserver"
+	"github.com/flare-foundation/go-flare-common/pkg/storage"
+
+	"github.com/flare-foundation/fdc-client/client/attestation"
+	bitvotes "github.com/flare-foundation/fdc-client/client/attestation/bitVotes"
+	"github.com/flare-foundation/fdc-client/client/round"
+)
+
+const (
+	maxStatusRounds = 100              // maximal number of rounds queried at once
+	reloadTimeout   = 30 * time.Second // maximal duration of a configuration reload
+)
+
+type StatusController struct {
+	Rounds   *storage.Cyclic[uint32, *round.Round]
+	Breakers *attestation.Breakers
+	Reload   func(context.Context) error // reloads the configuration, nil if reloading is not supported
+}
+
+type ReloadResponse struct {
+	Reloaded bool
+	Error    string `json:",omitempty"`
+}
+
+type VerifiersResponse struct {
+	Verifiers []attestation.BreakerStatus
+	Parked    int // number of attestations waiting for a verifier to become available
+}
+
+type RoundStatusResponse struct {
+	Status DAResponseStatus
+	Round  *RoundDiagnostics `json:",omitempty"`
+}
+
+type ConsensusExplanationResponse struct {
+	Status      DAResponseStatus
+	Explanation *bitvotes.Explanation `json:",omitempty"`
+}
+
+type RoundsStatusResponse struct {
+	Rounds []RoundDiagnostics
+}
+
+type RoundsQuery struct {
+	From uint32 `schema:"from"`
+	To   uint32 `schema:"to"`
+}
+
+func (c *StatusController) getRoundController(
+	params map[string]string,
+	_ any,
+	_ any,
+) (RoundStatusResponse, *restserver.ErrorHandler) {
+	votingRoundID, err := validateRoundIDParam(params)
+	if err != nil {
+		logger.Error(err)
+		return RoundStatusResponse{}, restserver.BadParamsErrorHandler(err)
+	}
+
+	diagnostics, exists := c.RoundStatus(votingRoundID)
+	if !exists {
+		return RoundStatusResponse{Status: NotAvailable}, nil
+	}
+
+	return RoundStatusResponse{Status: Ok, Round: &diagnostics}, nil
+}
+
+func (c *StatusController) getConsensusController(
+	params map[string]string,
+	_ any,
+	_ any,
+) (ConsensusExplanationResponse, *restserver.ErrorHandler) {
+	votingRoundID, err := validateRoundIDParam(params)
+	if err != nil {
+		logger.Error(err)
+		return ConsensusExplanationResponse{}, restserver.BadParamsErrorHandler(err)
+	}
+
+	explanation, exists := c.ConsensusExplanation(votingRoundID)
+	if !exists {
+		return ConsensusExplanationResponse{Status: NotAvailable}, nil
+	}
+
+	return ConsensusExplanationResponse{Status: Ok, Explanation: explanation}, nil
+}
+
+func (c *StatusController) getRoundsController(
+	_ map[string]string,
+	query RoundsQuery,
+	_ any,
+) (RoundsStatusResponse, *restserver.ErrorHandler) {
+	if query.To < query.From {
+		err := fmt.Errorf("to %d before from %d", query.To, query.From)
+		return RoundsStatusResponse{}, restserver.BadParamsErrorHandler(err)
+	}
+
+	if query.To-query.From >= maxStatusRounds {
+		err := fmt.Errorf("at most %d rounds can be queried at once", maxStatusRounds)
+		return RoundsStatusResponse{}, restserver.BadParamsErrorHandler(err)
+	}
+
+	return RoundsStatusResponse{Rounds: c.RoundsStatus(query.From, query.To)}, nil
+}
+
+func (c *StatusController) getVerifiersController(
+	_ map[string]string,
+	_ any,
+	_ any,
+) (VerifiersResponse, *restserver.ErrorHandler) {
+	return c.Verifiers(), nil
+}
+
+// Verifiers returns the states of circuit breakers of the verifiers.
+func (c *StatusController) Verifiers() VerifiersResponse {
+	if c.Breakers == nil {
+		return VerifiersResponse{Verifiers: []attestation.BreakerStatus{}}
+	}
+
+	return VerifiersResponse{Verifiers: c.Breakers.Statuses(), Parked: c.Breakers.Parked()}
+}
+
+func (c *StatusController) reloadController(
+	_ map[string]string,
+	_ any,
+	_ any,
+) (ReloadResponse, *restserver.ErrorHandler) {
+	ctx, cancel := context.WithTimeout(context.Background(), reloadTimeout)
+	defer cancel()
+
+	if err := c.Reload(ctx); err != nil {
+		logger.Errorf("reloading configuration: %s", err)
+		return ReloadResponse{Error: err.Error()}, nil
+	}
+
+	return ReloadResponse{Reloaded: true}, nil
+}
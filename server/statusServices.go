@@ -0,0 +1,108 @@
+package server
+
+import (
+	"encoding/hex"
+	"slices"
+
+	"github.com/flare-foundation/fdc-client/client/attestation"
+	bitvotes "github.com/flare-foundation/fdc-client/client/attestation/bitVotes"
+	"github.com/flare-foundation/fdc-client/client/round"
+)
+
+// RoundStatus returns diagnostics of the round with roundID and a boolean indicating whether the round is stored.
+func (c *StatusController) RoundStatus(roundID uint32) (RoundDiagnostics, bool) {
+	r, exists := c.Rounds.Get(roundID)
+	if !exists {
+		return RoundDiagnostics{}, false
+	}
+
+	return roundDiagnostics(r), true
+}
+
+// ConsensusExplanation returns the explanation of the consensus bitVote of the round with roundID and true if it is available.
+func (c *StatusController) ConsensusExplanation(roundID uint32) (*bitvotes.Explanation, bool) {
+	r, exists := c.Rounds.Get(roundID)
+	if !exists {
+		return nil, false
+	}
+
+	return r.ConsensusExplanation()
+}
+
+// RoundsStatus returns diagnostics of the stored rounds with IDs from from to to (both included).
+func (c *StatusController) RoundsStatus(from, to uint32) []RoundDiagnostics {
+	rounds := make([]RoundDiagnostics, 0)
+
+	for roundID := uint64(from); roundID <= uint64(to); roundID++ {
+		diagnostics, exists := c.RoundStatus(uint32(roundID))
+		if exists {
+			rounds = append(rounds, diagnostics)
+		}
+	}
+
+	return rounds
+}
+
+func roundDiagnostics(r *round.Round) RoundDiagnostics {
+	r.Status.RLock()
+	status := r.Status.Value
+	r.Status.RUnlock()
+
+	count, weight, totalWeight := r.BitVoteParticipation()
+
+	diagnostics := RoundDiagnostics{
+		RoundID:       r.ID,
+		Status:        status.String(),
+		BitVotes:      make([]BitVoteDiagnostics, 0, count),
+		BitVoteWeight: weight,
+		TotalWeight:   totalWeight,
+		Blocking:      make([]int, 0),
+	}
+
+	for address, bitVote := range r.SubmittedBitVotes() {
+		diagnostics.BitVotes = append(diagnostics.BitVotes, BitVoteDiagnostics{
+			SubmitAddress: address.Hex(),
+			Index:         bitVote.Index,
+			Weight:        bitVote.Weight,
+			BitVote:       hexPrefix + bitVote.BitVote.EncodeBitVoteHex(),
+		})
+	}
+	slices.SortFunc(diagnostics.BitVotes, func(a, b BitVoteDiagnostics) int { return a.Index - b.Index })
+
+	r.RLock()
+	defer r.RUnlock()
+
+	diagnostics.ConsensusComputed = r.ConsensusCalculationFinished
+	if r.ConsensusBitVote.BitVector != nil {
+		diagnostics.ConsensusBitVote = hexPrefix + r.ConsensusBitVote.EncodeBitVoteHex()
+		diagnostics.ConsensusOptimal = r.ConsensusOptimal
+	}
+
+	diagnostics.Attestations = make([]AttestationDiagnostics, len(r.Attestations))
+	for i := range r.Attestations {
+		diagnostics.Attestations[i] = attestationDiagnostics(r.Attestations[i])
+
+		if diagnostics.Attestations[i].Consensus && diagnostics.Attestations[i].Status != attestation.Success.String() {
+			diagnostics.Blocking = append(diagnostics.Blocking, i)
+		}
+	}
+
+	return diagnostics
+}
+
+func attestationDiagnostics(att *attestation.Attestation) AttestationDiagnostics {
+	att.RLock()
+	defer att.RUnlock()
+
+	attType, source := att.Request.TypeAndSource()
+
+	return AttestationDiagnostics{
+		Request:   hex.EncodeToString(att.Request),
+		Type:      attType,
+		Source:    source,
+		Status:    att.Status.String(),
+		Consensus: att.Consensus,
+		Queue:     att.QueueName,
+		Attempts:  att.Attempts,
+	}
+}
@@ -2,8 +2,6 @@ package server
 
 import (
 	"github.com/flare-foundation/fdc-client/client/attestation"
-
-	"github.com/ethereum/go-ethereum/common"
 )
 
 type DAResponseStatus string
@@ -16,11 +14,12 @@ const (
 type AttestationStatus string
 
 const (
-	Valid     AttestationStatus = "OK"
-	WrongMIC  AttestationStatus = "WrongMIC"
-	FailedLUT AttestationStatus = "FailedLUT"
-	Failed    AttestationStatus = "FAILED"
-	Error     AttestationStatus = "ERROR"
+	Valid        AttestationStatus = "OK"
+	WrongMIC     AttestationStatus = "WrongMIC"
+	FailedLUT    AttestationStatus = "FailedLUT"
+	Disagreement AttestationStatus = "DISAGREEMENT" // verifiers of the source responded with different responses
+	Failed       AttestationStatus = "FAILED"
+	Error        AttestationStatus = "ERROR"
 )
 
 type DARequest struct {
@@ -37,5 +36,35 @@ type DAAttestation struct {
 	Response    string   `json:"response"`
 	ResponseABI string   `json:"abi"`
 	Proof       []string `json:"proof"`
-	hash        common.Hash
+}
+
+// RoundDiagnostics describes the state of a voting round.
+type RoundDiagnostics struct {
+	RoundID           uint32                   `json:"roundId"`
+	Status            string                   `json:"status"`
+	Attestations      []AttestationDiagnostics `json:"attestations"`
+	BitVotes          []BitVoteDiagnostics     `json:"bitVotes"`
+	BitVoteWeight     uint16                   `json:"bitVoteWeight"` // total weight of voters that submitted a valid bitVote
+	TotalWeight       uint16                   `json:"totalWeight"`
+	ConsensusComputed bool                     `json:"consensusComputed"`
+	ConsensusBitVote  string                   `json:"consensusBitVote,omitempty"`
+	ConsensusOptimal  bool                     `json:"consensusOptimal"`
+	Blocking          []int                    `json:"blocking"` // indexes of attestations chosen by the consensus bitVote without a successful verification
+}
+
+type AttestationDiagnostics struct {
+	Request   string `json:"request"`
+	Type      string `json:"type"`
+	Source    string `json:"source"`
+	Status    string `json:"status"`
+	Consensus bool   `json:"consensus"`
+	Queue     string `json:"queue"`
+	Attempts  int    `json:"attempts"`
+}
+
+type BitVoteDiagnostics struct {
+	SubmitAddress string `json:"submitAddress"`
+	Index         int    `json:"index"` // signing policy index of the voter
+	Weight        uint16 `json:"weight"`
+	BitVote       string `json:"bitVote"`
 }
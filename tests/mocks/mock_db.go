@@ -1,202 +1,84 @@
 package mocks
 
 import (
-	"bytes"
-	"context"
-	"encoding/binary"
-	"encoding/hex"
-	"math/big"
+	"fmt"
+	"time"
 
-	"github.com/flare-foundation/go-flare-common/pkg/contracts/relay"
 	"github.com/flare-foundation/go-flare-common/pkg/database"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 
-	"github.com/ethereum/go-ethereum/accounts/abi"
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/pkg/errors"
+	"github.com/flare-foundation/fdc-client/client/collector"
 )
 
-const (
-	relayContractAddrHex = "0x26A90DA287264E2E20a45d8c2c79Ca98439c5aa8"
-
-	// used by mock collector DB
-	spiLogName         = "SigningPolicyInitialized"
-	rewardEpochID      = 1
-	startVotingRoundID = 1
-	threshold          = 0
-	seed               = 0
-	voterAddrHex       = "0xac872479e5EFc21989A4183Dc580C8264C9e54f5"
-	timestamp          = 0
-)
-
-const (
-	submitContractAddrHex = "0x90C6423ec3Ea40591bAdb177171B64c7e6556028"
-	protocol              = 0xff
-	roundID               = 1
-	t0                    = 1658429955
-	roundLengthSeconds    = 90
-)
-
-var (
-	submitContractAddr = common.HexToAddress(submitContractAddrHex)
-	funcSel            = [4]byte{1, 2, 3, 4}
-	payloadMsg         = []byte{1, 2, 3, 4, 5, 6, 7, 8}
-)
-
-var relayContractAddr = common.HexToAddress(relayContractAddrHex)
-
-const lastQueriedBlock = 123
-
-type MockCollectorDB struct {
-	Logs  []database.Log
-	Txs   []database.Transaction
-	State database.State
-}
-
-func (c MockCollectorDB) FetchState(ctx context.Context) (database.State, error) {
-	return c.State, nil
+// IndexerDB is an in-memory SQLite C-chain indexer that can be seeded with logs and transactions.
+// It implements collector.IndexerSource with the same queries as the real indexer database.
+type IndexerDB struct {
+	*collector.DBSource
 }
 
-func (c MockCollectorDB) FetchLatestLogsByAddressAndTopic0(
-	ctx context.Context, addr common.Address, topic0 common.Hash, num int,
-) ([]database.Log, error) {
-	if addr != relayContractAddr {
-		return nil, errors.New("unknown address")
-	}
+// NewIndexerDB creates an empty in-memory indexer. Indexers with the same name share the database.
+func NewIndexerDB(name string) (*IndexerDB, error) {
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", name)
 
-	return c.Logs, nil
-}
-
-func (c MockCollectorDB) FetchLogsByAddressAndTopic0Timestamp(
-	ctx context.Context, addr common.Address, topic0 common.Hash, from, to int64,
-) ([]database.Log, error) {
-	if addr != relayContractAddr {
-		return nil, errors.New("unknown address")
-	}
-
-	return c.Logs, nil
-}
-
-func (c MockCollectorDB) FetchLogsByAddressAndTopic0BlockNumber(
-	ctx context.Context,
-	address common.Address,
-	topic0 common.Hash,
-	from, to int64,
-) ([]database.Log, error) {
-	return c.Logs, nil
-}
-
-func (c MockCollectorDB) FetchLogsByAddressAndTopic0FromTimestampToBlockNumber(
-	ctx context.Context,
-	address common.Address,
-	topic0 common.Hash,
-	from, to int64,
-) ([]database.Log, error) {
-	return c.Logs, nil
-}
-
-func (c MockCollectorDB) FetchTransactionsByAddressAndSelectorTimestamp(
-	ctx context.Context,
-	toAddress common.Address,
-	functionSel [4]byte,
-	from, to int64,
-) ([]database.Transaction, error) {
-	if toAddress != submitContractAddr {
-		return nil, errors.New("unknown address")
-	}
-
-	if functionSel != funcSel {
-		return nil, errors.New("unknown function selector")
-	}
-
-	return c.Txs, nil
-}
-
-func NewMockCollectorDB() (*MockCollectorDB, error) {
-	log, err := newTestLog()
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	tx, err := newTestTx()
+	err = db.AutoMigrate(&database.State{}, &database.Log{}, &database.Transaction{})
 	if err != nil {
 		return nil, err
 	}
 
-	state := database.State{Index: lastQueriedBlock}
-
-	return &MockCollectorDB{
-		Logs: []database.Log{*log}, Txs: []database.Transaction{*tx}, State: state,
-	}, nil
+	return &IndexerDB{DBSource: collector.NewDBSource(db)}, nil
 }
 
-func newTestLog() (*database.Log, error) {
-	relayABI, err := relay.RelayMetaData.GetAbi()
-	if err != nil {
-		return nil, err
-	}
-
-	event, ok := relayABI.Events[spiLogName]
-	if !ok {
-		return nil, errors.Errorf("event %s not found in ABI", spiLogName)
-	}
-
-	var indexedArgs abi.Arguments
-	for i := range event.Inputs {
-		if event.Inputs[i].Indexed {
-			indexedArgs = append(indexedArgs, event.Inputs[i])
-		}
-	}
-
-	if len(indexedArgs) != 1 {
-		return nil, errors.Errorf("unexpected number of indexed args: %d %+v", len(indexedArgs), indexedArgs)
+// SetState sets the last indexed block.
+func (i *IndexerDB) SetState(blockNumber, blockTimestamp uint64) error {
+	state := database.State{
+		Name:           "last_database_block",
+		Index:          blockNumber,
+		BlockTimestamp: blockTimestamp,
+		Updated:        time.Now(),
 	}
 
-	topic1, err := indexedArgs.Pack(big.NewInt(rewardEpochID))
+	var stored database.State
+	err := i.DB.Where("name = ?", state.Name).Limit(1).Find(&stored).Error
 	if err != nil {
-		return nil, errors.Wrap(err, "packing topic1")
+		return err
 	}
+	state.ID = stored.ID
 
-	voters := []common.Address{common.HexToAddress(voterAddrHex)}
-	weights := []uint16{1}
-	signingPolicyBytes := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	return i.DB.Save(&state).Error
+}
 
-	eventData, err := event.Inputs.NonIndexed().Pack(
-		uint32(startVotingRoundID),
-		uint16(threshold),
-		big.NewInt(seed),
-		voters,
-		weights,
-		signingPolicyBytes,
-		uint64(timestamp),
-	)
-	if err != nil {
-		return nil, errors.Wrap(err, "packing eventData")
+// AddLogs adds logs to the indexer.
+func (i *IndexerDB) AddLogs(logs ...database.Log) error {
+	if len(logs) == 0 {
+		return nil
 	}
-
-	return &database.Log{
-		Data:   hex.EncodeToString(eventData),
-		Topic0: hex.EncodeToString(event.ID[:]),
-		Topic1: hex.EncodeToString(topic1),
-		Topic2: "NULL",
-		Topic3: "NULL",
-	}, nil
+	return i.DB.Create(&logs).Error
 }
 
-func newTestTx() (*database.Transaction, error) {
-	var b bytes.Buffer
-
-	b.Write(funcSel[:])
-	b.WriteByte(protocol)
-
-	if err := binary.Write(&b, binary.BigEndian, uint32(roundID)); err != nil {
-		return nil, err
+// AddTransactions adds transactions to the indexer.
+func (i *IndexerDB) AddTransactions(txs ...database.Transaction) error {
+	if len(txs) == 0 {
+		return nil
 	}
+	return i.DB.Create(&txs).Error
+}
 
-	if err := binary.Write(&b, binary.BigEndian, uint16(len(payloadMsg))); err != nil {
-		return nil, err
+// RemoveLogs removes logs with the same transaction hash and log index from the indexer.
+func (i *IndexerDB) RemoveLogs(logs ...database.Log) error {
+	for j := range logs {
+		err := i.DB.Where("transaction_hash = ? AND log_index = ?", logs[j].TransactionHash, logs[j].LogIndex).Delete(&database.Log{}).Error
+		if err != nil {
+			return err
+		}
 	}
-
-	b.Write(payloadMsg)
-
-	return &database.Transaction{Input: hex.EncodeToString(b.Bytes())}, nil
+	return nil
 }
@@ -0,0 +1,237 @@
+package pipeline_test
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/flare-foundation/go-flare-common/pkg/contracts/relay"
+	"github.com/flare-foundation/go-flare-common/pkg/database"
+
+	"github.com/flare-foundation/fdc-client/client/attestation"
+	"github.com/flare-foundation/fdc-client/client/collector"
+	"github.com/flare-foundation/fdc-client/client/collector/registry"
+	"github.com/flare-foundation/fdc-client/client/config"
+	"github.com/flare-foundation/fdc-client/client/manager"
+	"github.com/flare-foundation/fdc-client/client/shared"
+	"github.com/flare-foundation/fdc-client/client/timing"
+	"github.com/flare-foundation/fdc-client/server"
+	"github.com/flare-foundation/fdc-client/tests/mocks"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	userFile        = "../configs/testConfig.toml"
+	systemDirectory = "../../configs/systemConfigs"
+
+	verifierPort  = 5557
+	serverAddr    = "localhost:8091"
+	rewardEpochID = 100_000
+)
+
+var (
+	voter         = common.HexToAddress("0xac872479e5EFc21989A4183Dc580C8264C9e54f5")
+	submitAddress = common.HexToAddress("0x8fe15e1048f90bc028a60007c7d5b55d9d20de66")
+)
+
+var requestLog = database.Log{
+	Data:            "0000000000000000000000000000000000000000000000000000000000000040000000000000000000000000000000000000000000000000000000000000000a000000000000000000000000000000000000000000000000000000000000014045564d5472616e73616374696f6e00000000000000000000000000000000000045544800000000000000000000000000000000000000000000000000000000005453e040c1d33d8852f82714b28959380834b66988fa0348efe38625b3320b4500000000000000000000000000000000000000000000000000000000000000204ff8da95da542ca5e013daf405d08871fdb4375ee6dec77f001e918c8cd8d1b800000000000000000000000000000000000000000000000000000000000000050000000000000000000000000000000000000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000a00000000000000000000000000000000000000000000000000000000000000000",
+	Topic0:          hex.EncodeToString(collector.AttestationRequestEventSel[:]),
+	Topic1:          "NULL",
+	Topic2:          "NULL",
+	Topic3:          "NULL",
+	TransactionHash: "e995790cdbb02e851cd767ee4f36bdf4d172b6fc210a497a505ec9c73330f5d1",
+	LogIndex:        0,
+}
+
+var testResponse = "000000000000000000000000000000000000000000000000000000000000002045564d5472616e73616374696f6e0000000000000000000000000000000000004554480000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000666853c800000000000000000000000000000000000000000000000000000000000000c000000000000000000000000000000000000000000000000000000000000001804ff8da95da542ca5e013daf405d08871fdb4375ee6dec77f001e918c8cd8d1b800000000000000000000000000000000000000000000000000000000000000050000000000000000000000000000000000000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000a000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000fbbb5500000000000000000000000000000000000000000000000000000000666853c8000000000000000000000000b8b1bca1f986c471ed3ce9586a18ca63db53080a00000000000000000000000000000000000000000000000000000000000000000000000000000000000000002ca6571daa15ce734bbd0bf27d5c9d16787fc33f000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001200000000000000000000000000000000000000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000034000000000000000000000000000000000000000000000000000000000000001e4833bf6c0000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000001a000000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000000000000000000000000000000fbbb5400000000000000000000000000000000000000000000000000000000000000a80000dae57b41b2c6153ba5398c6e89ca4977c39e11961f17eb32fb8fb642d00c1e677006353f97c936c96e46145cb65369736d83fe759392835e955f53694056023661bf961aada3e0a6722caa365ca49c0cb8fe5ae829686b4f60b3a0f00219090053635e5e8399627ea08de9c326729a9a3517aecb99e45e3d6afb25fd40b30000000000000000000000000000000000000000000000000000000000000140000000000000000000000000000000000000000000000000000000000000001c5dc7876a724e68cb21aa323b56a897c2f976d74eebecd96f6a1e324fc97d20956e62ac1d63acb20522793f1e75f761164603970641655dcbfb733a3386d7624f000000000000000000000000000000000000000000000000000000000000000ddffffffffffc0000f003c000c000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000"
+
+// indexedTopic returns hex encoded topic of an indexed event argument.
+func indexedTopic(t *testing.T, arg abi.Argument, value any) string {
+	topic, err := abi.Arguments{arg}.Pack(value)
+	require.NoError(t, err)
+
+	return hex.EncodeToString(topic)
+}
+
+// signingPolicyLog builds a SigningPolicyInitialized log with a single voter.
+func signingPolicyLog(t *testing.T, relayContract common.Address, startVotingRoundID uint32) database.Log {
+	relayABI, err := relay.RelayMetaData.GetAbi()
+	require.NoError(t, err)
+
+	event := relayABI.Events["SigningPolicyInitialized"]
+
+	data, err := event.Inputs.NonIndexed().Pack(
+		startVotingRoundID,
+		uint16(1),
+		big.NewInt(0),
+		[]common.Address{voter},
+		[]uint16{1},
+		[]byte{1, 2, 3, 4, 5, 6, 7, 8},
+		uint64(0),
+	)
+	require.NoError(t, err)
+
+	return database.Log{
+		Address:         hex.EncodeToString(relayContract[:]),
+		Data:            hex.EncodeToString(data),
+		Topic0:          hex.EncodeToString(event.ID[:]),
+		Topic1:          indexedTopic(t, event.Inputs[0], big.NewInt(rewardEpochID)),
+		Topic2:          "NULL",
+		Topic3:          "NULL",
+		TransactionHash: fmt.Sprintf("%064x", 1),
+		Timestamp:       1,
+		BlockNumber:     1,
+	}
+}
+
+// voterRegisteredLog builds a VoterRegistered log registering submitAddress for the voter.
+func voterRegisteredLog(t *testing.T, registryContract common.Address) database.Log {
+	registryABI, err := registry.RegistryMetaData.GetAbi()
+	require.NoError(t, err)
+
+	event := registryABI.Events["VoterRegistered"]
+
+	data, err := event.Inputs.NonIndexed().Pack(
+		submitAddress,
+		submitAddress,
+		struct{ X, Y [32]byte }{},
+		big.NewInt(1),
+		struct {
+			V    uint8
+			R, S [32]byte
+		}{},
+	)
+	require.NoError(t, err)
+
+	return database.Log{
+		Address:         hex.EncodeToString(registryContract[:]),
+		Data:            hex.EncodeToString(data),
+		Topic0:          hex.EncodeToString(event.ID[:]),
+		Topic1:          indexedTopic(t, event.Inputs[0], voter),
+		Topic2:          indexedTopic(t, event.Inputs[1], uint32(rewardEpochID)),
+		Topic3:          indexedTopic(t, event.Inputs[2], voter),
+		TransactionHash: fmt.Sprintf("%064x", 2),
+		Timestamp:       1,
+		BlockNumber:     1,
+	}
+}
+
+// TestPipeline runs collector, manager and server on an in-memory indexer and checks that a request
+// emitted in the current round is verified and served by the server.
+func TestPipeline(t *testing.T) {
+	userConfig, systemConfig, err := config.Read(userFile, systemDirectory)
+	require.NoError(t, err)
+
+	attestationTypeConfig, err := config.ParseAttestationTypes(userConfig.AttestationTypeConfig)
+	require.NoError(t, err)
+
+	for attType := range attestationTypeConfig {
+		for source, sourceConfig := range attestationTypeConfig[attType].SourcesConfig {
+			sourceConfig.Verifiers = []config.Verifier{{URL: fmt.Sprintf("http://localhost:%d", verifierPort)}}
+			attestationTypeConfig[attType].SourcesConfig[source] = sourceConfig
+		}
+	}
+
+	userConfig.RestServer.Addr = serverAddr
+
+	now := uint64(time.Now().Unix())
+	roundID, err := timing.RoundIDForTS(now)
+	require.NoError(t, err)
+
+	// seed the indexer
+	db, err := mocks.NewIndexerDB("pipeline")
+	require.NoError(t, err)
+
+	require.NoError(t, db.SetState(100, now))
+
+	request := requestLog
+	request.Address = hex.EncodeToString(systemConfig.Addresses.FdcContract[:])
+	request.Timestamp = now
+	request.BlockNumber = 99
+
+	voterRegistry, ok := systemConfig.VoterRegistries.ForRewardEpoch(rewardEpochID)
+	require.True(t, ok)
+
+	require.NoError(t, db.AddLogs(
+		signingPolicyLog(t, systemConfig.Addresses.RelayContract, roundID-10),
+		voterRegisteredLog(t, voterRegistry.Address),
+		request,
+	))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	go mocks.MockVerifierForTests(t, verifierPort, testResponse, request)
+
+	sharedDataPipes := shared.NewDataPipes()
+
+	col := &collector.Collector{
+		ProtocolID:            userConfig.ProtocolID,
+		SubmitContractAddress: systemConfig.Addresses.SubmitContract,
+		FdcContractAddress:    systemConfig.Addresses.FdcContract,
+		RelayContractAddress:  systemConfig.Addresses.RelayContract,
+		VoterRegistries:       systemConfig.VoterRegistries,
+
+		Source:          db,
+		SigningPolicies: sharedDataPipes.Voters,
+		BitVotes:        sharedDataPipes.BitVotes,
+		Requests:        sharedDataPipes.Requests,
+	}
+	go col.Run(ctx)
+
+	mngr, err := manager.New(userConfig, attestationTypeConfig, sharedDataPipes)
+	require.NoError(t, err)
+	go mngr.Run(ctx, cancel)
+
+	srv := server.New(&sharedDataPipes.Rounds, sharedDataPipes.Breakers, sharedDataPipes.Events, sharedDataPipes.Shutdown, sharedDataPipes.Health, nil, userConfig.ProtocolID, userConfig.RestServer)
+	go srv.Run(ctx)
+	defer srv.Shutdown()
+
+	require.Eventually(t, func() bool {
+		r, ok := sharedDataPipes.Rounds.Get(roundID)
+		if !ok || len(r.Attestations) != 1 {
+			return false
+		}
+
+		r.Attestations[0].RLock()
+		defer r.Attestations[0].RUnlock()
+
+		return r.Attestations[0].Status == attestation.Success
+	}, 20*time.Second, 100*time.Millisecond)
+
+	r, _ := sharedDataPipes.Rounds.Get(roundID)
+	bitVote, err := r.BitVote()
+	require.NoError(t, err)
+	require.Equal(t, uint16(1), bitVote.Length)
+	require.Equal(t, int64(1), bitVote.BitVector.Int64())
+
+	// requests served by the server
+	url := fmt.Sprintf("http://%s%s/getRequests/%d", serverAddr, userConfig.RestServer.DAPSubpath, roundID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	req.Header.Add(userConfig.RestServer.APIKeyName, userConfig.RestServer.APIKeys[0])
+
+	rsp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer rsp.Body.Close() //nolint:errcheck
+	require.Equal(t, http.StatusOK, rsp.StatusCode)
+
+	body, err := io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+
+	var response server.RequestsResponse
+	require.NoError(t, json.Unmarshal(body, &response))
+	require.Equal(t, server.Ok, response.Status)
+	require.Len(t, response.Requests, 1)
+	require.Equal(t, server.Valid, response.Requests[0].Status)
+}
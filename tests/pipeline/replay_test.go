@@ -0,0 +1,117 @@
+package pipeline_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flare-foundation/go-flare-common/pkg/database"
+	"github.com/flare-foundation/go-flare-common/pkg/payload"
+
+	bitvotes "github.com/flare-foundation/fdc-client/client/attestation/bitVotes"
+	"github.com/flare-foundation/fdc-client/client/collector"
+	"github.com/flare-foundation/fdc-client/client/config"
+	"github.com/flare-foundation/fdc-client/client/replay"
+	"github.com/flare-foundation/fdc-client/client/timing"
+	"github.com/flare-foundation/fdc-client/tests/mocks"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestReplay replays a past round with a single request and a bitVote from the indexer and a response from a file.
+func TestReplay(t *testing.T) {
+	userConfig, systemConfig, err := config.Read(userFile, systemDirectory)
+	require.NoError(t, err)
+
+	attestationTypeConfig, err := config.ParseAttestationTypes(userConfig.AttestationTypeConfig)
+	require.NoError(t, err)
+
+	currentRoundID, err := timing.RoundIDForTS(uint64(time.Now().Unix()))
+	require.NoError(t, err)
+	roundID := currentRoundID - 5
+
+	db, err := mocks.NewIndexerDB("replay")
+	require.NoError(t, err)
+
+	request := requestLog
+	request.Address = hex.EncodeToString(systemConfig.Addresses.FdcContract[:])
+	request.Timestamp = timing.RoundStartTS(roundID) + 1
+	request.BlockNumber = 99
+
+	policyLog := signingPolicyLog(t, systemConfig.Addresses.RelayContract, roundID-10)
+	policyLog.Timestamp = timing.RoundStartTS(roundID) - 100
+
+	voterRegistry, ok := systemConfig.VoterRegistries.ForRewardEpoch(rewardEpochID)
+	require.True(t, ok)
+
+	require.NoError(t, db.AddLogs(policyLog, voterRegisteredLog(t, voterRegistry.Address), request))
+
+	bitVote := bitvotes.BitVote{Length: 1, BitVector: big.NewInt(1)}
+	message := payload.BuildMessage(userConfig.ProtocolID, roundID, bitVote.EncodeBitVote())
+
+	require.NoError(t, db.AddTransactions(database.Transaction{
+		Hash:        fmt.Sprintf("%064x", 3),
+		FunctionSig: hex.EncodeToString(collector.Submit2FuncSel[:]),
+		Input:       hex.EncodeToString(collector.Submit2FuncSel[:]) + message[2:],
+		BlockNumber: 100,
+		FromAddress: hex.EncodeToString(submitAddress[:]),
+		ToAddress:   hex.EncodeToString(systemConfig.Addresses.SubmitContract[:]),
+		Timestamp:   timing.ChooseStartTS(roundID) + 1,
+	}))
+
+	replayer := replay.Replayer{
+		Source:          db,
+		System:          systemConfig,
+		ProtocolID:      userConfig.ProtocolID,
+		AttestationType: attestationTypeConfig,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var attestationRequest string
+
+	t.Run("without responses", func(t *testing.T) {
+		result, err := replayer.Replay(ctx, replay.Options{RoundID: roundID})
+		require.NoError(t, err)
+
+		require.Equal(t, 1, result.BitVotes)
+		require.Equal(t, "0x000101", result.ConsensusBitVote)
+		require.Empty(t, result.ConsensusError)
+		require.Empty(t, result.MerkleRoot)
+		require.NotEmpty(t, result.MerkleRootError)
+		require.Len(t, result.Attestations, 1)
+		require.True(t, result.Attestations[0].Consensus)
+
+		attestationRequest = result.Attestations[0].Request
+	})
+
+	t.Run("with responses", func(t *testing.T) {
+		responsesFile := filepath.Join(t.TempDir(), "responses.json")
+		data, err := json.Marshal([]map[string]string{{"request": "0x" + attestationRequest, "response": "0x" + testResponse}})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(responsesFile, data, 0o600))
+
+		responses, err := replay.ReadResponses(responsesFile)
+		require.NoError(t, err)
+
+		result, err := replayer.Replay(ctx, replay.Options{RoundID: roundID, Responses: responses})
+		require.NoError(t, err)
+
+		require.Empty(t, result.MerkleRootError)
+		require.Len(t, result.Attestations, 1)
+		require.Equal(t, "success", result.Attestations[0].Status)
+		require.Equal(t, result.Attestations[0].Hash, result.MerkleRoot)
+
+		var text bytes.Buffer
+		require.NoError(t, result.WriteText(&text))
+		require.Contains(t, text.String(), result.MerkleRoot)
+	})
+}